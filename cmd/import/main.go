@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/internal/client"
+)
+
+func main() {
+	apiAddress := ""
+	album := ""
+	owner := ""
+	timeout := time.Duration(0)
+	flag.StringVar(&apiAddress, "api-address", "https://localhost", "")
+	flag.StringVar(&album, "album", "", "id of the album")
+	flag.StringVar(&owner, "owner", "", "owner token of the album")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -album id -owner token file.csv|file.jsonl\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if album == "" || owner == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c, err := client.New(apiAddress, timeout)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	n, err := c.Import(album, owner, flag.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d votes imported\n", n)
+}
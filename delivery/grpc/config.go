@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"time"
+)
+
+const (
+	kb = 1 << (10 * 1)
+
+	chunkSize = 64 * kb
+)
+
+type ServerConfig struct {
+	Domain          string           `mapstructure:"GRPC_DOMAIN"`
+	Host            string           `mapstructure:"GRPC_HOST"`
+	Port            string           `mapstructure:"GRPC_PORT"             validate:"required"`
+	ShutdownTimeout time.Duration    `mapstructure:"GRPC_SHUTDOWN_TIMEOUT" validate:"required"`
+	Controller      ControllerConfig `mapstructure:",squash"`
+}
+
+type ControllerConfig struct {
+	MaxNumberOfFiles     int   `mapstructure:"CONTROLLER_MAX_NUMBER_OF_FILES"    validate:"required"`
+	MaxFileSize          int64 `mapstructure:"CONTROLLER_MAX_FILE_SIZE"          validate:"required"`
+	MaxTitleLength       int   `mapstructure:"CONTROLLER_MAX_TITLE_LENGTH"       validate:"required"`
+	MaxDescriptionLength int   `mapstructure:"CONTROLLER_MAX_DESCRIPTION_LENGTH" validate:"required"`
+	MaxCaptionLength     int   `mapstructure:"CONTROLLER_MAX_CAPTION_LENGTH"     validate:"required"`
+	MaxCodeLength        int   `mapstructure:"CONTROLLER_MAX_CODE_LENGTH"        validate:"required"`
+	MaxImportEdges       int   `mapstructure:"CONTROLLER_MAX_IMPORT_EDGES"       validate:"required"`
+	MaxImportCount       int   `mapstructure:"CONTROLLER_MAX_IMPORT_COUNT"       validate:"required"`
+}
+
+var (
+	DefaultServerConfig = ServerConfig{
+		Domain:          "",
+		Host:            "localhost",
+		Port:            "8002",
+		ShutdownTimeout: 1 * time.Second,
+		Controller:      DefaultControllerConfig,
+	}
+	DefaultControllerConfig = ControllerConfig{
+		MaxNumberOfFiles:     3,
+		MaxFileSize:          512 * kb,
+		MaxTitleLength:       16,
+		MaxDescriptionLength: 32,
+		MaxCaptionLength:     16,
+		MaxCodeLength:        16,
+		MaxImportEdges:       8,
+		MaxImportCount:       8,
+	}
+)
@@ -0,0 +1,678 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/domain/service"
+	"github.com/zitryss/aye-and-nay/pkg/base64"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/pool"
+)
+
+func newController(
+	conf ControllerConfig,
+	serv domain.Servicer,
+) controller {
+	return controller{conf: conf, serv: serv}
+}
+
+type controller struct {
+	pb.UnimplementedAyeAndNayServer
+	conf ControllerConfig
+	serv domain.Servicer
+}
+
+func (c *controller) Album(stream pb.AyeAndNay_AlbumServer) error {
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	info := req.GetAlbum()
+	if info == nil {
+		return errors.Wrap(domain.ErrMessageInvalid)
+	}
+	if info.Duration == "" {
+		return errors.Wrap(domain.ErrDurationNotSet)
+	}
+	dur, err := time.ParseDuration(info.Duration)
+	if err != nil {
+		return errors.Wrap(domain.ErrDurationInvalid)
+	}
+	if utf8.RuneCountInString(info.Title) > c.conf.MaxTitleLength {
+		return errors.Wrap(domain.ErrTitleTooLong)
+	}
+	if utf8.RuneCountInString(info.Description) > c.conf.MaxDescriptionLength {
+		return errors.Wrap(domain.ErrDescriptionTooLong)
+	}
+	if utf8.RuneCountInString(info.Code) > c.conf.MaxCodeLength {
+		return errors.Wrap(domain.ErrCodeInvalid)
+	}
+	ff, captions, err := c.files(func() (imagePart, error) { return stream.Recv() })
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer func() {
+		for _, f := range ff {
+			_ = f.Close()
+		}
+	}()
+	if len(ff) < 2 {
+		return errors.Wrap(domain.ErrNotEnoughImages)
+	}
+	opts := model.AlbumOptions{
+		Duration: dur,
+		Ranking:  info.Ranking,
+		Mode:     info.Mode,
+		Metadata: model.Metadata{Title: info.Title, Description: info.Description, Captions: captions},
+		Access:   info.Access,
+		Code:     info.Code,
+		Results:  info.Results,
+	}
+	album, owner, err := c.serv.Album(ctx, ff, opts)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	resp := &pb.AlbumResponse{Id: base64.FromUint64(album), Owner: owner}
+	err = stream.SendAndClose(resp)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (c *controller) Access(ctx context.Context, req *pb.AccessRequest) (*pb.AccessResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	pass, err := c.serv.Access(ctx, album, req.Code, credentials(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	resp := &pb.AccessResponse{}
+	if pass != 0x0 {
+		resp.Pass = base64.FromUint64(pass)
+	}
+	return resp, nil
+}
+
+func (c *controller) Pair(ctx context.Context, req *pb.PairRequest) (*pb.PairResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	voter, err := voter(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	img1, img2, err := c.serv.Pair(ctx, album, voter, credentials(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	meta, err := c.serv.Metadata(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	resp := &pb.PairResponse{}
+	resp.Title = meta.Title
+	resp.Description = meta.Description
+	resp.Img1 = &pb.PairResponse_Image{Token: base64.FromUint64(img1.Token), Src: img1.Src, Caption: img1.Caption}
+	resp.Img2 = &pb.PairResponse_Image{Token: base64.FromUint64(img2.Token), Src: img2.Src, Caption: img2.Caption}
+	return resp, nil
+}
+
+func (c *controller) Image(req *pb.ImageRequest, stream pb.AyeAndNay_ImageServer) error {
+	ctx := stream.Context()
+	token, err := base64.ToUint64(req.Token)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	f, err := c.serv.Image(ctx, token, credentials(ctx))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer f.Close()
+	b := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(f.Reader, b)
+		if n > 0 {
+			err := stream.Send(&pb.ImageResponse{Chunk: b[:n]})
+			if err != nil {
+				return errors.Wrap(err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+}
+
+func (c *controller) Vote(ctx context.Context, req *pb.VoteRequest) (*pb.VoteResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	imgFrom, err := base64.ToUint64(req.ImgFrom)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	imgTo, err := base64.ToUint64(req.ImgTo)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	voter, err := voter(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = c.serv.Vote(ctx, album, voter, imgFrom, imgTo, req.Outcome, credentials(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.VoteResponse{}, nil
+}
+
+func (c *controller) Top(ctx context.Context, req *pb.TopRequest) (*pb.TopResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	imgs, _, err := c.serv.Top(ctx, album, credentials(ctx), model.Page{})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	meta, err := c.serv.Metadata(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	resp := &pb.TopResponse{}
+	resp.Title = meta.Title
+	resp.Description = meta.Description
+	resp.Images = images(imgs)
+	return resp, nil
+}
+
+func (c *controller) Bracket(ctx context.Context, req *pb.BracketRequest) (*pb.BracketResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	tour, imgs, err := c.serv.Bracket(ctx, album, credentials(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	srcs := make(map[uint64]string, len(imgs))
+	for _, img := range imgs {
+		srcs[img.Id] = img.Src
+	}
+	resp := &pb.BracketResponse{}
+	resp.Mode = tour.Mode
+	resp.Round = int64(tour.Round)
+	resp.Rounds = int64(tour.Rounds)
+	resp.Matches = make([]*pb.BracketResponse_Match, 0, len(tour.Matches))
+	for _, m := range tour.Matches {
+		match := &pb.BracketResponse_Match{Round: int64(m.Round), Img1: srcs[m.Image1], Img2: srcs[m.Image2], Winner: srcs[m.Winner], Done: m.Done}
+		resp.Matches = append(resp.Matches, match)
+	}
+	if tour.Scores != nil {
+		resp.Standings = make([]*pb.BracketResponse_Standing, 0, len(imgs))
+		for _, img := range imgs {
+			standing := &pb.BracketResponse_Standing{Src: img.Src, Score: tour.Scores[img.Id]}
+			resp.Standings = append(resp.Standings, standing)
+		}
+	}
+	resp.Champion = srcs[tour.Champion]
+	return resp, nil
+}
+
+func (c *controller) Progress(ctx context.Context, req *pb.ProgressRequest) (*pb.ProgressResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	p, err := c.serv.Progress(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.ProgressResponse{Progress: p}, nil
+}
+
+func (c *controller) Metadata(ctx context.Context, req *pb.MetadataRequest) (*pb.MetadataResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	meta, err := c.serv.Metadata(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.MetadataResponse{Title: meta.Title, Description: meta.Description}, nil
+}
+
+func (c *controller) Results(ctx context.Context, req *pb.ResultsRequest) (*pb.ResultsResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	res, err := c.serv.Results(ctx, album, owner(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	resp := &pb.ResultsResponse{Policy: res.Policy, Available: res.Available}
+	if !res.AvailableAt.IsZero() {
+		resp.AvailableAt = res.AvailableAt.UTC().Format(time.RFC3339)
+	}
+	return resp, nil
+}
+
+func (c *controller) Events(req *pb.EventsRequest, stream pb.AyeAndNay_EventsServer) error {
+	ctx := stream.Context()
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	events, err := c.serv.Events(ctx, album, credentials(ctx))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			resp := eventsResponse(event)
+			if resp == nil {
+				continue
+			}
+			err := stream.Send(resp)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+		}
+	}
+}
+
+func eventsResponse(event model.Event) *pb.EventsResponse {
+	switch event.Type {
+	case service.EventProgress:
+		progress := &pb.EventsResponse_Progress{Progress: event.Progress}
+		return &pb.EventsResponse{Event: &pb.EventsResponse_Progress_{Progress: progress}}
+	case service.EventRatings:
+		ratings := &pb.EventsResponse_Ratings{Votes: int64(event.Votes), Images: images(event.Images)}
+		return &pb.EventsResponse{Event: &pb.EventsResponse_Ratings_{Ratings: ratings}}
+	default:
+		return nil
+	}
+}
+
+func (c *controller) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	err = c.serv.Delete(ctx, album, owner(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+func (c *controller) Freeze(ctx context.Context, req *pb.FreezeRequest) (*pb.FreezeResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	err = c.serv.Freeze(ctx, album, owner(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.FreezeResponse{}, nil
+}
+
+func (c *controller) Reopen(ctx context.Context, req *pb.ReopenRequest) (*pb.ReopenResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	err = c.serv.Reopen(ctx, album, owner(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.ReopenResponse{}, nil
+}
+
+func (c *controller) Extend(ctx context.Context, req *pb.ExtendRequest) (*pb.ExtendResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	if req.Duration == "" {
+		return nil, errors.Wrap(domain.ErrDurationNotSet)
+	}
+	dur, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrDurationInvalid)
+	}
+	err = c.serv.Extend(ctx, album, owner(ctx), dur)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.ExtendResponse{}, nil
+}
+
+func (c *controller) AddImages(stream pb.AyeAndNay_AddImagesServer) error {
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	id, ok := req.GetPart().(*pb.AddImagesRequest_Album)
+	if !ok {
+		return errors.Wrap(domain.ErrMessageInvalid)
+	}
+	album, err := base64.ToUint64(id.Album)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	ff, captions, err := c.files(func() (imagePart, error) { return stream.Recv() })
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer func() {
+		for _, f := range ff {
+			_ = f.Close()
+		}
+	}()
+	if len(ff) < 1 {
+		return errors.Wrap(domain.ErrNotEnoughImages)
+	}
+	imgs, err := c.serv.AddImages(ctx, album, owner(ctx), ff, captions)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	resp := &pb.AddImagesResponse{}
+	resp.Images = make([]string, 0, len(imgs))
+	for _, image := range imgs {
+		resp.Images = append(resp.Images, base64.FromUint64(image))
+	}
+	err = stream.SendAndClose(resp)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (c *controller) RemoveImage(ctx context.Context, req *pb.RemoveImageRequest) (*pb.RemoveImageResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	image, err := base64.ToUint64(req.Image)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	err = c.serv.RemoveImage(ctx, album, owner(ctx), image)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.RemoveImageResponse{}, nil
+}
+
+func (c *controller) Export(req *pb.ExportRequest, stream pb.AyeAndNay_ExportServer) error {
+	ctx := stream.Context()
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	exp, err := c.serv.Export(ctx, album, owner(ctx))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for _, image := range images(exp.Images) {
+		err := stream.Send(&pb.ExportResponse{Record: &pb.ExportResponse_Image{Image: image}})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	for _, e := range edges(exp.Edges) {
+		err := stream.Send(&pb.ExportResponse{Record: &pb.ExportResponse_Edge_{Edge: e}})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	if !req.Files {
+		return nil
+	}
+	for _, img := range exp.Images {
+		err := exportFile(ctx, stream, exp, img.Id)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// edges flattens the vote edges in a stable order
+func edges(edgs map[uint64]map[uint64]float64) []*pb.ExportResponse_Edge {
+	froms := maps.Keys(edgs)
+	slices.Sort(froms)
+	ee := []*pb.ExportResponse_Edge(nil)
+	for _, from := range froms {
+		tos := maps.Keys(edgs[from])
+		slices.Sort(tos)
+		for _, to := range tos {
+			e := &pb.ExportResponse_Edge{From: base64.FromUint64(from), To: base64.FromUint64(to), Weight: edgs[from][to]}
+			ee = append(ee, e)
+		}
+	}
+	return ee
+}
+
+// exportFile streams a compressed image in chunks, the images are read one
+// at a time
+func exportFile(ctx context.Context, stream pb.AyeAndNay_ExportServer, exp model.Export, image uint64) error {
+	f, err := exp.Open(ctx, image)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer f.Close()
+	id := base64.FromUint64(image)
+	b := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(f.Reader, b)
+		if n > 0 {
+			file := &pb.ExportResponse_File{Image: id, Chunk: b[:n]}
+			err := stream.Send(&pb.ExportResponse{Record: &pb.ExportResponse_File_{File: file}})
+			if err != nil {
+				return errors.Wrap(err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+}
+
+func (c *controller) Import(ctx context.Context, req *pb.ImportRequest) (*pb.ImportResponse, error) {
+	album, err := base64.ToUint64(req.Album)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInvalidId)
+	}
+	if len(req.Edges) > c.conf.MaxImportEdges {
+		return nil, errors.Wrapf(domain.ErrImportInvalid, "more than %d edges", c.conf.MaxImportEdges)
+	}
+	edgs := make([]model.Edge, 0, len(req.Edges))
+	for i, ie := range req.Edges {
+		e := model.Edge{Winner: ie.Winner, Loser: ie.Loser, Count: int(ie.Count)}
+		if e.Count == 0 {
+			e.Count = 1
+		}
+		if ie.Count > int64(c.conf.MaxImportCount) {
+			return nil, errors.Wrapf(domain.ErrImportInvalid, "edge %d: count above %d", i+1, c.conf.MaxImportCount)
+		}
+		edgs = append(edgs, e)
+	}
+	n, err := c.serv.Import(ctx, album, owner(ctx), edgs)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.ImportResponse{Votes: int64(n)}, nil
+}
+
+func (c *controller) Health(ctx context.Context, _ *pb.HealthRequest) (*pb.HealthResponse, error) {
+	_, err := c.serv.Health(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pb.HealthResponse{}, nil
+}
+
+// imagePart is a message of an upload stream, both the album and the add
+// images requests carry the images the same way
+type imagePart interface {
+	GetImage() *pb.ImageInfo
+	GetChunk() []byte
+}
+
+// files reads the images of an upload stream until the client closes it,
+// every image starts with its info followed by the chunks of its content
+func (c *controller) files(recv func() (imagePart, error)) ([]model.File, []string, error) {
+	bufs := []*bytes.Buffer(nil)
+	captions := []string(nil)
+	putBack := func() {
+		for _, buf := range bufs {
+			pool.PutBuffer(buf)
+		}
+	}
+	for {
+		part, err := recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			putBack()
+			return nil, nil, errors.Wrap(err)
+		}
+		if info := part.GetImage(); info != nil {
+			if len(bufs) == c.conf.MaxNumberOfFiles {
+				putBack()
+				return nil, nil, errors.Wrap(domain.ErrTooManyImages)
+			}
+			if utf8.RuneCountInString(info.Caption) > c.conf.MaxCaptionLength {
+				putBack()
+				return nil, nil, errors.Wrap(domain.ErrCaptionTooLong)
+			}
+			bufs = append(bufs, pool.GetBuffer())
+			captions = append(captions, info.Caption)
+			continue
+		}
+		chunk := part.GetChunk()
+		if chunk == nil || len(bufs) == 0 {
+			putBack()
+			return nil, nil, errors.Wrap(domain.ErrMessageInvalid)
+		}
+		buf := bufs[len(bufs)-1]
+		if int64(buf.Len()+len(chunk)) > c.conf.MaxFileSize {
+			putBack()
+			return nil, nil, errors.Wrap(domain.ErrImageTooLarge)
+		}
+		_, _ = buf.Write(chunk)
+	}
+	ff := make([]model.File, 0, len(bufs))
+	for _, buf := range bufs {
+		b := buf.Bytes()
+		if len(b) > 512 {
+			b = b[:512]
+		}
+		typ := http.DetectContentType(b)
+		if !strings.HasPrefix(typ, "image/") {
+			putBack()
+			return nil, nil, errors.Wrap(domain.ErrNotImage)
+		}
+	}
+	for _, buf := range bufs {
+		buf := buf
+		closeFn := func() error {
+			pool.PutBuffer(buf)
+			return nil
+		}
+		ff = append(ff, model.NewFile(buf, closeFn, int64(buf.Len())))
+	}
+	return ff, captions, nil
+}
+
+func images(imgs []model.Image) []*pb.Image {
+	if imgs == nil {
+		return nil
+	}
+	images := make([]*pb.Image, 0, len(imgs))
+	for _, img := range imgs {
+		image := &pb.Image{Id: base64.FromUint64(img.Id), Src: img.Src, Caption: img.Caption, Rating: img.Rating, RatingLow: img.RatingLow, RatingHigh: img.RatingHigh, Comparisons: int64(img.Comparisons)}
+		images = append(images, image)
+	}
+	return images
+}
+
+func get(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	vals := md.Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+func owner(ctx context.Context) string {
+	return get(ctx, "x-owner-token")
+}
+
+func credentials(ctx context.Context) model.Credentials {
+	cred := model.Credentials{}
+	cred.Code = get(ctx, "x-access-code")
+	cred.Owner = owner(ctx)
+	pass, err := base64.ToUint64(get(ctx, "x-access-pass"))
+	if err != nil {
+		return cred
+	}
+	cred.Pass = pass
+	return cred
+}
+
+func voter(ctx context.Context) (uint64, error) {
+	session := get(ctx, "x-voter-session")
+	if session == "" {
+		return 0x0, nil
+	}
+	hash := fnv.New64a()
+	_, err := io.WriteString(hash, session)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	return hash.Sum64(), nil
+}
@@ -0,0 +1,404 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/service"
+	. "github.com/zitryss/aye-and-nay/internal/testing"
+)
+
+func newMockClient(t *testing.T, err error) pb.AyeAndNayClient {
+	t.Helper()
+	serv := service.NewMock(err)
+	contr := newController(DefaultControllerConfig, serv)
+	middle := newMiddleware(limiterMockPos{})
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(middle.unary()), grpc.ChainStreamInterceptor(middle.stream()))
+	pb.RegisterAyeAndNayServer(srv, &contr)
+	return dial(t, srv)
+}
+
+func assertStatus(t *testing.T, err error, code codes.Code, appCode string, msg string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, code, st.Code())
+	assert.Equal(t, msg, st.Message())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, appCode, info.Reason)
+	assert.Equal(t, errorDomain, info.Domain)
+}
+
+func TestControllerAlbum(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	type give struct {
+		info      *pb.AlbumInfo
+		filenames []string
+	}
+	type want struct {
+		code    codes.Code
+		appCode string
+		msg     string
+	}
+	tests := []struct {
+		give
+		want
+	}{
+		{
+			give: give{
+				info:      &pb.AlbumInfo{Duration: "1h"},
+				filenames: []string{"alan.jpg"},
+			},
+			want: want{
+				code:    codes.InvalidArgument,
+				appCode: "4",
+				msg:     "not enough images",
+			},
+		},
+		{
+			give: give{
+				info:      &pb.AlbumInfo{Duration: "1h"},
+				filenames: []string{"alan.jpg", "john.bmp", "dennis.png", "tim.gif"},
+			},
+			want: want{
+				code:    codes.ResourceExhausted,
+				appCode: "5",
+				msg:     "too many images",
+			},
+		},
+		{
+			give: give{
+				info:      &pb.AlbumInfo{Duration: "1h"},
+				filenames: []string{"alan.jpg", "big.jpg"},
+			},
+			want: want{
+				code:    codes.ResourceExhausted,
+				appCode: "6",
+				msg:     "image too large",
+			},
+		},
+		{
+			give: give{
+				info:      &pb.AlbumInfo{Duration: "1h"},
+				filenames: []string{"alan.jpg", "audio.ogg"},
+			},
+			want: want{
+				code:    codes.InvalidArgument,
+				appCode: "7",
+				msg:     "unsupported media type",
+			},
+		},
+		{
+			give: give{
+				info:      &pb.AlbumInfo{},
+				filenames: []string{"alan.jpg", "john.bmp"},
+			},
+			want: want{
+				code:    codes.InvalidArgument,
+				appCode: "8",
+				msg:     "duration not set",
+			},
+		},
+		{
+			give: give{
+				info:      &pb.AlbumInfo{Duration: "1y"},
+				filenames: []string{"alan.jpg", "john.bmp"},
+			},
+			want: want{
+				code:    codes.InvalidArgument,
+				appCode: "9",
+				msg:     "duration invalid",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := newMockClient(t, nil)
+			_, err := upload(t, c, tt.give.info, tt.give.filenames...)
+			assertStatus(t, err, tt.want.code, tt.want.appCode, tt.want.msg)
+		})
+	}
+	t.Run("Positive", func(t *testing.T) {
+		c := newMockClient(t, nil)
+		resp, err := upload(t, c, &pb.AlbumInfo{Duration: "1h", Title: "Cats"}, "alan.jpg", "john.bmp", "dennis.png")
+		require.NoError(t, err)
+		assert.Equal(t, "rRsAAAAAAAA", resp.Id)
+		assert.Equal(t, "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw", resp.Owner)
+	})
+	t.Run("Negative", func(t *testing.T) {
+		c := newMockClient(t, nil)
+		stream, err := c.Album(context.Background())
+		require.NoError(t, err)
+		err = stream.Send(&pb.AlbumRequest{Part: &pb.AlbumRequest_Chunk{Chunk: []byte{0x1}}})
+		require.NoError(t, err)
+		_, err = stream.CloseAndRecv()
+		assertStatus(t, err, codes.InvalidArgument, "46", "message invalid")
+	})
+}
+
+func TestControllerAddImages(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	c := newMockClient(t, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-owner-token", "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw")
+	stream, err := c.AddImages(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&pb.AddImagesRequest{Part: &pb.AddImagesRequest_Album{Album: "rRsAAAAAAAA"}})
+	require.NoError(t, err)
+	err = stream.Send(&pb.AddImagesRequest{Part: &pb.AddImagesRequest_Image{Image: &pb.ImageInfo{Caption: "Tom"}}})
+	require.NoError(t, err)
+	b, err := io.ReadAll(Png())
+	require.NoError(t, err)
+	err = stream.Send(&pb.AddImagesRequest{Part: &pb.AddImagesRequest_Chunk{Chunk: b}})
+	require.NoError(t, err)
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HzoAAAAAAAA", "LHsAAAAAAAA"}, resp.Images)
+}
+
+func TestControllerUnary(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	c := newMockClient(t, nil)
+	ctx := context.Background()
+	t.Run("Pair", func(t *testing.T) {
+		resp, err := c.Pair(ctx, &pb.PairRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		assert.Equal(t, "Cats", resp.Title)
+		assert.Equal(t, "f8cAAAAAAAA", resp.Img1.Token)
+		assert.Equal(t, "Tom", resp.Img1.Caption)
+		assert.Equal(t, "iakAAAAAAAA", resp.Img2.Token)
+		assert.Equal(t, "Felix", resp.Img2.Caption)
+	})
+	t.Run("Vote", func(t *testing.T) {
+		_, err := c.Vote(ctx, &pb.VoteRequest{Album: "rRsAAAAAAAA", ImgFrom: "f8cAAAAAAAA", ImgTo: "iakAAAAAAAA"})
+		assert.NoError(t, err)
+	})
+	t.Run("Top", func(t *testing.T) {
+		resp, err := c.Top(ctx, &pb.TopRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		require.Len(t, resp.Images, 2)
+		assert.Equal(t, "yFwAAAAAAAA", resp.Images[0].Id)
+		assert.Equal(t, 0.5, resp.Images[0].Rating)
+		assert.Equal(t, int64(1), resp.Images[0].Comparisons)
+	})
+	t.Run("Bracket", func(t *testing.T) {
+		resp, err := c.Bracket(ctx, &pb.BracketRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		assert.Equal(t, service.ModeSingleElimination, resp.Mode)
+		require.Len(t, resp.Matches, 1)
+		assert.Equal(t, "/aye-and-nay/albums/byYAAAAAAAA/images/Oh4AAAAAAAA", resp.Champion)
+	})
+	t.Run("Results", func(t *testing.T) {
+		resp, err := c.Results(ctx, &pb.ResultsRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		assert.Equal(t, "always", resp.Policy)
+		assert.True(t, resp.Available)
+	})
+	t.Run("Access", func(t *testing.T) {
+		resp, err := c.Access(ctx, &pb.AccessRequest{Album: "rRsAAAAAAAA", Code: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, "VQoAAAAAAAA", resp.Pass)
+	})
+	t.Run("Extend", func(t *testing.T) {
+		_, err := c.Extend(ctx, &pb.ExtendRequest{Album: "rRsAAAAAAAA"})
+		assertStatus(t, err, codes.InvalidArgument, "8", "duration not set")
+	})
+	t.Run("Import", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(ctx, "x-owner-token", "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw")
+		edgs := []*pb.ImportRequest_Edge{{Winner: "yFwAAAAAAAA", Loser: "jVgAAAAAAAA", Count: 3}, {Winner: "jVgAAAAAAAA", Loser: "yFwAAAAAAAA"}}
+		resp, err := c.Import(ctx, &pb.ImportRequest{Album: "rRsAAAAAAAA", Edges: edgs})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), resp.Votes)
+		edgs = []*pb.ImportRequest_Edge{{Winner: "yFwAAAAAAAA", Loser: "jVgAAAAAAAA", Count: 9}}
+		_, err = c.Import(ctx, &pb.ImportRequest{Album: "rRsAAAAAAAA", Edges: edgs})
+		assertStatus(t, err, codes.InvalidArgument, "54", "import invalid")
+		edgs = make([]*pb.ImportRequest_Edge, DefaultControllerConfig.MaxImportEdges+1)
+		for i := range edgs {
+			edgs[i] = &pb.ImportRequest_Edge{Winner: "yFwAAAAAAAA", Loser: "jVgAAAAAAAA"}
+		}
+		_, err = c.Import(ctx, &pb.ImportRequest{Album: "rRsAAAAAAAA", Edges: edgs})
+		assertStatus(t, err, codes.InvalidArgument, "54", "import invalid")
+	})
+	t.Run("InvalidId", func(t *testing.T) {
+		_, err := c.Delete(ctx, &pb.DeleteRequest{Album: "!"})
+		assertStatus(t, err, codes.InvalidArgument, "23", "id invalid")
+	})
+}
+
+func TestControllerStream(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	c := newMockClient(t, nil)
+	ctx := context.Background()
+	t.Run("Image", func(t *testing.T) {
+		stream, err := c.Image(ctx, &pb.ImageRequest{Token: "f8cAAAAAAAA"})
+		require.NoError(t, err)
+		n := 0
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			n += len(resp.Chunk)
+		}
+		assert.Equal(t, Png().Size, int64(n))
+	})
+	t.Run("Events", func(t *testing.T) {
+		stream, err := c.Events(ctx, &pb.EventsRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, resp.GetProgress().GetProgress())
+		resp, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.GetRatings().GetVotes())
+		assert.Len(t, resp.GetRatings().GetImages(), 2)
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+	t.Run("Export", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(ctx, "x-owner-token", "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw")
+		stream, err := c.Export(ctx, &pb.ExportRequest{Album: "rRsAAAAAAAA", Files: true})
+		require.NoError(t, err)
+		imgs := []*pb.Image(nil)
+		edgs := []*pb.ExportResponse_Edge(nil)
+		files := map[string]int{}
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			switch {
+			case resp.GetImage() != nil:
+				imgs = append(imgs, resp.GetImage())
+			case resp.GetEdge() != nil:
+				edgs = append(edgs, resp.GetEdge())
+			case resp.GetFile() != nil:
+				files[resp.GetFile().Image] += len(resp.GetFile().Chunk)
+			}
+		}
+		require.Len(t, imgs, 2)
+		assert.Equal(t, "yFwAAAAAAAA", imgs[0].Id)
+		assert.Equal(t, 0.75, imgs[0].Rating)
+		require.Len(t, edgs, 1)
+		assert.Equal(t, &pb.ExportResponse_Edge{From: "jVgAAAAAAAA", To: "yFwAAAAAAAA", Weight: 1}, edgs[0])
+		assert.Equal(t, map[string]int{"yFwAAAAAAAA": int(Png().Size), "jVgAAAAAAAA": int(Png().Size)}, files)
+	})
+}
+
+func TestControllerError(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	type give struct {
+		err error
+	}
+	type want struct {
+		code    codes.Code
+		appCode string
+		msg     string
+	}
+	tests := []struct {
+		give
+		want
+	}{
+		{
+			give: give{
+				err: domain.ErrTooManyRequests,
+			},
+			want: want{
+				code:    codes.ResourceExhausted,
+				appCode: "1",
+				msg:     "too many requests",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrWrongContentType,
+			},
+			want: want{
+				code:    codes.InvalidArgument,
+				appCode: "3",
+				msg:     "unsupported media type",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrAlbumNotFound,
+			},
+			want: want{
+				code:    codes.NotFound,
+				appCode: "10",
+				msg:     "album not found",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTournamentFinished,
+			},
+			want: want{
+				code:    codes.FailedPrecondition,
+				appCode: "27",
+				msg:     "tournament finished",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrDeleteForbidden,
+			},
+			want: want{
+				code:    codes.PermissionDenied,
+				appCode: "33",
+				msg:     "not allowed to delete the album",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrBadHealthCache,
+			},
+			want: want{
+				code:    codes.Internal,
+				appCode: "21",
+				msg:     "internal server error",
+			},
+		},
+		{
+			give: give{
+				err: context.DeadlineExceeded,
+			},
+			want: want{
+				code:    codes.DeadlineExceeded,
+				appCode: "-2",
+				msg:     "internal server error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := newMockClient(t, tt.give.err)
+			_, err := c.Health(context.Background(), &pb.HealthRequest{})
+			assertStatus(t, err, tt.want.code, tt.want.appCode, tt.want.msg)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/service"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	errorDomain = "aye-and-nay"
+)
+
+func handleError(ctx context.Context, err error) error {
+	service.HandleInnerError(ctx, err)
+	return handleOuterError(err)
+}
+
+// handleOuterError turns an error into a status carrying the same message
+// as the HTTP API, the application code is attached as the error reason
+func handleOuterError(err error) error {
+	cause := errors.Cause(err)
+	if e := domain.Error(nil); errors.As(cause, &e) {
+		out := e.Outer()
+		return newStatus(statusCode(out.StatusCode), out.AppCode, out.UserMsg)
+	}
+	switch cause {
+	case context.Canceled:
+		return newStatus(codes.Canceled, -1, "internal server error")
+	case context.DeadlineExceeded:
+		return newStatus(codes.DeadlineExceeded, -2, "internal server error")
+	default:
+		return newStatus(codes.Internal, -3, "internal server error")
+	}
+}
+
+func newStatus(code codes.Code, appCode int, userMsg string) error {
+	st := status.New(code, userMsg)
+	info := &errdetails.ErrorInfo{Reason: strconv.Itoa(appCode), Domain: errorDomain}
+	stWithDetails, err := st.WithDetails(info)
+	if err != nil {
+		return st.Err()
+	}
+	return stWithDetails.Err()
+}
+
+func statusCode(httpCode int) codes.Code {
+	switch httpCode {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
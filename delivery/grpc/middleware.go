@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/internal/requestid"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+func newMiddleware(lim domain.Limiter) middleware {
+	return middleware{lim}
+}
+
+type middleware struct {
+	lim domain.Limiter
+}
+
+func (m *middleware) unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = requestid.Set(ctx)
+		resp, err := m.recover(ctx, func() (any, error) {
+			err := m.limit(ctx, info.FullMethod)
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			return handler(ctx, req)
+		})
+		if err != nil {
+			return nil, handleError(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+func (m *middleware) stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := requestid.Set(ss.Context())
+		_, err := m.recover(ctx, func() (any, error) {
+			err := m.limit(ctx, info.FullMethod)
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			return nil, handler(srv, serverStream{ss, ctx})
+		})
+		if err != nil {
+			return handleError(ctx, err)
+		}
+		return nil
+	}
+}
+
+func (m *middleware) recover(ctx context.Context, fn func() (any, error)) (resp any, e error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		err, ok := v.(error)
+		if ok {
+			e = errors.Wrap(err)
+		} else {
+			e = errors.Wrapf(domain.ErrUnknown, "%v", v)
+		}
+	}()
+	return fn()
+}
+
+func (m *middleware) limit(ctx context.Context, method string) error {
+	hash := fnv.New64a()
+	_, err := io.WriteString(hash, ip(ctx))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	allowed, err := m.lim.Allow(ctx, hash.Sum64())
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !allowed {
+		return errors.Wrap(domain.ErrTooManyRequests)
+	}
+	// a new album takes up room in the storage, it is limited far more
+	// strictly than the rest
+	if method == pb.AyeAndNay_Album_FullMethodName {
+		allowed, err := m.lim.AllowCreate(ctx, hash.Sum64())
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if !allowed {
+			return errors.Wrap(domain.ErrTooManyRequests)
+		}
+	}
+	return nil
+}
+
+func ip(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// serverStream replaces the context of a stream with the one carrying the
+// request id
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
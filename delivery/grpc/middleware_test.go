@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/service"
+)
+
+type limiterMockPos struct{}
+
+func (l limiterMockPos) Allow(_ context.Context, _ uint64) (bool, error) {
+	return true, nil
+}
+
+func (l limiterMockPos) AllowCode(_ context.Context, _ uint64) (bool, error) {
+	return true, nil
+}
+
+func (l limiterMockPos) FailCode(_ context.Context, _ uint64) error {
+	return nil
+}
+
+func (l limiterMockPos) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return true, nil
+}
+
+type limiterMockCreateNeg struct {
+	limiterMockPos
+}
+
+func (l limiterMockCreateNeg) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
+type limiterMockNeg struct{}
+
+func (l limiterMockNeg) Allow(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
+func (l limiterMockNeg) AllowCode(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
+func (l limiterMockNeg) FailCode(_ context.Context, _ uint64) error {
+	return nil
+}
+
+func (l limiterMockNeg) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
+type servicerMockPanic struct {
+	*service.Mock
+}
+
+func (s servicerMockPanic) Health(_ context.Context) (bool, error) {
+	panic("don't panic")
+}
+
+func TestMiddlewareRecover(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Parallel()
+	contr := newController(DefaultControllerConfig, servicerMockPanic{service.NewMock(nil)})
+	middle := newMiddleware(limiterMockPos{})
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(middle.unary()), grpc.ChainStreamInterceptor(middle.stream()))
+	pb.RegisterAyeAndNayServer(srv, &contr)
+	c := dial(t, srv)
+	_, err := c.Health(context.Background(), &pb.HealthRequest{})
+	assertStatus(t, err, codes.Internal, "22", "internal server error")
+	_, err = c.Progress(context.Background(), &pb.ProgressRequest{Album: "rRsAAAAAAAA"})
+	assert.NoError(t, err)
+}
+
+func TestMiddlewareLimit(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Parallel()
+	t.Run("Positive", func(t *testing.T) {
+		t.Parallel()
+		c := newMockClient(t, nil)
+		_, err := c.Health(context.Background(), &pb.HealthRequest{})
+		assert.NoError(t, err)
+	})
+	t.Run("Negative", func(t *testing.T) {
+		t.Parallel()
+		contr := newController(DefaultControllerConfig, service.NewMock(nil))
+		middle := newMiddleware(limiterMockNeg{})
+		srv := grpc.NewServer(grpc.ChainUnaryInterceptor(middle.unary()), grpc.ChainStreamInterceptor(middle.stream()))
+		pb.RegisterAyeAndNayServer(srv, &contr)
+		c := dial(t, srv)
+		_, err := c.Health(context.Background(), &pb.HealthRequest{})
+		assertStatus(t, err, codes.ResourceExhausted, "1", "too many requests")
+		stream, err := c.Events(context.Background(), &pb.EventsRequest{Album: "rRsAAAAAAAA"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assertStatus(t, err, codes.ResourceExhausted, "1", "too many requests")
+	})
+	t.Run("Negative2", func(t *testing.T) {
+		t.Parallel()
+		contr := newController(DefaultControllerConfig, service.NewMock(nil))
+		middle := newMiddleware(limiterMockCreateNeg{})
+		srv := grpc.NewServer(grpc.ChainUnaryInterceptor(middle.unary()), grpc.ChainStreamInterceptor(middle.stream()))
+		pb.RegisterAyeAndNayServer(srv, &contr)
+		c := dial(t, srv)
+		stream, err := c.Album(context.Background())
+		require.NoError(t, err)
+		_, err = stream.CloseAndRecv()
+		assertStatus(t, err, codes.ResourceExhausted, "1", "too many requests")
+		_, err = c.Health(context.Background(), &pb.HealthRequest{})
+		assert.NoError(t, err)
+	})
+}
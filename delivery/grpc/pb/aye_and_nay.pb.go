@@ -0,0 +1,4029 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: aye_and_nay.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AlbumRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Part:
+	//	*AlbumRequest_Album
+	//	*AlbumRequest_Image
+	//	*AlbumRequest_Chunk
+	Part isAlbumRequest_Part `protobuf_oneof:"part"`
+}
+
+func (x *AlbumRequest) Reset() {
+	*x = AlbumRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AlbumRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlbumRequest) ProtoMessage() {}
+
+func (x *AlbumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlbumRequest.ProtoReflect.Descriptor instead.
+func (*AlbumRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *AlbumRequest) GetPart() isAlbumRequest_Part {
+	if m != nil {
+		return m.Part
+	}
+	return nil
+}
+
+func (x *AlbumRequest) GetAlbum() *AlbumInfo {
+	if x, ok := x.GetPart().(*AlbumRequest_Album); ok {
+		return x.Album
+	}
+	return nil
+}
+
+func (x *AlbumRequest) GetImage() *ImageInfo {
+	if x, ok := x.GetPart().(*AlbumRequest_Image); ok {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *AlbumRequest) GetChunk() []byte {
+	if x, ok := x.GetPart().(*AlbumRequest_Chunk); ok {
+		return x.Chunk
+	}
+	return nil
+}
+
+type isAlbumRequest_Part interface {
+	isAlbumRequest_Part()
+}
+
+type AlbumRequest_Album struct {
+	Album *AlbumInfo `protobuf:"bytes,1,opt,name=album,proto3,oneof"`
+}
+
+type AlbumRequest_Image struct {
+	Image *ImageInfo `protobuf:"bytes,2,opt,name=image,proto3,oneof"`
+}
+
+type AlbumRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,3,opt,name=chunk,proto3,oneof"`
+}
+
+func (*AlbumRequest_Album) isAlbumRequest_Part() {}
+
+func (*AlbumRequest_Image) isAlbumRequest_Part() {}
+
+func (*AlbumRequest_Chunk) isAlbumRequest_Part() {}
+
+type AlbumInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// duration - Go duration string, e.g. "1h"
+	Duration    string `protobuf:"bytes,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	Ranking     string `protobuf:"bytes,2,opt,name=ranking,proto3" json:"ranking,omitempty"`
+	Mode        string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	Title       string `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Description string `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Access      string `protobuf:"bytes,6,opt,name=access,proto3" json:"access,omitempty"`
+	Code        string `protobuf:"bytes,7,opt,name=code,proto3" json:"code,omitempty"`
+	Results     string `protobuf:"bytes,8,opt,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *AlbumInfo) Reset() {
+	*x = AlbumInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AlbumInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlbumInfo) ProtoMessage() {}
+
+func (x *AlbumInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlbumInfo.ProtoReflect.Descriptor instead.
+func (*AlbumInfo) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AlbumInfo) GetDuration() string {
+	if x != nil {
+		return x.Duration
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetRanking() string {
+	if x != nil {
+		return x.Ranking
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetAccess() string {
+	if x != nil {
+		return x.Access
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AlbumInfo) GetResults() string {
+	if x != nil {
+		return x.Results
+	}
+	return ""
+}
+
+type ImageInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Caption string `protobuf:"bytes,1,opt,name=caption,proto3" json:"caption,omitempty"`
+}
+
+func (x *ImageInfo) Reset() {
+	*x = ImageInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageInfo) ProtoMessage() {}
+
+func (x *ImageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageInfo.ProtoReflect.Descriptor instead.
+func (*ImageInfo) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ImageInfo) GetCaption() string {
+	if x != nil {
+		return x.Caption
+	}
+	return ""
+}
+
+type AlbumResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner string `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+}
+
+func (x *AlbumResponse) Reset() {
+	*x = AlbumResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AlbumResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlbumResponse) ProtoMessage() {}
+
+func (x *AlbumResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlbumResponse.ProtoReflect.Descriptor instead.
+func (*AlbumResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AlbumResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AlbumResponse) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type AccessRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	Code  string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *AccessRequest) Reset() {
+	*x = AccessRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessRequest) ProtoMessage() {}
+
+func (x *AccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessRequest.ProtoReflect.Descriptor instead.
+func (*AccessRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AccessRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *AccessRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type AccessResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// pass - empty if the album is public
+	Pass string `protobuf:"bytes,1,opt,name=pass,proto3" json:"pass,omitempty"`
+}
+
+func (x *AccessResponse) Reset() {
+	*x = AccessResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessResponse) ProtoMessage() {}
+
+func (x *AccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessResponse.ProtoReflect.Descriptor instead.
+func (*AccessResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AccessResponse) GetPass() string {
+	if x != nil {
+		return x.Pass
+	}
+	return ""
+}
+
+type PairRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *PairRequest) Reset() {
+	*x = PairRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairRequest) ProtoMessage() {}
+
+func (x *PairRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairRequest.ProtoReflect.Descriptor instead.
+func (*PairRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PairRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type PairResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Title       string              `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description string              `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Img1        *PairResponse_Image `protobuf:"bytes,3,opt,name=img1,proto3" json:"img1,omitempty"`
+	Img2        *PairResponse_Image `protobuf:"bytes,4,opt,name=img2,proto3" json:"img2,omitempty"`
+}
+
+func (x *PairResponse) Reset() {
+	*x = PairResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairResponse) ProtoMessage() {}
+
+func (x *PairResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairResponse.ProtoReflect.Descriptor instead.
+func (*PairResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PairResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PairResponse) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PairResponse) GetImg1() *PairResponse_Image {
+	if x != nil {
+		return x.Img1
+	}
+	return nil
+}
+
+func (x *PairResponse) GetImg2() *PairResponse_Image {
+	if x != nil {
+		return x.Img2
+	}
+	return nil
+}
+
+type ImageRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (x *ImageRequest) Reset() {
+	*x = ImageRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageRequest) ProtoMessage() {}
+
+func (x *ImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageRequest.ProtoReflect.Descriptor instead.
+func (*ImageRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ImageRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type ImageResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Chunk []byte `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (x *ImageResponse) Reset() {
+	*x = ImageResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageResponse) ProtoMessage() {}
+
+func (x *ImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageResponse.ProtoReflect.Descriptor instead.
+func (*ImageResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ImageResponse) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type VoteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album   string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	ImgFrom string `protobuf:"bytes,2,opt,name=img_from,json=imgFrom,proto3" json:"img_from,omitempty"`
+	ImgTo   string `protobuf:"bytes,3,opt,name=img_to,json=imgTo,proto3" json:"img_to,omitempty"`
+	// outcome - one of "win", "tie" and "skip", empty means "win"
+	Outcome string `protobuf:"bytes,4,opt,name=outcome,proto3" json:"outcome,omitempty"`
+}
+
+func (x *VoteRequest) Reset() {
+	*x = VoteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteRequest) ProtoMessage() {}
+
+func (x *VoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteRequest.ProtoReflect.Descriptor instead.
+func (*VoteRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *VoteRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *VoteRequest) GetImgFrom() string {
+	if x != nil {
+		return x.ImgFrom
+	}
+	return ""
+}
+
+func (x *VoteRequest) GetImgTo() string {
+	if x != nil {
+		return x.ImgTo
+	}
+	return ""
+}
+
+func (x *VoteRequest) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+type VoteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *VoteResponse) Reset() {
+	*x = VoteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteResponse) ProtoMessage() {}
+
+func (x *VoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteResponse.ProtoReflect.Descriptor instead.
+func (*VoteResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{11}
+}
+
+type Image struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Src         string  `protobuf:"bytes,2,opt,name=src,proto3" json:"src,omitempty"`
+	Caption     string  `protobuf:"bytes,3,opt,name=caption,proto3" json:"caption,omitempty"`
+	Rating      float64 `protobuf:"fixed64,4,opt,name=rating,proto3" json:"rating,omitempty"`
+	RatingLow   float64 `protobuf:"fixed64,5,opt,name=rating_low,json=ratingLow,proto3" json:"rating_low,omitempty"`
+	RatingHigh  float64 `protobuf:"fixed64,6,opt,name=rating_high,json=ratingHigh,proto3" json:"rating_high,omitempty"`
+	Comparisons int64   `protobuf:"varint,7,opt,name=comparisons,proto3" json:"comparisons,omitempty"`
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Image) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Image) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *Image) GetCaption() string {
+	if x != nil {
+		return x.Caption
+	}
+	return ""
+}
+
+func (x *Image) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Image) GetRatingLow() float64 {
+	if x != nil {
+		return x.RatingLow
+	}
+	return 0
+}
+
+func (x *Image) GetRatingHigh() float64 {
+	if x != nil {
+		return x.RatingHigh
+	}
+	return 0
+}
+
+func (x *Image) GetComparisons() int64 {
+	if x != nil {
+		return x.Comparisons
+	}
+	return 0
+}
+
+type TopRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *TopRequest) Reset() {
+	*x = TopRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopRequest) ProtoMessage() {}
+
+func (x *TopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopRequest.ProtoReflect.Descriptor instead.
+func (*TopRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TopRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type TopResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Title       string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Images      []*Image `protobuf:"bytes,3,rep,name=images,proto3" json:"images,omitempty"`
+}
+
+func (x *TopResponse) Reset() {
+	*x = TopResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopResponse) ProtoMessage() {}
+
+func (x *TopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopResponse.ProtoReflect.Descriptor instead.
+func (*TopResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TopResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *TopResponse) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *TopResponse) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+type BracketRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *BracketRequest) Reset() {
+	*x = BracketRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BracketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BracketRequest) ProtoMessage() {}
+
+func (x *BracketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BracketRequest.ProtoReflect.Descriptor instead.
+func (*BracketRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BracketRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type BracketResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mode      string                      `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Round     int64                       `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	Rounds    int64                       `protobuf:"varint,3,opt,name=rounds,proto3" json:"rounds,omitempty"`
+	Matches   []*BracketResponse_Match    `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
+	Standings []*BracketResponse_Standing `protobuf:"bytes,5,rep,name=standings,proto3" json:"standings,omitempty"`
+	Champion  string                      `protobuf:"bytes,6,opt,name=champion,proto3" json:"champion,omitempty"`
+}
+
+func (x *BracketResponse) Reset() {
+	*x = BracketResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BracketResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BracketResponse) ProtoMessage() {}
+
+func (x *BracketResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BracketResponse.ProtoReflect.Descriptor instead.
+func (*BracketResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BracketResponse) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *BracketResponse) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *BracketResponse) GetRounds() int64 {
+	if x != nil {
+		return x.Rounds
+	}
+	return 0
+}
+
+func (x *BracketResponse) GetMatches() []*BracketResponse_Match {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *BracketResponse) GetStandings() []*BracketResponse_Standing {
+	if x != nil {
+		return x.Standings
+	}
+	return nil
+}
+
+func (x *BracketResponse) GetChampion() string {
+	if x != nil {
+		return x.Champion
+	}
+	return ""
+}
+
+type ProgressRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *ProgressRequest) Reset() {
+	*x = ProgressRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressRequest) ProtoMessage() {}
+
+func (x *ProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressRequest.ProtoReflect.Descriptor instead.
+func (*ProgressRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ProgressRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type ProgressResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Progress float64 `protobuf:"fixed64,1,opt,name=progress,proto3" json:"progress,omitempty"`
+}
+
+func (x *ProgressResponse) Reset() {
+	*x = ProgressResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressResponse) ProtoMessage() {}
+
+func (x *ProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressResponse.ProtoReflect.Descriptor instead.
+func (*ProgressResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ProgressResponse) GetProgress() float64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+type MetadataRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *MetadataRequest) Reset() {
+	*x = MetadataRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataRequest) ProtoMessage() {}
+
+func (x *MetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataRequest.ProtoReflect.Descriptor instead.
+func (*MetadataRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *MetadataRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type MetadataResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Title       string `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *MetadataResponse) Reset() {
+	*x = MetadataResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetadataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataResponse) ProtoMessage() {}
+
+func (x *MetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataResponse.ProtoReflect.Descriptor instead.
+func (*MetadataResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *MetadataResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MetadataResponse) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type ResultsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *ResultsRequest) Reset() {
+	*x = ResultsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultsRequest) ProtoMessage() {}
+
+func (x *ResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultsRequest.ProtoReflect.Descriptor instead.
+func (*ResultsRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ResultsRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type ResultsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Policy    string `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Available bool   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	// available_at - RFC 3339 time, empty if it is unknown
+	AvailableAt string `protobuf:"bytes,3,opt,name=available_at,json=availableAt,proto3" json:"available_at,omitempty"`
+}
+
+func (x *ResultsResponse) Reset() {
+	*x = ResultsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultsResponse) ProtoMessage() {}
+
+func (x *ResultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultsResponse.ProtoReflect.Descriptor instead.
+func (*ResultsResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ResultsResponse) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *ResultsResponse) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *ResultsResponse) GetAvailableAt() string {
+	if x != nil {
+		return x.AvailableAt
+	}
+	return ""
+}
+
+type EventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EventsRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type EventsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*EventsResponse_Progress_
+	//	*EventsResponse_Ratings_
+	Event isEventsResponse_Event `protobuf_oneof:"event"`
+}
+
+func (x *EventsResponse) Reset() {
+	*x = EventsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse) ProtoMessage() {}
+
+func (x *EventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
+func (*EventsResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{24}
+}
+
+func (m *EventsResponse) GetEvent() isEventsResponse_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *EventsResponse) GetProgress() *EventsResponse_Progress {
+	if x, ok := x.GetEvent().(*EventsResponse_Progress_); ok {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *EventsResponse) GetRatings() *EventsResponse_Ratings {
+	if x, ok := x.GetEvent().(*EventsResponse_Ratings_); ok {
+		return x.Ratings
+	}
+	return nil
+}
+
+type isEventsResponse_Event interface {
+	isEventsResponse_Event()
+}
+
+type EventsResponse_Progress_ struct {
+	Progress *EventsResponse_Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type EventsResponse_Ratings_ struct {
+	Ratings *EventsResponse_Ratings `protobuf:"bytes,2,opt,name=ratings,proto3,oneof"`
+}
+
+func (*EventsResponse_Progress_) isEventsResponse_Event() {}
+
+func (*EventsResponse_Ratings_) isEventsResponse_Event() {}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DeleteRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{26}
+}
+
+type FreezeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *FreezeRequest) Reset() {
+	*x = FreezeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FreezeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreezeRequest) ProtoMessage() {}
+
+func (x *FreezeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreezeRequest.ProtoReflect.Descriptor instead.
+func (*FreezeRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *FreezeRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type FreezeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *FreezeResponse) Reset() {
+	*x = FreezeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FreezeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreezeResponse) ProtoMessage() {}
+
+func (x *FreezeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreezeResponse.ProtoReflect.Descriptor instead.
+func (*FreezeResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{28}
+}
+
+type ReopenRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+}
+
+func (x *ReopenRequest) Reset() {
+	*x = ReopenRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReopenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReopenRequest) ProtoMessage() {}
+
+func (x *ReopenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReopenRequest.ProtoReflect.Descriptor instead.
+func (*ReopenRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReopenRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+type ReopenResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ReopenResponse) Reset() {
+	*x = ReopenResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReopenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReopenResponse) ProtoMessage() {}
+
+func (x *ReopenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReopenResponse.ProtoReflect.Descriptor instead.
+func (*ReopenResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{30}
+}
+
+type ExtendRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	// duration - Go duration string, e.g. "1h"
+	Duration string `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+}
+
+func (x *ExtendRequest) Reset() {
+	*x = ExtendRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendRequest) ProtoMessage() {}
+
+func (x *ExtendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendRequest.ProtoReflect.Descriptor instead.
+func (*ExtendRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ExtendRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *ExtendRequest) GetDuration() string {
+	if x != nil {
+		return x.Duration
+	}
+	return ""
+}
+
+type ExtendResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ExtendResponse) Reset() {
+	*x = ExtendResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendResponse) ProtoMessage() {}
+
+func (x *ExtendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendResponse.ProtoReflect.Descriptor instead.
+func (*ExtendResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{32}
+}
+
+type AddImagesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Part:
+	//	*AddImagesRequest_Album
+	//	*AddImagesRequest_Image
+	//	*AddImagesRequest_Chunk
+	Part isAddImagesRequest_Part `protobuf_oneof:"part"`
+}
+
+func (x *AddImagesRequest) Reset() {
+	*x = AddImagesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddImagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddImagesRequest) ProtoMessage() {}
+
+func (x *AddImagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddImagesRequest.ProtoReflect.Descriptor instead.
+func (*AddImagesRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{33}
+}
+
+func (m *AddImagesRequest) GetPart() isAddImagesRequest_Part {
+	if m != nil {
+		return m.Part
+	}
+	return nil
+}
+
+func (x *AddImagesRequest) GetAlbum() string {
+	if x, ok := x.GetPart().(*AddImagesRequest_Album); ok {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *AddImagesRequest) GetImage() *ImageInfo {
+	if x, ok := x.GetPart().(*AddImagesRequest_Image); ok {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *AddImagesRequest) GetChunk() []byte {
+	if x, ok := x.GetPart().(*AddImagesRequest_Chunk); ok {
+		return x.Chunk
+	}
+	return nil
+}
+
+type isAddImagesRequest_Part interface {
+	isAddImagesRequest_Part()
+}
+
+type AddImagesRequest_Album struct {
+	Album string `protobuf:"bytes,1,opt,name=album,proto3,oneof"`
+}
+
+type AddImagesRequest_Image struct {
+	Image *ImageInfo `protobuf:"bytes,2,opt,name=image,proto3,oneof"`
+}
+
+type AddImagesRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,3,opt,name=chunk,proto3,oneof"`
+}
+
+func (*AddImagesRequest_Album) isAddImagesRequest_Part() {}
+
+func (*AddImagesRequest_Image) isAddImagesRequest_Part() {}
+
+func (*AddImagesRequest_Chunk) isAddImagesRequest_Part() {}
+
+type AddImagesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Images []string `protobuf:"bytes,1,rep,name=images,proto3" json:"images,omitempty"`
+}
+
+func (x *AddImagesResponse) Reset() {
+	*x = AddImagesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddImagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddImagesResponse) ProtoMessage() {}
+
+func (x *AddImagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddImagesResponse.ProtoReflect.Descriptor instead.
+func (*AddImagesResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *AddImagesResponse) GetImages() []string {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+type RemoveImageRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	Image string `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+}
+
+func (x *RemoveImageRequest) Reset() {
+	*x = RemoveImageRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoveImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveImageRequest) ProtoMessage() {}
+
+func (x *RemoveImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveImageRequest.ProtoReflect.Descriptor instead.
+func (*RemoveImageRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RemoveImageRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *RemoveImageRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+type RemoveImageResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RemoveImageResponse) Reset() {
+	*x = RemoveImageResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoveImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveImageResponse) ProtoMessage() {}
+
+func (x *RemoveImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveImageResponse.ProtoReflect.Descriptor instead.
+func (*RemoveImageResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{36}
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	// files - also stream the compressed images
+	Files bool `protobuf:"varint,2,opt,name=files,proto3" json:"files,omitempty"`
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ExportRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetFiles() bool {
+	if x != nil {
+		return x.Files
+	}
+	return false
+}
+
+type ExportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Record:
+	//	*ExportResponse_Image
+	//	*ExportResponse_Edge_
+	//	*ExportResponse_File_
+	Record isExportResponse_Record `protobuf_oneof:"record"`
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{38}
+}
+
+func (m *ExportResponse) GetRecord() isExportResponse_Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (x *ExportResponse) GetImage() *Image {
+	if x, ok := x.GetRecord().(*ExportResponse_Image); ok {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *ExportResponse) GetEdge() *ExportResponse_Edge {
+	if x, ok := x.GetRecord().(*ExportResponse_Edge_); ok {
+		return x.Edge
+	}
+	return nil
+}
+
+func (x *ExportResponse) GetFile() *ExportResponse_File {
+	if x, ok := x.GetRecord().(*ExportResponse_File_); ok {
+		return x.File
+	}
+	return nil
+}
+
+type isExportResponse_Record interface {
+	isExportResponse_Record()
+}
+
+type ExportResponse_Image struct {
+	Image *Image `protobuf:"bytes,1,opt,name=image,proto3,oneof"`
+}
+
+type ExportResponse_Edge_ struct {
+	Edge *ExportResponse_Edge `protobuf:"bytes,2,opt,name=edge,proto3,oneof"`
+}
+
+type ExportResponse_File_ struct {
+	File *ExportResponse_File `protobuf:"bytes,3,opt,name=file,proto3,oneof"`
+}
+
+func (*ExportResponse_Image) isExportResponse_Record() {}
+
+func (*ExportResponse_Edge_) isExportResponse_Record() {}
+
+func (*ExportResponse_File_) isExportResponse_Record() {}
+
+type ImportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Album string                `protobuf:"bytes,1,opt,name=album,proto3" json:"album,omitempty"`
+	Edges []*ImportRequest_Edge `protobuf:"bytes,2,rep,name=edges,proto3" json:"edges,omitempty"`
+}
+
+func (x *ImportRequest) Reset() {
+	*x = ImportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRequest) ProtoMessage() {}
+
+func (x *ImportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRequest.ProtoReflect.Descriptor instead.
+func (*ImportRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ImportRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *ImportRequest) GetEdges() []*ImportRequest_Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+type ImportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Votes int64 `protobuf:"varint,1,opt,name=votes,proto3" json:"votes,omitempty"`
+}
+
+func (x *ImportResponse) Reset() {
+	*x = ImportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportResponse) ProtoMessage() {}
+
+func (x *ImportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportResponse.ProtoReflect.Descriptor instead.
+func (*ImportResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ImportResponse) GetVotes() int64 {
+	if x != nil {
+		return x.Votes
+	}
+	return 0
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{41}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[42]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[42]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{42}
+}
+
+type PairResponse_Image struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token   string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Src     string `protobuf:"bytes,2,opt,name=src,proto3" json:"src,omitempty"`
+	Caption string `protobuf:"bytes,3,opt,name=caption,proto3" json:"caption,omitempty"`
+}
+
+func (x *PairResponse_Image) Reset() {
+	*x = PairResponse_Image{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[43]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairResponse_Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairResponse_Image) ProtoMessage() {}
+
+func (x *PairResponse_Image) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[43]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairResponse_Image.ProtoReflect.Descriptor instead.
+func (*PairResponse_Image) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{7, 0}
+}
+
+func (x *PairResponse_Image) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *PairResponse_Image) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *PairResponse_Image) GetCaption() string {
+	if x != nil {
+		return x.Caption
+	}
+	return ""
+}
+
+type BracketResponse_Match struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Round  int64  `protobuf:"varint,1,opt,name=round,proto3" json:"round,omitempty"`
+	Img1   string `protobuf:"bytes,2,opt,name=img1,proto3" json:"img1,omitempty"`
+	Img2   string `protobuf:"bytes,3,opt,name=img2,proto3" json:"img2,omitempty"`
+	Winner string `protobuf:"bytes,4,opt,name=winner,proto3" json:"winner,omitempty"`
+	Done   bool   `protobuf:"varint,5,opt,name=done,proto3" json:"done,omitempty"`
+}
+
+func (x *BracketResponse_Match) Reset() {
+	*x = BracketResponse_Match{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[44]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BracketResponse_Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BracketResponse_Match) ProtoMessage() {}
+
+func (x *BracketResponse_Match) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[44]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BracketResponse_Match.ProtoReflect.Descriptor instead.
+func (*BracketResponse_Match) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{16, 0}
+}
+
+func (x *BracketResponse_Match) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *BracketResponse_Match) GetImg1() string {
+	if x != nil {
+		return x.Img1
+	}
+	return ""
+}
+
+func (x *BracketResponse_Match) GetImg2() string {
+	if x != nil {
+		return x.Img2
+	}
+	return ""
+}
+
+func (x *BracketResponse_Match) GetWinner() string {
+	if x != nil {
+		return x.Winner
+	}
+	return ""
+}
+
+func (x *BracketResponse_Match) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+type BracketResponse_Standing struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Src   string  `protobuf:"bytes,1,opt,name=src,proto3" json:"src,omitempty"`
+	Score float64 `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *BracketResponse_Standing) Reset() {
+	*x = BracketResponse_Standing{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[45]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BracketResponse_Standing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BracketResponse_Standing) ProtoMessage() {}
+
+func (x *BracketResponse_Standing) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[45]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BracketResponse_Standing.ProtoReflect.Descriptor instead.
+func (*BracketResponse_Standing) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{16, 1}
+}
+
+func (x *BracketResponse_Standing) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *BracketResponse_Standing) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type EventsResponse_Progress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Progress float64 `protobuf:"fixed64,1,opt,name=progress,proto3" json:"progress,omitempty"`
+}
+
+func (x *EventsResponse_Progress) Reset() {
+	*x = EventsResponse_Progress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsResponse_Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse_Progress) ProtoMessage() {}
+
+func (x *EventsResponse_Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse_Progress.ProtoReflect.Descriptor instead.
+func (*EventsResponse_Progress) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{24, 0}
+}
+
+func (x *EventsResponse_Progress) GetProgress() float64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+type EventsResponse_Ratings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Votes int64 `protobuf:"varint,1,opt,name=votes,proto3" json:"votes,omitempty"`
+	// images - empty while the results are hidden
+	Images []*Image `protobuf:"bytes,2,rep,name=images,proto3" json:"images,omitempty"`
+}
+
+func (x *EventsResponse_Ratings) Reset() {
+	*x = EventsResponse_Ratings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsResponse_Ratings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse_Ratings) ProtoMessage() {}
+
+func (x *EventsResponse_Ratings) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse_Ratings.ProtoReflect.Descriptor instead.
+func (*EventsResponse_Ratings) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{24, 1}
+}
+
+func (x *EventsResponse_Ratings) GetVotes() int64 {
+	if x != nil {
+		return x.Votes
+	}
+	return 0
+}
+
+func (x *EventsResponse_Ratings) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+type ExportResponse_Edge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// weight - a tie counts half in both directions
+	Weight float64 `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (x *ExportResponse_Edge) Reset() {
+	*x = ExportResponse_Edge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportResponse_Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse_Edge) ProtoMessage() {}
+
+func (x *ExportResponse_Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse_Edge.ProtoReflect.Descriptor instead.
+func (*ExportResponse_Edge) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{38, 0}
+}
+
+func (x *ExportResponse_Edge) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ExportResponse_Edge) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ExportResponse_Edge) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type ExportResponse_File struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// image - id of the image the chunk belongs to
+	Image string `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (x *ExportResponse_File) Reset() {
+	*x = ExportResponse_File{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[49]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportResponse_File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse_File) ProtoMessage() {}
+
+func (x *ExportResponse_File) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[49]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse_File.ProtoReflect.Descriptor instead.
+func (*ExportResponse_File) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{38, 1}
+}
+
+func (x *ExportResponse_File) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *ExportResponse_File) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type ImportRequest_Edge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Winner string `protobuf:"bytes,1,opt,name=winner,proto3" json:"winner,omitempty"`
+	Loser  string `protobuf:"bytes,2,opt,name=loser,proto3" json:"loser,omitempty"`
+	// count - number of votes the edge stands for, zero means one
+	Count int64 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *ImportRequest_Edge) Reset() {
+	*x = ImportRequest_Edge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aye_and_nay_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImportRequest_Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRequest_Edge) ProtoMessage() {}
+
+func (x *ImportRequest_Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_aye_and_nay_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRequest_Edge.ProtoReflect.Descriptor instead.
+func (*ImportRequest_Edge) Descriptor() ([]byte, []int) {
+	return file_aye_and_nay_proto_rawDescGZIP(), []int{39, 0}
+}
+
+func (x *ImportRequest_Edge) GetWinner() string {
+	if x != nil {
+		return x.Winner
+	}
+	return ""
+}
+
+func (x *ImportRequest_Edge) GetLoser() string {
+	if x != nil {
+		return x.Loser
+	}
+	return ""
+}
+
+func (x *ImportRequest_Edge) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+var File_aye_and_nay_proto protoreflect.FileDescriptor
+
+var file_aye_and_nay_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x61, 0x79, 0x65, 0x5f, 0x61, 0x6e, 0x64, 0x5f, 0x6e, 0x61, 0x79, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x22, 0x90, 0x01, 0x0a, 0x0c, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x2f, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x48, 0x00, 0x52, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x12, 0x2f, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x48, 0x00, 0x52, 0x05, 0x69,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x06, 0x0a, 0x04,
+	0x70, 0x61, 0x72, 0x74, 0x22, 0xd3, 0x01, 0x0a, 0x09, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18,
+	0x0a, 0x07, 0x72, 0x61, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x72, 0x61, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74,
+	0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x25, 0x0a, 0x09, 0x49, 0x6d,
+	0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0x35, 0x0a, 0x0d, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x22, 0x39, 0x0a, 0x0d, 0x41, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62,
+	0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x22, 0x24, 0x0a, 0x0e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x73, 0x73, 0x22, 0x23, 0x0a, 0x0b, 0x50, 0x61, 0x69,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x22, 0xfd,
+	0x01, 0x0a, 0x0c, 0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x34, 0x0a, 0x04, 0x69, 0x6d, 0x67, 0x31, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61,
+	0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x04, 0x69, 0x6d, 0x67, 0x31, 0x12, 0x34, 0x0a,
+	0x04, 0x69, 0x6d, 0x67, 0x32, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x61, 0x79,
+	0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x69, 0x72, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x04, 0x69,
+	0x6d, 0x67, 0x32, 0x1a, 0x49, 0x0a, 0x05, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x72, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x24,
+	0x0a, 0x0c, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x25, 0x0a, 0x0d, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x6f, 0x0a, 0x0b, 0x56,
+	0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d,
+	0x12, 0x19, 0x0a, 0x08, 0x69, 0x6d, 0x67, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x69, 0x6d, 0x67, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x15, 0x0a, 0x06, 0x69,
+	0x6d, 0x67, 0x5f, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6d, 0x67,
+	0x54, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x22, 0x0e, 0x0a, 0x0c,
+	0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0xbd, 0x01, 0x0a,
+	0x05, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x61,
+	0x74, 0x69, 0x6e, 0x67, 0x5f, 0x6c, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09,
+	0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x4c, 0x6f, 0x77, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x61, 0x74,
+	0x69, 0x6e, 0x67, 0x5f, 0x68, 0x69, 0x67, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a,
+	0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x48, 0x69, 0x67, 0x68, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x73, 0x22, 0x22, 0x0a, 0x0a,
+	0x54, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d,
+	0x22, 0x72, 0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2b, 0x0a, 0x06, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x06, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x73, 0x22, 0x26, 0x0a, 0x0e, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x22, 0x9b, 0x03, 0x0a,
+	0x0f, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6d, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x6f,
+	0x75, 0x6e, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x72, 0x6f, 0x75, 0x6e,
+	0x64, 0x73, 0x12, 0x3d, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x2e, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x73, 0x12, 0x44, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79,
+	0x2e, 0x76, 0x31, 0x2e, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x09, 0x73, 0x74,
+	0x61, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x6d, 0x70,
+	0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x68, 0x61, 0x6d, 0x70,
+	0x69, 0x6f, 0x6e, 0x1a, 0x71, 0x0a, 0x05, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x14, 0x0a, 0x05,
+	0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x6f, 0x75,
+	0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x6d, 0x67, 0x31, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x69, 0x6d, 0x67, 0x31, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x6d, 0x67, 0x32, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x69, 0x6d, 0x67, 0x32, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69,
+	0x6e, 0x6e, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x6e,
+	0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x1a, 0x32, 0x0a, 0x08, 0x53, 0x74, 0x61, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x72, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x27, 0x0a, 0x0f, 0x50, 0x72,
+	0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x22, 0x2e, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x22, 0x27, 0x0a, 0x0f, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x22, 0x4a, 0x0a, 0x10,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x26, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d,
+	0x22, 0x6a, 0x0a, 0x0f, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x61,
+	0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x76, 0x61,
+	0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x41, 0x74, 0x22, 0x25, 0x0a, 0x0d,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x22, 0x96, 0x02, 0x0a, 0x0e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e,
+	0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x48,
+	0x00, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x40, 0x0a, 0x07, 0x72,
+	0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x61,
+	0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x61, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x48, 0x00, 0x52, 0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x26, 0x0a,
+	0x08, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x4c, 0x0a, 0x07, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x76, 0x6f, 0x74, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x06, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e,
+	0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x06, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x73, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x25, 0x0a, 0x0d,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c,
+	0x62, 0x75, 0x6d, 0x22, 0x10, 0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x25, 0x0a, 0x0d, 0x46, 0x72, 0x65, 0x65, 0x7a, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x22, 0x10, 0x0a, 0x0e,
+	0x46, 0x72, 0x65, 0x65, 0x7a, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x25,
+	0x0a, 0x0d, 0x52, 0x65, 0x6f, 0x70, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x61, 0x6c, 0x62, 0x75, 0x6d, 0x22, 0x10, 0x0a, 0x0e, 0x52, 0x65, 0x6f, 0x70, 0x65, 0x6e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x41, 0x0a, 0x0d, 0x45, 0x78, 0x74, 0x65, 0x6e,
+	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x10, 0x0a, 0x0e, 0x45, 0x78,
+	0x74, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x7b, 0x0a, 0x10,
+	0x41, 0x64, 0x64, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x16, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x00, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x2f, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f,
+	0x48, 0x00, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x63, 0x68, 0x75,
+	0x6e, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e,
+	0x6b, 0x42, 0x06, 0x0a, 0x04, 0x70, 0x61, 0x72, 0x74, 0x22, 0x2b, 0x0a, 0x11, 0x41, 0x64, 0x64,
+	0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x22, 0x40, 0x0a, 0x12, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65,
+	0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x62,
+	0x75, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x15, 0x0a, 0x13, 0x52, 0x65, 0x6d, 0x6f,
+	0x76, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x3b, 0x0a, 0x0d, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x22, 0xb1, 0x02, 0x0a,
+	0x0e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x2b, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d,
+	0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x37, 0x0a, 0x04,
+	0x65, 0x64, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x45, 0x64, 0x67, 0x65, 0x48, 0x00, 0x52,
+	0x04, 0x65, 0x64, 0x67, 0x65, 0x12, 0x37, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x1a, 0x42,
+	0x0a, 0x04, 0x45, 0x64, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x1a, 0x32, 0x0a, 0x04, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x22, 0xa9, 0x01, 0x0a, 0x0d, 0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x36, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x2e, 0x45, 0x64, 0x67, 0x65, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73,
+	0x1a, 0x4a, 0x0a, 0x04, 0x45, 0x64, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x6e,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72,
+	0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x6c, 0x6f, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x26, 0x0a, 0x0e,
+	0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76,
+	0x6f, 0x74, 0x65, 0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x10, 0x0a, 0x0e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x88, 0x0b, 0x0a, 0x09, 0x41, 0x79, 0x65, 0x41,
+	0x6e, 0x64, 0x4e, 0x61, 0x79, 0x12, 0x42, 0x0a, 0x05, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x1a,
+	0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c,
+	0x62, 0x75, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x43, 0x0a, 0x06, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d,
+	0x0a, 0x04, 0x50, 0x61, 0x69, 0x72, 0x12, 0x19, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e,
+	0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1a, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a,
+	0x05, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e,
+	0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30,
+	0x01, 0x12, 0x3d, 0x0a, 0x04, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x61, 0x79, 0x65, 0x61,
+	0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79,
+	0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x3a, 0x0a, 0x03, 0x54, 0x6f, 0x70, 0x12, 0x18, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x07,
+	0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61,
+	0x79, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x12, 0x1d, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1e, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50,
+	0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x49, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x2e, 0x61, 0x79,
+	0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x07, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61,
+	0x79, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x45, 0x0a, 0x06, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1b, 0x2e, 0x61,
+	0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61,
+	0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x43, 0x0a, 0x06, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43,
+	0x0a, 0x06, 0x46, 0x72, 0x65, 0x65, 0x7a, 0x65, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e,
+	0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x65, 0x65, 0x7a, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61,
+	0x79, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x65, 0x65, 0x7a, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x06, 0x52, 0x65, 0x6f, 0x70, 0x65, 0x6e, 0x12, 0x1b, 0x2e,
+	0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6f,
+	0x70, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6f, 0x70, 0x65, 0x6e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x06, 0x45, 0x78, 0x74, 0x65,
+	0x6e, 0x64, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x78, 0x74, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a,
+	0x09, 0x41, 0x64, 0x64, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x12, 0x1e, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x64, 0x64, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x61, 0x79, 0x65,
+	0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x64, 0x64, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x52, 0x0a,
+	0x0b, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x20, 0x2e, 0x61,
+	0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6d, 0x6f,
+	0x76, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21,
+	0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x45, 0x0a, 0x06, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1b, 0x2e, 0x61, 0x79,
+	0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e,
+	0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x43, 0x0a, 0x06, 0x49, 0x6d, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49,
+	0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a,
+	0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x1b, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64,
+	0x6e, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x79, 0x65, 0x61, 0x6e, 0x64, 0x6e, 0x61, 0x79,
+	0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x7a, 0x69, 0x74, 0x72, 0x79, 0x73, 0x73, 0x2f, 0x61, 0x79, 0x65, 0x2d, 0x61, 0x6e, 0x64,
+	0x2d, 0x6e, 0x61, 0x79, 0x2f, 0x64, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x2f, 0x67, 0x72,
+	0x70, 0x63, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_aye_and_nay_proto_rawDescOnce sync.Once
+	file_aye_and_nay_proto_rawDescData = file_aye_and_nay_proto_rawDesc
+)
+
+func file_aye_and_nay_proto_rawDescGZIP() []byte {
+	file_aye_and_nay_proto_rawDescOnce.Do(func() {
+		file_aye_and_nay_proto_rawDescData = protoimpl.X.CompressGZIP(file_aye_and_nay_proto_rawDescData)
+	})
+	return file_aye_and_nay_proto_rawDescData
+}
+
+var file_aye_and_nay_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
+var file_aye_and_nay_proto_goTypes = []interface{}{
+	(*AlbumRequest)(nil),             // 0: ayeandnay.v1.AlbumRequest
+	(*AlbumInfo)(nil),                // 1: ayeandnay.v1.AlbumInfo
+	(*ImageInfo)(nil),                // 2: ayeandnay.v1.ImageInfo
+	(*AlbumResponse)(nil),            // 3: ayeandnay.v1.AlbumResponse
+	(*AccessRequest)(nil),            // 4: ayeandnay.v1.AccessRequest
+	(*AccessResponse)(nil),           // 5: ayeandnay.v1.AccessResponse
+	(*PairRequest)(nil),              // 6: ayeandnay.v1.PairRequest
+	(*PairResponse)(nil),             // 7: ayeandnay.v1.PairResponse
+	(*ImageRequest)(nil),             // 8: ayeandnay.v1.ImageRequest
+	(*ImageResponse)(nil),            // 9: ayeandnay.v1.ImageResponse
+	(*VoteRequest)(nil),              // 10: ayeandnay.v1.VoteRequest
+	(*VoteResponse)(nil),             // 11: ayeandnay.v1.VoteResponse
+	(*Image)(nil),                    // 12: ayeandnay.v1.Image
+	(*TopRequest)(nil),               // 13: ayeandnay.v1.TopRequest
+	(*TopResponse)(nil),              // 14: ayeandnay.v1.TopResponse
+	(*BracketRequest)(nil),           // 15: ayeandnay.v1.BracketRequest
+	(*BracketResponse)(nil),          // 16: ayeandnay.v1.BracketResponse
+	(*ProgressRequest)(nil),          // 17: ayeandnay.v1.ProgressRequest
+	(*ProgressResponse)(nil),         // 18: ayeandnay.v1.ProgressResponse
+	(*MetadataRequest)(nil),          // 19: ayeandnay.v1.MetadataRequest
+	(*MetadataResponse)(nil),         // 20: ayeandnay.v1.MetadataResponse
+	(*ResultsRequest)(nil),           // 21: ayeandnay.v1.ResultsRequest
+	(*ResultsResponse)(nil),          // 22: ayeandnay.v1.ResultsResponse
+	(*EventsRequest)(nil),            // 23: ayeandnay.v1.EventsRequest
+	(*EventsResponse)(nil),           // 24: ayeandnay.v1.EventsResponse
+	(*DeleteRequest)(nil),            // 25: ayeandnay.v1.DeleteRequest
+	(*DeleteResponse)(nil),           // 26: ayeandnay.v1.DeleteResponse
+	(*FreezeRequest)(nil),            // 27: ayeandnay.v1.FreezeRequest
+	(*FreezeResponse)(nil),           // 28: ayeandnay.v1.FreezeResponse
+	(*ReopenRequest)(nil),            // 29: ayeandnay.v1.ReopenRequest
+	(*ReopenResponse)(nil),           // 30: ayeandnay.v1.ReopenResponse
+	(*ExtendRequest)(nil),            // 31: ayeandnay.v1.ExtendRequest
+	(*ExtendResponse)(nil),           // 32: ayeandnay.v1.ExtendResponse
+	(*AddImagesRequest)(nil),         // 33: ayeandnay.v1.AddImagesRequest
+	(*AddImagesResponse)(nil),        // 34: ayeandnay.v1.AddImagesResponse
+	(*RemoveImageRequest)(nil),       // 35: ayeandnay.v1.RemoveImageRequest
+	(*RemoveImageResponse)(nil),      // 36: ayeandnay.v1.RemoveImageResponse
+	(*ExportRequest)(nil),            // 37: ayeandnay.v1.ExportRequest
+	(*ExportResponse)(nil),           // 38: ayeandnay.v1.ExportResponse
+	(*ImportRequest)(nil),            // 39: ayeandnay.v1.ImportRequest
+	(*ImportResponse)(nil),           // 40: ayeandnay.v1.ImportResponse
+	(*HealthRequest)(nil),            // 41: ayeandnay.v1.HealthRequest
+	(*HealthResponse)(nil),           // 42: ayeandnay.v1.HealthResponse
+	(*PairResponse_Image)(nil),       // 43: ayeandnay.v1.PairResponse.Image
+	(*BracketResponse_Match)(nil),    // 44: ayeandnay.v1.BracketResponse.Match
+	(*BracketResponse_Standing)(nil), // 45: ayeandnay.v1.BracketResponse.Standing
+	(*EventsResponse_Progress)(nil),  // 46: ayeandnay.v1.EventsResponse.Progress
+	(*EventsResponse_Ratings)(nil),   // 47: ayeandnay.v1.EventsResponse.Ratings
+	(*ExportResponse_Edge)(nil),      // 48: ayeandnay.v1.ExportResponse.Edge
+	(*ExportResponse_File)(nil),      // 49: ayeandnay.v1.ExportResponse.File
+	(*ImportRequest_Edge)(nil),       // 50: ayeandnay.v1.ImportRequest.Edge
+}
+var file_aye_and_nay_proto_depIdxs = []int32{
+	1,  // 0: ayeandnay.v1.AlbumRequest.album:type_name -> ayeandnay.v1.AlbumInfo
+	2,  // 1: ayeandnay.v1.AlbumRequest.image:type_name -> ayeandnay.v1.ImageInfo
+	43, // 2: ayeandnay.v1.PairResponse.img1:type_name -> ayeandnay.v1.PairResponse.Image
+	43, // 3: ayeandnay.v1.PairResponse.img2:type_name -> ayeandnay.v1.PairResponse.Image
+	12, // 4: ayeandnay.v1.TopResponse.images:type_name -> ayeandnay.v1.Image
+	44, // 5: ayeandnay.v1.BracketResponse.matches:type_name -> ayeandnay.v1.BracketResponse.Match
+	45, // 6: ayeandnay.v1.BracketResponse.standings:type_name -> ayeandnay.v1.BracketResponse.Standing
+	46, // 7: ayeandnay.v1.EventsResponse.progress:type_name -> ayeandnay.v1.EventsResponse.Progress
+	47, // 8: ayeandnay.v1.EventsResponse.ratings:type_name -> ayeandnay.v1.EventsResponse.Ratings
+	2,  // 9: ayeandnay.v1.AddImagesRequest.image:type_name -> ayeandnay.v1.ImageInfo
+	12, // 10: ayeandnay.v1.ExportResponse.image:type_name -> ayeandnay.v1.Image
+	48, // 11: ayeandnay.v1.ExportResponse.edge:type_name -> ayeandnay.v1.ExportResponse.Edge
+	49, // 12: ayeandnay.v1.ExportResponse.file:type_name -> ayeandnay.v1.ExportResponse.File
+	50, // 13: ayeandnay.v1.ImportRequest.edges:type_name -> ayeandnay.v1.ImportRequest.Edge
+	12, // 14: ayeandnay.v1.EventsResponse.Ratings.images:type_name -> ayeandnay.v1.Image
+	0,  // 15: ayeandnay.v1.AyeAndNay.Album:input_type -> ayeandnay.v1.AlbumRequest
+	4,  // 16: ayeandnay.v1.AyeAndNay.Access:input_type -> ayeandnay.v1.AccessRequest
+	6,  // 17: ayeandnay.v1.AyeAndNay.Pair:input_type -> ayeandnay.v1.PairRequest
+	8,  // 18: ayeandnay.v1.AyeAndNay.Image:input_type -> ayeandnay.v1.ImageRequest
+	10, // 19: ayeandnay.v1.AyeAndNay.Vote:input_type -> ayeandnay.v1.VoteRequest
+	13, // 20: ayeandnay.v1.AyeAndNay.Top:input_type -> ayeandnay.v1.TopRequest
+	15, // 21: ayeandnay.v1.AyeAndNay.Bracket:input_type -> ayeandnay.v1.BracketRequest
+	17, // 22: ayeandnay.v1.AyeAndNay.Progress:input_type -> ayeandnay.v1.ProgressRequest
+	19, // 23: ayeandnay.v1.AyeAndNay.Metadata:input_type -> ayeandnay.v1.MetadataRequest
+	21, // 24: ayeandnay.v1.AyeAndNay.Results:input_type -> ayeandnay.v1.ResultsRequest
+	23, // 25: ayeandnay.v1.AyeAndNay.Events:input_type -> ayeandnay.v1.EventsRequest
+	25, // 26: ayeandnay.v1.AyeAndNay.Delete:input_type -> ayeandnay.v1.DeleteRequest
+	27, // 27: ayeandnay.v1.AyeAndNay.Freeze:input_type -> ayeandnay.v1.FreezeRequest
+	29, // 28: ayeandnay.v1.AyeAndNay.Reopen:input_type -> ayeandnay.v1.ReopenRequest
+	31, // 29: ayeandnay.v1.AyeAndNay.Extend:input_type -> ayeandnay.v1.ExtendRequest
+	33, // 30: ayeandnay.v1.AyeAndNay.AddImages:input_type -> ayeandnay.v1.AddImagesRequest
+	35, // 31: ayeandnay.v1.AyeAndNay.RemoveImage:input_type -> ayeandnay.v1.RemoveImageRequest
+	37, // 32: ayeandnay.v1.AyeAndNay.Export:input_type -> ayeandnay.v1.ExportRequest
+	39, // 33: ayeandnay.v1.AyeAndNay.Import:input_type -> ayeandnay.v1.ImportRequest
+	41, // 34: ayeandnay.v1.AyeAndNay.Health:input_type -> ayeandnay.v1.HealthRequest
+	3,  // 35: ayeandnay.v1.AyeAndNay.Album:output_type -> ayeandnay.v1.AlbumResponse
+	5,  // 36: ayeandnay.v1.AyeAndNay.Access:output_type -> ayeandnay.v1.AccessResponse
+	7,  // 37: ayeandnay.v1.AyeAndNay.Pair:output_type -> ayeandnay.v1.PairResponse
+	9,  // 38: ayeandnay.v1.AyeAndNay.Image:output_type -> ayeandnay.v1.ImageResponse
+	11, // 39: ayeandnay.v1.AyeAndNay.Vote:output_type -> ayeandnay.v1.VoteResponse
+	14, // 40: ayeandnay.v1.AyeAndNay.Top:output_type -> ayeandnay.v1.TopResponse
+	16, // 41: ayeandnay.v1.AyeAndNay.Bracket:output_type -> ayeandnay.v1.BracketResponse
+	18, // 42: ayeandnay.v1.AyeAndNay.Progress:output_type -> ayeandnay.v1.ProgressResponse
+	20, // 43: ayeandnay.v1.AyeAndNay.Metadata:output_type -> ayeandnay.v1.MetadataResponse
+	22, // 44: ayeandnay.v1.AyeAndNay.Results:output_type -> ayeandnay.v1.ResultsResponse
+	24, // 45: ayeandnay.v1.AyeAndNay.Events:output_type -> ayeandnay.v1.EventsResponse
+	26, // 46: ayeandnay.v1.AyeAndNay.Delete:output_type -> ayeandnay.v1.DeleteResponse
+	28, // 47: ayeandnay.v1.AyeAndNay.Freeze:output_type -> ayeandnay.v1.FreezeResponse
+	30, // 48: ayeandnay.v1.AyeAndNay.Reopen:output_type -> ayeandnay.v1.ReopenResponse
+	32, // 49: ayeandnay.v1.AyeAndNay.Extend:output_type -> ayeandnay.v1.ExtendResponse
+	34, // 50: ayeandnay.v1.AyeAndNay.AddImages:output_type -> ayeandnay.v1.AddImagesResponse
+	36, // 51: ayeandnay.v1.AyeAndNay.RemoveImage:output_type -> ayeandnay.v1.RemoveImageResponse
+	38, // 52: ayeandnay.v1.AyeAndNay.Export:output_type -> ayeandnay.v1.ExportResponse
+	40, // 53: ayeandnay.v1.AyeAndNay.Import:output_type -> ayeandnay.v1.ImportResponse
+	42, // 54: ayeandnay.v1.AyeAndNay.Health:output_type -> ayeandnay.v1.HealthResponse
+	35, // [35:55] is the sub-list for method output_type
+	15, // [15:35] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_aye_and_nay_proto_init() }
+func file_aye_and_nay_proto_init() {
+	if File_aye_and_nay_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_aye_and_nay_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AlbumRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AlbumInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AlbumResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccessRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccessResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VoteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VoteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Image); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BracketRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BracketResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProgressRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProgressResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetadataRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetadataResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResultsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResultsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FreezeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FreezeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReopenRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReopenResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddImagesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddImagesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoveImageRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoveImageResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairResponse_Image); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BracketResponse_Match); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BracketResponse_Standing); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsResponse_Progress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventsResponse_Ratings); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportResponse_Edge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportResponse_File); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aye_and_nay_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImportRequest_Edge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_aye_and_nay_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*AlbumRequest_Album)(nil),
+		(*AlbumRequest_Image)(nil),
+		(*AlbumRequest_Chunk)(nil),
+	}
+	file_aye_and_nay_proto_msgTypes[24].OneofWrappers = []interface{}{
+		(*EventsResponse_Progress_)(nil),
+		(*EventsResponse_Ratings_)(nil),
+	}
+	file_aye_and_nay_proto_msgTypes[33].OneofWrappers = []interface{}{
+		(*AddImagesRequest_Album)(nil),
+		(*AddImagesRequest_Image)(nil),
+		(*AddImagesRequest_Chunk)(nil),
+	}
+	file_aye_and_nay_proto_msgTypes[38].OneofWrappers = []interface{}{
+		(*ExportResponse_Image)(nil),
+		(*ExportResponse_Edge_)(nil),
+		(*ExportResponse_File_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_aye_and_nay_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   51,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_aye_and_nay_proto_goTypes,
+		DependencyIndexes: file_aye_and_nay_proto_depIdxs,
+		MessageInfos:      file_aye_and_nay_proto_msgTypes,
+	}.Build()
+	File_aye_and_nay_proto = out.File
+	file_aye_and_nay_proto_rawDesc = nil
+	file_aye_and_nay_proto_goTypes = nil
+	file_aye_and_nay_proto_depIdxs = nil
+}
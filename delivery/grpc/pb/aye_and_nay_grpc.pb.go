@@ -0,0 +1,984 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: aye_and_nay.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AyeAndNay_Album_FullMethodName       = "/ayeandnay.v1.AyeAndNay/Album"
+	AyeAndNay_Access_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Access"
+	AyeAndNay_Pair_FullMethodName        = "/ayeandnay.v1.AyeAndNay/Pair"
+	AyeAndNay_Image_FullMethodName       = "/ayeandnay.v1.AyeAndNay/Image"
+	AyeAndNay_Vote_FullMethodName        = "/ayeandnay.v1.AyeAndNay/Vote"
+	AyeAndNay_Top_FullMethodName         = "/ayeandnay.v1.AyeAndNay/Top"
+	AyeAndNay_Bracket_FullMethodName     = "/ayeandnay.v1.AyeAndNay/Bracket"
+	AyeAndNay_Progress_FullMethodName    = "/ayeandnay.v1.AyeAndNay/Progress"
+	AyeAndNay_Metadata_FullMethodName    = "/ayeandnay.v1.AyeAndNay/Metadata"
+	AyeAndNay_Results_FullMethodName     = "/ayeandnay.v1.AyeAndNay/Results"
+	AyeAndNay_Events_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Events"
+	AyeAndNay_Delete_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Delete"
+	AyeAndNay_Freeze_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Freeze"
+	AyeAndNay_Reopen_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Reopen"
+	AyeAndNay_Extend_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Extend"
+	AyeAndNay_AddImages_FullMethodName   = "/ayeandnay.v1.AyeAndNay/AddImages"
+	AyeAndNay_RemoveImage_FullMethodName = "/ayeandnay.v1.AyeAndNay/RemoveImage"
+	AyeAndNay_Export_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Export"
+	AyeAndNay_Import_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Import"
+	AyeAndNay_Health_FullMethodName      = "/ayeandnay.v1.AyeAndNay/Health"
+)
+
+// AyeAndNayClient is the client API for AyeAndNay service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AyeAndNayClient interface {
+	// Album creates an album. The first message carries the album info, every
+	// image starts with an image info message followed by its chunks.
+	Album(ctx context.Context, opts ...grpc.CallOption) (AyeAndNay_AlbumClient, error)
+	Access(ctx context.Context, in *AccessRequest, opts ...grpc.CallOption) (*AccessResponse, error)
+	Pair(ctx context.Context, in *PairRequest, opts ...grpc.CallOption) (*PairResponse, error)
+	// Image streams the image in chunks.
+	Image(ctx context.Context, in *ImageRequest, opts ...grpc.CallOption) (AyeAndNay_ImageClient, error)
+	Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error)
+	Top(ctx context.Context, in *TopRequest, opts ...grpc.CallOption) (*TopResponse, error)
+	Bracket(ctx context.Context, in *BracketRequest, opts ...grpc.CallOption) (*BracketResponse, error)
+	Progress(ctx context.Context, in *ProgressRequest, opts ...grpc.CallOption) (*ProgressResponse, error)
+	Metadata(ctx context.Context, in *MetadataRequest, opts ...grpc.CallOption) (*MetadataResponse, error)
+	Results(ctx context.Context, in *ResultsRequest, opts ...grpc.CallOption) (*ResultsResponse, error)
+	// Events watches the compression progress and the leaderboard of an album
+	// until the client goes away.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (AyeAndNay_EventsClient, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	Freeze(ctx context.Context, in *FreezeRequest, opts ...grpc.CallOption) (*FreezeResponse, error)
+	Reopen(ctx context.Context, in *ReopenRequest, opts ...grpc.CallOption) (*ReopenResponse, error)
+	Extend(ctx context.Context, in *ExtendRequest, opts ...grpc.CallOption) (*ExtendResponse, error)
+	// AddImages adds images to an album. The first message carries the album
+	// id, the images follow the same way as in Album.
+	AddImages(ctx context.Context, opts ...grpc.CallOption) (AyeAndNay_AddImagesClient, error)
+	RemoveImage(ctx context.Context, in *RemoveImageRequest, opts ...grpc.CallOption) (*RemoveImageResponse, error)
+	// Export streams the ratings and then the vote edges of an album, the
+	// compressed images follow in chunks if they are asked for.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (AyeAndNay_ExportClient, error)
+	// Import adds votes to an album, the edges are refused as a whole if any
+	// of them is invalid.
+	Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type ayeAndNayClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAyeAndNayClient(cc grpc.ClientConnInterface) AyeAndNayClient {
+	return &ayeAndNayClient{cc}
+}
+
+func (c *ayeAndNayClient) Album(ctx context.Context, opts ...grpc.CallOption) (AyeAndNay_AlbumClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AyeAndNay_ServiceDesc.Streams[0], AyeAndNay_Album_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ayeAndNayAlbumClient{stream}
+	return x, nil
+}
+
+type AyeAndNay_AlbumClient interface {
+	Send(*AlbumRequest) error
+	CloseAndRecv() (*AlbumResponse, error)
+	grpc.ClientStream
+}
+
+type ayeAndNayAlbumClient struct {
+	grpc.ClientStream
+}
+
+func (x *ayeAndNayAlbumClient) Send(m *AlbumRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ayeAndNayAlbumClient) CloseAndRecv() (*AlbumResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AlbumResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ayeAndNayClient) Access(ctx context.Context, in *AccessRequest, opts ...grpc.CallOption) (*AccessResponse, error) {
+	out := new(AccessResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Access_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Pair(ctx context.Context, in *PairRequest, opts ...grpc.CallOption) (*PairResponse, error) {
+	out := new(PairResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Pair_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Image(ctx context.Context, in *ImageRequest, opts ...grpc.CallOption) (AyeAndNay_ImageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AyeAndNay_ServiceDesc.Streams[1], AyeAndNay_Image_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ayeAndNayImageClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AyeAndNay_ImageClient interface {
+	Recv() (*ImageResponse, error)
+	grpc.ClientStream
+}
+
+type ayeAndNayImageClient struct {
+	grpc.ClientStream
+}
+
+func (x *ayeAndNayImageClient) Recv() (*ImageResponse, error) {
+	m := new(ImageResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ayeAndNayClient) Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error) {
+	out := new(VoteResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Vote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Top(ctx context.Context, in *TopRequest, opts ...grpc.CallOption) (*TopResponse, error) {
+	out := new(TopResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Top_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Bracket(ctx context.Context, in *BracketRequest, opts ...grpc.CallOption) (*BracketResponse, error) {
+	out := new(BracketResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Bracket_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Progress(ctx context.Context, in *ProgressRequest, opts ...grpc.CallOption) (*ProgressResponse, error) {
+	out := new(ProgressResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Progress_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Metadata(ctx context.Context, in *MetadataRequest, opts ...grpc.CallOption) (*MetadataResponse, error) {
+	out := new(MetadataResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Metadata_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Results(ctx context.Context, in *ResultsRequest, opts ...grpc.CallOption) (*ResultsResponse, error) {
+	out := new(ResultsResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Results_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (AyeAndNay_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AyeAndNay_ServiceDesc.Streams[2], AyeAndNay_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ayeAndNayEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AyeAndNay_EventsClient interface {
+	Recv() (*EventsResponse, error)
+	grpc.ClientStream
+}
+
+type ayeAndNayEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ayeAndNayEventsClient) Recv() (*EventsResponse, error) {
+	m := new(EventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ayeAndNayClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Freeze(ctx context.Context, in *FreezeRequest, opts ...grpc.CallOption) (*FreezeResponse, error) {
+	out := new(FreezeResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Freeze_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Reopen(ctx context.Context, in *ReopenRequest, opts ...grpc.CallOption) (*ReopenResponse, error) {
+	out := new(ReopenResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Reopen_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Extend(ctx context.Context, in *ExtendRequest, opts ...grpc.CallOption) (*ExtendResponse, error) {
+	out := new(ExtendResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Extend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) AddImages(ctx context.Context, opts ...grpc.CallOption) (AyeAndNay_AddImagesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AyeAndNay_ServiceDesc.Streams[3], AyeAndNay_AddImages_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ayeAndNayAddImagesClient{stream}
+	return x, nil
+}
+
+type AyeAndNay_AddImagesClient interface {
+	Send(*AddImagesRequest) error
+	CloseAndRecv() (*AddImagesResponse, error)
+	grpc.ClientStream
+}
+
+type ayeAndNayAddImagesClient struct {
+	grpc.ClientStream
+}
+
+func (x *ayeAndNayAddImagesClient) Send(m *AddImagesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ayeAndNayAddImagesClient) CloseAndRecv() (*AddImagesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AddImagesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ayeAndNayClient) RemoveImage(ctx context.Context, in *RemoveImageRequest, opts ...grpc.CallOption) (*RemoveImageResponse, error) {
+	out := new(RemoveImageResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_RemoveImage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (AyeAndNay_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AyeAndNay_ServiceDesc.Streams[4], AyeAndNay_Export_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ayeAndNayExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AyeAndNay_ExportClient interface {
+	Recv() (*ExportResponse, error)
+	grpc.ClientStream
+}
+
+type ayeAndNayExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *ayeAndNayExportClient) Recv() (*ExportResponse, error) {
+	m := new(ExportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ayeAndNayClient) Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error) {
+	out := new(ImportResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Import_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ayeAndNayClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, AyeAndNay_Health_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AyeAndNayServer is the server API for AyeAndNay service.
+// All implementations must embed UnimplementedAyeAndNayServer
+// for forward compatibility
+type AyeAndNayServer interface {
+	// Album creates an album. The first message carries the album info, every
+	// image starts with an image info message followed by its chunks.
+	Album(AyeAndNay_AlbumServer) error
+	Access(context.Context, *AccessRequest) (*AccessResponse, error)
+	Pair(context.Context, *PairRequest) (*PairResponse, error)
+	// Image streams the image in chunks.
+	Image(*ImageRequest, AyeAndNay_ImageServer) error
+	Vote(context.Context, *VoteRequest) (*VoteResponse, error)
+	Top(context.Context, *TopRequest) (*TopResponse, error)
+	Bracket(context.Context, *BracketRequest) (*BracketResponse, error)
+	Progress(context.Context, *ProgressRequest) (*ProgressResponse, error)
+	Metadata(context.Context, *MetadataRequest) (*MetadataResponse, error)
+	Results(context.Context, *ResultsRequest) (*ResultsResponse, error)
+	// Events watches the compression progress and the leaderboard of an album
+	// until the client goes away.
+	Events(*EventsRequest, AyeAndNay_EventsServer) error
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	Freeze(context.Context, *FreezeRequest) (*FreezeResponse, error)
+	Reopen(context.Context, *ReopenRequest) (*ReopenResponse, error)
+	Extend(context.Context, *ExtendRequest) (*ExtendResponse, error)
+	// AddImages adds images to an album. The first message carries the album
+	// id, the images follow the same way as in Album.
+	AddImages(AyeAndNay_AddImagesServer) error
+	RemoveImage(context.Context, *RemoveImageRequest) (*RemoveImageResponse, error)
+	// Export streams the ratings and then the vote edges of an album, the
+	// compressed images follow in chunks if they are asked for.
+	Export(*ExportRequest, AyeAndNay_ExportServer) error
+	// Import adds votes to an album, the edges are refused as a whole if any
+	// of them is invalid.
+	Import(context.Context, *ImportRequest) (*ImportResponse, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedAyeAndNayServer()
+}
+
+// UnimplementedAyeAndNayServer must be embedded to have forward compatible implementations.
+type UnimplementedAyeAndNayServer struct {
+}
+
+func (UnimplementedAyeAndNayServer) Album(AyeAndNay_AlbumServer) error {
+	return status.Errorf(codes.Unimplemented, "method Album not implemented")
+}
+func (UnimplementedAyeAndNayServer) Access(context.Context, *AccessRequest) (*AccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Access not implemented")
+}
+func (UnimplementedAyeAndNayServer) Pair(context.Context, *PairRequest) (*PairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pair not implemented")
+}
+func (UnimplementedAyeAndNayServer) Image(*ImageRequest, AyeAndNay_ImageServer) error {
+	return status.Errorf(codes.Unimplemented, "method Image not implemented")
+}
+func (UnimplementedAyeAndNayServer) Vote(context.Context, *VoteRequest) (*VoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vote not implemented")
+}
+func (UnimplementedAyeAndNayServer) Top(context.Context, *TopRequest) (*TopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Top not implemented")
+}
+func (UnimplementedAyeAndNayServer) Bracket(context.Context, *BracketRequest) (*BracketResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Bracket not implemented")
+}
+func (UnimplementedAyeAndNayServer) Progress(context.Context, *ProgressRequest) (*ProgressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Progress not implemented")
+}
+func (UnimplementedAyeAndNayServer) Metadata(context.Context, *MetadataRequest) (*MetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Metadata not implemented")
+}
+func (UnimplementedAyeAndNayServer) Results(context.Context, *ResultsRequest) (*ResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Results not implemented")
+}
+func (UnimplementedAyeAndNayServer) Events(*EventsRequest, AyeAndNay_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedAyeAndNayServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedAyeAndNayServer) Freeze(context.Context, *FreezeRequest) (*FreezeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Freeze not implemented")
+}
+func (UnimplementedAyeAndNayServer) Reopen(context.Context, *ReopenRequest) (*ReopenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reopen not implemented")
+}
+func (UnimplementedAyeAndNayServer) Extend(context.Context, *ExtendRequest) (*ExtendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Extend not implemented")
+}
+func (UnimplementedAyeAndNayServer) AddImages(AyeAndNay_AddImagesServer) error {
+	return status.Errorf(codes.Unimplemented, "method AddImages not implemented")
+}
+func (UnimplementedAyeAndNayServer) RemoveImage(context.Context, *RemoveImageRequest) (*RemoveImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveImage not implemented")
+}
+func (UnimplementedAyeAndNayServer) Export(*ExportRequest, AyeAndNay_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedAyeAndNayServer) Import(context.Context, *ImportRequest) (*ImportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedAyeAndNayServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedAyeAndNayServer) mustEmbedUnimplementedAyeAndNayServer() {}
+
+// UnsafeAyeAndNayServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AyeAndNayServer will
+// result in compilation errors.
+type UnsafeAyeAndNayServer interface {
+	mustEmbedUnimplementedAyeAndNayServer()
+}
+
+func RegisterAyeAndNayServer(s grpc.ServiceRegistrar, srv AyeAndNayServer) {
+	s.RegisterService(&AyeAndNay_ServiceDesc, srv)
+}
+
+func _AyeAndNay_Album_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AyeAndNayServer).Album(&ayeAndNayAlbumServer{stream})
+}
+
+type AyeAndNay_AlbumServer interface {
+	SendAndClose(*AlbumResponse) error
+	Recv() (*AlbumRequest, error)
+	grpc.ServerStream
+}
+
+type ayeAndNayAlbumServer struct {
+	grpc.ServerStream
+}
+
+func (x *ayeAndNayAlbumServer) SendAndClose(m *AlbumResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ayeAndNayAlbumServer) Recv() (*AlbumRequest, error) {
+	m := new(AlbumRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _AyeAndNay_Access_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Access(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Access_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Access(ctx, req.(*AccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Pair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Pair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Pair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Pair(ctx, req.(*PairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Image_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ImageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AyeAndNayServer).Image(m, &ayeAndNayImageServer{stream})
+}
+
+type AyeAndNay_ImageServer interface {
+	Send(*ImageResponse) error
+	grpc.ServerStream
+}
+
+type ayeAndNayImageServer struct {
+	grpc.ServerStream
+}
+
+func (x *ayeAndNayImageServer) Send(m *ImageResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AyeAndNay_Vote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Vote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Vote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Vote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Top_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Top(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Top_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Top(ctx, req.(*TopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Bracket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BracketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Bracket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Bracket_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Bracket(ctx, req.(*BracketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Progress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Progress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Progress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Progress(ctx, req.(*ProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Metadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Metadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Metadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Metadata(ctx, req.(*MetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Results_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Results(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Results_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Results(ctx, req.(*ResultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AyeAndNayServer).Events(m, &ayeAndNayEventsServer{stream})
+}
+
+type AyeAndNay_EventsServer interface {
+	Send(*EventsResponse) error
+	grpc.ServerStream
+}
+
+type ayeAndNayEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ayeAndNayEventsServer) Send(m *EventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AyeAndNay_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Freeze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreezeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Freeze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Freeze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Freeze(ctx, req.(*FreezeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Reopen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReopenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Reopen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Reopen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Reopen(ctx, req.(*ReopenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Extend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Extend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Extend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Extend(ctx, req.(*ExtendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_AddImages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AyeAndNayServer).AddImages(&ayeAndNayAddImagesServer{stream})
+}
+
+type AyeAndNay_AddImagesServer interface {
+	SendAndClose(*AddImagesResponse) error
+	Recv() (*AddImagesRequest, error)
+	grpc.ServerStream
+}
+
+type ayeAndNayAddImagesServer struct {
+	grpc.ServerStream
+}
+
+func (x *ayeAndNayAddImagesServer) SendAndClose(m *AddImagesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ayeAndNayAddImagesServer) Recv() (*AddImagesRequest, error) {
+	m := new(AddImagesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _AyeAndNay_RemoveImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).RemoveImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_RemoveImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).RemoveImage(ctx, req.(*RemoveImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AyeAndNayServer).Export(m, &ayeAndNayExportServer{stream})
+}
+
+type AyeAndNay_ExportServer interface {
+	Send(*ExportResponse) error
+	grpc.ServerStream
+}
+
+type ayeAndNayExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *ayeAndNayExportServer) Send(m *ExportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AyeAndNay_Import_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Import(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Import_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Import(ctx, req.(*ImportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AyeAndNay_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AyeAndNayServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AyeAndNay_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AyeAndNayServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AyeAndNay_ServiceDesc is the grpc.ServiceDesc for AyeAndNay service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AyeAndNay_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ayeandnay.v1.AyeAndNay",
+	HandlerType: (*AyeAndNayServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Access",
+			Handler:    _AyeAndNay_Access_Handler,
+		},
+		{
+			MethodName: "Pair",
+			Handler:    _AyeAndNay_Pair_Handler,
+		},
+		{
+			MethodName: "Vote",
+			Handler:    _AyeAndNay_Vote_Handler,
+		},
+		{
+			MethodName: "Top",
+			Handler:    _AyeAndNay_Top_Handler,
+		},
+		{
+			MethodName: "Bracket",
+			Handler:    _AyeAndNay_Bracket_Handler,
+		},
+		{
+			MethodName: "Progress",
+			Handler:    _AyeAndNay_Progress_Handler,
+		},
+		{
+			MethodName: "Metadata",
+			Handler:    _AyeAndNay_Metadata_Handler,
+		},
+		{
+			MethodName: "Results",
+			Handler:    _AyeAndNay_Results_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _AyeAndNay_Delete_Handler,
+		},
+		{
+			MethodName: "Freeze",
+			Handler:    _AyeAndNay_Freeze_Handler,
+		},
+		{
+			MethodName: "Reopen",
+			Handler:    _AyeAndNay_Reopen_Handler,
+		},
+		{
+			MethodName: "Extend",
+			Handler:    _AyeAndNay_Extend_Handler,
+		},
+		{
+			MethodName: "RemoveImage",
+			Handler:    _AyeAndNay_RemoveImage_Handler,
+		},
+		{
+			MethodName: "Import",
+			Handler:    _AyeAndNay_Import_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _AyeAndNay_Health_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Album",
+			Handler:       _AyeAndNay_Album_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Image",
+			Handler:       _AyeAndNay_Image_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Events",
+			Handler:       _AyeAndNay_Events_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "AddImages",
+			Handler:       _AyeAndNay_AddImages_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _AyeAndNay_Export_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "aye_and_nay.proto",
+}
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+func NewServer(
+	conf ServerConfig,
+	serv domain.Servicer,
+	lim domain.Limiter,
+	serverWait chan<- error,
+) (*Server, error) {
+	contr := newController(conf.Controller, serv)
+	middle := newMiddleware(lim)
+	srv, err := newServer(conf, middle)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	pb.RegisterAyeAndNayServer(srv, &contr)
+	return &Server{conf, srv, serverWait}, nil
+}
+
+func newServer(conf ServerConfig, middle middleware) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(middle.unary()),
+		grpc.ChainStreamInterceptor(middle.stream()),
+	}
+	if conf.Domain != "" {
+		tlsConfig, err := certmagic.TLS([]string{conf.Domain})
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		opts = append(opts, grpc.Creds(grpccredentials.NewTLS(tlsConfig)))
+	}
+	return grpc.NewServer(opts...), nil
+}
+
+type Server struct {
+	conf       ServerConfig
+	srv        *grpc.Server
+	serverWait chan<- error
+}
+
+func (s *Server) Monitor(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.shutdown()
+	}()
+}
+
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", s.conf.Host+":"+s.conf.Port)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.srv.Serve(lis)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// shutdown waits for the running calls to finish, the streams that outlive
+// the timeout are cut off
+func (s *Server) shutdown() {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	timer := time.NewTimer(s.conf.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		s.serverWait <- nil
+	case <-timer.C:
+		s.srv.Stop()
+		s.serverWait <- errors.Wrap(context.DeadlineExceeded)
+	}
+}
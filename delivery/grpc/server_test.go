@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/zitryss/aye-and-nay/delivery/grpc/pb"
+	"github.com/zitryss/aye-and-nay/domain/service"
+	"github.com/zitryss/aye-and-nay/infrastructure/cache"
+	"github.com/zitryss/aye-and-nay/infrastructure/compressor"
+	"github.com/zitryss/aye-and-nay/infrastructure/database"
+	"github.com/zitryss/aye-and-nay/infrastructure/storage"
+)
+
+var (
+	unit        = flag.Bool("unit", false, "")
+	integration = flag.Bool("int", false, "")
+	ci          = flag.Bool("ci", false, "")
+)
+
+func TestServer(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Parallel()
+	comp := compressor.NewMock()
+	stor := storage.NewMock()
+	data := database.NewMem(database.DefaultMemConfig)
+	cach := cache.NewMem(cache.DefaultMemConfig)
+	qCalc := &service.QueueCalc{}
+	qComp := &service.QueueComp{}
+	qDel := &service.QueueDel{}
+	serv := service.New(service.DefaultServiceConfig, comp, stor, data, cach, qCalc, qComp, qDel)
+
+	srvWait := make(chan error, 1)
+	srv, err := NewServer(DefaultServerConfig, serv, cach, srvWait)
+	require.NoError(t, err)
+	c := dial(t, srv.srv)
+	ctx := context.Background()
+
+	album, err := upload(t, c, &pb.AlbumInfo{Duration: "1h"}, "alan.jpg", "john.bmp", "dennis.png")
+	require.NoError(t, err)
+	_, err = c.Progress(ctx, &pb.ProgressRequest{Album: album.Id})
+	assert.NoError(t, err)
+	_, err = c.Metadata(ctx, &pb.MetadataRequest{Album: album.Id})
+	assert.NoError(t, err)
+	_, err = c.Results(ctx, &pb.ResultsRequest{Album: album.Id})
+	assert.NoError(t, err)
+	p, err := c.Pair(ctx, &pb.PairRequest{Album: album.Id})
+	require.NoError(t, err)
+	if service.DefaultServiceConfig.TempLinks == true {
+		stream, err := c.Image(ctx, &pb.ImageRequest{Token: p.Img1.Token})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.NoError(t, err)
+	}
+	_, err = c.Vote(ctx, &pb.VoteRequest{Album: album.Id, ImgFrom: p.Img1.Token, ImgTo: p.Img2.Token})
+	assert.NoError(t, err)
+	_, err = c.Top(ctx, &pb.TopRequest{Album: album.Id})
+	assert.NoError(t, err)
+	_, err = c.Health(ctx, &pb.HealthRequest{})
+	assert.NoError(t, err)
+}
+
+func dial(t *testing.T, srv *grpc.Server) pb.AyeAndNayClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewAyeAndNayClient(conn)
+}
+
+// upload stops sending as soon as the server gives up on the stream, the
+// reason is returned by CloseAndRecv
+func upload(t *testing.T, c pb.AyeAndNayClient, info *pb.AlbumInfo, filenames ...string) (*pb.AlbumResponse, error) {
+	t.Helper()
+	stream, err := c.Album(context.Background())
+	require.NoError(t, err)
+	err = stream.Send(&pb.AlbumRequest{Part: &pb.AlbumRequest_Album{Album: info}})
+	require.NoError(t, err)
+	for _, filename := range filenames {
+		err = stream.Send(&pb.AlbumRequest{Part: &pb.AlbumRequest_Image{Image: &pb.ImageInfo{}}})
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		b, err := os.ReadFile("../../testdata/" + filename)
+		require.NoError(t, err)
+		err = stream.Send(&pb.AlbumRequest{Part: &pb.AlbumRequest_Chunk{Chunk: b}})
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	return stream.CloseAndRecv()
+}
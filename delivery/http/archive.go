@@ -0,0 +1,210 @@
+package http
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/pool"
+)
+
+const (
+	archiveZip = "application/zip"
+	archiveTar = "application/x-tar"
+)
+
+// archive collects the images of a zip or tar body, nothing is ever
+// written to disk, entry names only end up as the names of the files
+type archive struct {
+	maxBodySize int64
+	maxFiles    int
+	maxFileSize int64
+	ff          []model.File
+}
+
+func (a *archive) read(kind string, body io.Reader) ([]model.File, error) {
+	lr := &limitedReader{r: body, n: a.maxBodySize}
+	err := error(nil)
+	switch kind {
+	case archiveZip:
+		err = a.readZip(lr)
+	case archiveTar:
+		err = a.readTar(lr)
+	default:
+		err = errors.Wrap(domain.ErrWrongContentType)
+	}
+	if err != nil {
+		a.close()
+		return nil, errors.Wrap(err)
+	}
+	if len(a.ff) < 2 {
+		a.close()
+		return nil, errors.Wrap(domain.ErrNotEnoughImages)
+	}
+	return a.ff, nil
+}
+
+// readZip has to hold the whole body, the central directory sits at the
+// very end of a zip file
+func (a *archive) readZip(r io.Reader) error {
+	buf := pool.GetBuffer()
+	defer pool.PutBuffer(buf)
+	_, err := io.Copy(buf, r)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		return errors.Wrapf(domain.ErrArchiveInvalid, "%s", err)
+	}
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		err := a.add(zf.Name, int64(zf.UncompressedSize64), func() (io.ReadCloser, error) {
+			return zf.Open()
+		})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (a *archive) readTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, domain.ErrBodyTooLarge) {
+			return errors.Wrap(err)
+		}
+		if err != nil {
+			return errors.Wrapf(domain.ErrArchiveInvalid, "%s", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		err = a.add(hdr.Name, hdr.Size, func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+}
+
+// add sniffs an entry and keeps it when it is an image, the declared size
+// is not trusted, a compressed entry may inflate far beyond it
+func (a *archive) add(name string, size int64, open func() (io.ReadCloser, error)) error {
+	name, err := entryName(name)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	rc, err := open()
+	if err != nil {
+		return errors.Wrapf(domain.ErrArchiveInvalid, "%s", err)
+	}
+	defer rc.Close()
+	buf := pool.GetBuffer()
+	_, err = io.CopyN(buf, rc, 512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		pool.PutBuffer(buf)
+		return archiveError(err)
+	}
+	typ := http.DetectContentType(buf.Bytes())
+	if !strings.HasPrefix(typ, "image/") {
+		pool.PutBuffer(buf)
+		return nil
+	}
+	if len(a.ff) == a.maxFiles {
+		pool.PutBuffer(buf)
+		return errors.Wrap(domain.ErrTooManyImages)
+	}
+	if size > a.maxFileSize {
+		pool.PutBuffer(buf)
+		return errors.Wrap(domain.ErrImageTooLarge)
+	}
+	_, err = io.Copy(buf, io.LimitReader(rc, a.maxFileSize+1-int64(buf.Len())))
+	if err != nil {
+		pool.PutBuffer(buf)
+		return archiveError(err)
+	}
+	n := int64(buf.Len())
+	if n > a.maxFileSize {
+		pool.PutBuffer(buf)
+		return errors.Wrap(domain.ErrImageTooLarge)
+	}
+	closeFn := func() error {
+		pool.PutBuffer(buf)
+		return nil
+	}
+	f := model.NewFile(buf, closeFn, n)
+	f.Name = name
+	a.ff = append(a.ff, f)
+	return nil
+}
+
+func (a *archive) close() {
+	for _, f := range a.ff {
+		_ = f.Close()
+	}
+	a.ff = nil
+}
+
+// entryName rejects absolute paths and any step out of the archive root,
+// whatever the entry is, such an archive is not to be trusted at all
+func entryName(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) {
+		return "", errors.Wrapf(domain.ErrArchiveInvalid, "entry %q", name)
+	}
+	for _, s := range strings.Split(name, "/") {
+		if s == ".." {
+			return "", errors.Wrapf(domain.ErrArchiveInvalid, "entry %q", name)
+		}
+	}
+	return path.Base(name), nil
+}
+
+func archiveError(err error) error {
+	if errors.Is(err, domain.ErrBodyTooLarge) {
+		return errors.Wrap(err)
+	}
+	return errors.Wrapf(domain.ErrArchiveInvalid, "%s", err)
+}
+
+// limitedReader unlike io.LimitReader tells a body that is too large
+// apart from one that has merely ended
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errors.Wrap(domain.ErrBodyTooLarge)
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errors.Wrap(domain.ErrBodyTooLarge)
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return n, errors.Wrap(domain.ErrBodyTooLarge)
+	}
+	return n, err
+}
@@ -6,6 +6,8 @@ import (
 
 const (
 	kb = 1 << (10 * 1)
+
+	socketReadLimit = 4 * kb
 )
 
 type ServerConfig struct {
@@ -21,15 +23,28 @@ type ServerConfig struct {
 }
 
 type MiddlewareConfig struct {
-	CorsAllowOrigin string        `mapstructure:"MIDDLEWARE_CORS_ALLOW_ORIGIN" validate:"required"`
-	WriteTimeout    time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"         validate:"required"`
-	MaxFileSize     int64         `mapstructure:"CONTROLLER_MAX_FILE_SIZE"     validate:"required"`
-	Debug           bool          `mapstructure:"MIDDLEWARE_DEBUG"`
+	CorsAllowOrigin  string        `mapstructure:"MIDDLEWARE_CORS_ALLOW_ORIGIN"   validate:"required"`
+	WriteTimeout     time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"           validate:"required"`
+	MaxNumberOfFiles int           `mapstructure:"CONTROLLER_MAX_NUMBER_OF_FILES" validate:"required"`
+	MaxFileSize      int64         `mapstructure:"CONTROLLER_MAX_FILE_SIZE"       validate:"required"`
+	Debug            bool          `mapstructure:"MIDDLEWARE_DEBUG"`
 }
 
 type ControllerConfig struct {
-	MaxNumberOfFiles int   `mapstructure:"CONTROLLER_MAX_NUMBER_OF_FILES" validate:"required"`
-	MaxFileSize      int64 `mapstructure:"CONTROLLER_MAX_FILE_SIZE"       validate:"required"`
+	MaxNumberOfFiles     int           `mapstructure:"CONTROLLER_MAX_NUMBER_OF_FILES"    validate:"required"`
+	MaxFileSize          int64         `mapstructure:"CONTROLLER_MAX_FILE_SIZE"          validate:"required"`
+	MaxTitleLength       int           `mapstructure:"CONTROLLER_MAX_TITLE_LENGTH"       validate:"required"`
+	MaxDescriptionLength int           `mapstructure:"CONTROLLER_MAX_DESCRIPTION_LENGTH" validate:"required"`
+	MaxCaptionLength     int           `mapstructure:"CONTROLLER_MAX_CAPTION_LENGTH"     validate:"required"`
+	MaxCodeLength        int           `mapstructure:"CONTROLLER_MAX_CODE_LENGTH"        validate:"required"`
+	MaxImportEdges       int           `mapstructure:"CONTROLLER_MAX_IMPORT_EDGES"       validate:"required"`
+	MaxImportCount       int           `mapstructure:"CONTROLLER_MAX_IMPORT_COUNT"       validate:"required"`
+	EventsTimeout        time.Duration `mapstructure:"CONTROLLER_EVENTS_TIMEOUT"         validate:"required"`
+	EventsKeepAlive      time.Duration `mapstructure:"CONTROLLER_EVENTS_KEEP_ALIVE"      validate:"required"`
+	CorsAllowOrigin      string        `mapstructure:"MIDDLEWARE_CORS_ALLOW_ORIGIN"      validate:"required"`
+	WriteTimeout         time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"              validate:"required"`
+	IdleTimeout          time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"               validate:"required"`
+	PingInterval         time.Duration `mapstructure:"SERVER_PING_INTERVAL"              validate:"required"`
 }
 
 var (
@@ -45,13 +60,26 @@ var (
 		Controller:      DefaultControllerConfig,
 	}
 	DefaultMiddlewareConfig = MiddlewareConfig{
-		CorsAllowOrigin: "",
-		WriteTimeout:    10 * time.Second,
-		MaxFileSize:     512 * kb,
-		Debug:           false,
-	}
-	DefaultControllerConfig = ControllerConfig{
+		CorsAllowOrigin:  "",
+		WriteTimeout:     10 * time.Second,
 		MaxNumberOfFiles: 3,
 		MaxFileSize:      512 * kb,
+		Debug:            false,
+	}
+	DefaultControllerConfig = ControllerConfig{
+		MaxNumberOfFiles:     3,
+		MaxFileSize:          512 * kb,
+		MaxTitleLength:       16,
+		MaxDescriptionLength: 32,
+		MaxCaptionLength:     16,
+		MaxCodeLength:        16,
+		MaxImportEdges:       8,
+		MaxImportCount:       8,
+		EventsTimeout:        5 * time.Second,
+		EventsKeepAlive:      1 * time.Second,
+		CorsAllowOrigin:      "*",
+		WriteTimeout:         10 * time.Second,
+		IdleTimeout:          10 * time.Second,
+		PingInterval:         5 * time.Second,
 	}
 )
@@ -1,109 +1,165 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"io"
+	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/domain/service"
 	"github.com/zitryss/aye-and-nay/pkg/base64"
 	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/rand"
 )
 
 func newController(
 	conf ControllerConfig,
 	serv domain.Servicer,
+	lim domain.Limiter,
+	fetch domain.Fetcher,
+	stage domain.Stager,
+	stor domain.Storager,
 ) controller {
-	return controller{conf, serv}
+	return controller{conf, serv, lim, fetch, stage, stor}
 }
 
 type controller struct {
-	conf ControllerConfig
-	serv domain.Servicer
+	conf  ControllerConfig
+	serv  domain.Servicer
+	lim   domain.Limiter
+	fetch domain.Fetcher
+	stage domain.Stager
+	stor  domain.Storager
 }
 
 func (c *controller) handleAlbum() httprouter.Handle {
 	input := func(r *http.Request, ps httprouter.Params) (context.Context, albumRequest, error) {
 		ctx := r.Context()
 		ct := r.Header.Get("Content-Type")
-		if !strings.HasPrefix(ct, "multipart/form-data") {
+		fhs := []*multipart.FileHeader(nil)
+		urls := []string(nil)
+		uploads := []string(nil)
+		directs := []string(nil)
+		kind := ""
+		multi := (*multipart.Form)(nil)
+		switch {
+		case strings.HasPrefix(ct, "multipart/form-data"):
+			maxBodySize := int64(c.conf.MaxNumberOfFiles) * c.conf.MaxFileSize
+			if r.ContentLength > maxBodySize {
+				return nil, albumRequest{}, errors.Wrap(domain.ErrBodyTooLarge)
+			}
+			err := parseMultipartForm(r)
+			if err != nil {
+				return nil, albumRequest{}, errors.Wrap(err)
+			}
+			if v2(r) {
+				err := albumValues(r.MultipartForm)
+				if err != nil {
+					_ = r.MultipartForm.RemoveAll()
+					return nil, albumRequest{}, errors.Wrap(err)
+				}
+			}
+			fhs = r.MultipartForm.File["images"]
+			multi = r.MultipartForm
+		case strings.HasPrefix(ct, "application/json"):
+			body := albumJsonRequest{}
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil {
+				return nil, albumRequest{}, errors.Wrap(err)
+			}
+			urls = body.Urls
+			uploads = body.Uploads
+			directs = body.Direct
+			multi = &multipart.Form{Value: partValues(body.albumPart)}
+		case strings.HasPrefix(ct, archiveZip), strings.HasPrefix(ct, archiveTar):
+			// the body is taken up by the archive, so the album fields
+			// travel in the query string instead
+			maxBodySize := int64(c.conf.MaxNumberOfFiles) * c.conf.MaxFileSize
+			if r.ContentLength > maxBodySize {
+				return nil, albumRequest{}, errors.Wrap(domain.ErrBodyTooLarge)
+			}
+			kind = archiveZip
+			if strings.HasPrefix(ct, archiveTar) {
+				kind = archiveTar
+			}
+			multi = &multipart.Form{Value: r.URL.Query()}
+		default:
 			return nil, albumRequest{}, errors.Wrap(domain.ErrWrongContentType)
 		}
-		maxBodySize := int64(c.conf.MaxNumberOfFiles) * c.conf.MaxFileSize
-		if r.ContentLength > maxBodySize {
-			return nil, albumRequest{}, errors.Wrap(domain.ErrBodyTooLarge)
-		}
-		err := r.ParseMultipartForm(r.ContentLength)
-		if err != nil {
-			return nil, albumRequest{}, errors.Wrap(err)
-		}
-		fhs := r.MultipartForm.File["images"]
-		if len(fhs) < 2 {
+		n := len(fhs) + len(urls) + len(uploads) + len(directs)
+		if kind == "" && n < 2 {
+			_ = multi.RemoveAll()
 			return nil, albumRequest{}, errors.Wrap(domain.ErrNotEnoughImages)
 		}
-		if len(fhs) > c.conf.MaxNumberOfFiles {
+		if n > c.conf.MaxNumberOfFiles {
+			_ = multi.RemoveAll()
 			return nil, albumRequest{}, errors.Wrap(domain.ErrTooManyImages)
 		}
-		req := albumRequest{ff: make([]model.File, 0, len(fhs)), multi: r.MultipartForm}
+		req := albumRequest{multi: multi}
+		// fetched images go back to the pool on close, so they may only be
+		// released here when the request never makes it to process
+		ok := false
 		defer func() {
+			if ok {
+				return
+			}
 			for _, f := range req.ff {
 				_ = f.Close()
 			}
 			_ = req.multi.RemoveAll()
 		}()
-		for _, fh := range fhs {
-			if fh.Size > c.conf.MaxFileSize {
-				return nil, albumRequest{}, errors.Wrap(domain.ErrImageTooLarge)
-			}
-			f, err := fh.Open()
-			if err != nil {
-				return nil, albumRequest{}, errors.Wrap(err)
-			}
-			b := make([]byte, 512)
-			_, err = f.Read(b)
-			if err != nil {
-				_ = f.Close()
-				return nil, albumRequest{}, errors.Wrap(err)
+		ff, err := c.files(fhs)
+		if err != nil {
+			return nil, albumRequest{}, errors.Wrap(err)
+		}
+		req.ff = ff
+		ff, err = c.urls(ctx, urls)
+		if err != nil {
+			return nil, albumRequest{}, errors.Wrap(err)
+		}
+		req.ff = append(req.ff, ff...)
+		ff, req.uploads, err = c.staged(ctx, uploads)
+		if err != nil {
+			return nil, albumRequest{}, errors.Wrap(err)
+		}
+		req.ff = append(req.ff, ff...)
+		ff, req.directs, err = c.direct(ctx, directs)
+		if err != nil {
+			return nil, albumRequest{}, errors.Wrap(err)
+		}
+		req.ff = append(req.ff, ff...)
+		if kind != "" {
+			a := archive{
+				maxBodySize: int64(c.conf.MaxNumberOfFiles) * c.conf.MaxFileSize,
+				maxFiles:    c.conf.MaxNumberOfFiles,
+				maxFileSize: c.conf.MaxFileSize,
 			}
-			_, err = f.Seek(0, io.SeekStart)
+			ff, err = a.read(kind, r.Body)
 			if err != nil {
-				_ = f.Close()
 				return nil, albumRequest{}, errors.Wrap(err)
 			}
-			typ := http.DetectContentType(b)
-			if !strings.HasPrefix(typ, "image/") {
-				_ = f.Close()
-				return nil, albumRequest{}, errors.Wrap(domain.ErrNotImage)
-			}
-			F := model.File{}
-			switch v := f.(type) {
-			case *os.File:
-				closeFn := func() error {
-					_ = v.Close()
-					_ = os.Remove(v.Name())
-					return nil
-				}
-				F = model.NewFile(v, closeFn, fh.Size)
-			case multipart.File:
-				closeFn := func() error {
-					_ = v.Close()
-					return nil
-				}
-				F = model.NewFile(v, closeFn, fh.Size)
-			default:
-				return nil, albumRequest{}, errors.Wrap(domain.ErrUnknown)
-			}
-			req.ff = append(req.ff, F)
+			req.ff = append(req.ff, ff...)
+			n = len(req.ff)
 		}
-		vals := r.MultipartForm.Value["duration"]
+		vals := multi.Value["duration"]
 		if len(vals) == 0 {
 			return nil, albumRequest{}, errors.Wrap(domain.ErrDurationNotSet)
 		}
@@ -111,7 +167,50 @@ func (c *controller) handleAlbum() httprouter.Handle {
 		if err != nil {
 			return nil, albumRequest{}, errors.Wrap(domain.ErrDurationInvalid)
 		}
-		req.dur = dur
+		req.opts.Duration = dur
+		vals = multi.Value["ranking"]
+		if len(vals) > 0 {
+			req.opts.Ranking = vals[0]
+		}
+		vals = multi.Value["mode"]
+		if len(vals) > 0 {
+			req.opts.Mode = vals[0]
+		}
+		vals = multi.Value["title"]
+		if len(vals) > 0 {
+			if utf8.RuneCountInString(vals[0]) > c.conf.MaxTitleLength {
+				return nil, albumRequest{}, errors.Wrap(domain.ErrTitleTooLong)
+			}
+			req.opts.Metadata.Title = vals[0]
+		}
+		vals = multi.Value["description"]
+		if len(vals) > 0 {
+			if utf8.RuneCountInString(vals[0]) > c.conf.MaxDescriptionLength {
+				return nil, albumRequest{}, errors.Wrap(domain.ErrDescriptionTooLong)
+			}
+			req.opts.Metadata.Description = vals[0]
+		}
+		captions, err := c.captions(multi.Value["captions"], n)
+		if err != nil {
+			return nil, albumRequest{}, errors.Wrap(err)
+		}
+		req.opts.Metadata.Captions = captions
+		vals = multi.Value["access"]
+		if len(vals) > 0 {
+			req.opts.Access = vals[0]
+		}
+		vals = multi.Value["code"]
+		if len(vals) > 0 {
+			if utf8.RuneCountInString(vals[0]) > c.conf.MaxCodeLength {
+				return nil, albumRequest{}, errors.Wrap(domain.ErrCodeInvalid)
+			}
+			req.opts.Code = vals[0]
+		}
+		vals = multi.Value["results"]
+		if len(vals) > 0 {
+			req.opts.Results = vals[0]
+		}
+		ok = true
 		return ctx, req, nil
 	}
 	process := func(ctx context.Context, req albumRequest) (albumResponse, error) {
@@ -121,13 +220,20 @@ func (c *controller) handleAlbum() httprouter.Handle {
 			}
 			_ = req.multi.RemoveAll()
 		}()
-		album, err := c.serv.Album(ctx, req.ff, req.dur)
+		album, owner, err := c.serv.Album(ctx, req.ff, req.opts)
 		if err != nil {
 			return albumResponse{}, errors.Wrap(err)
 		}
+		for _, upload := range req.uploads {
+			_ = c.stage.Remove(ctx, upload)
+		}
+		for _, direct := range req.directs {
+			_ = c.stor.RemoveDirect(ctx, direct)
+		}
 		resp := albumResponse{}
 		albumB64 := base64.FromUint64(album)
 		resp.Album.Id = albumB64
+		resp.Album.Owner = owner
 		return resp, nil
 	}
 	output := func(ctx context.Context, w http.ResponseWriter, resp albumResponse) error {
@@ -163,6 +269,7 @@ func (c *controller) handleStatus() httprouter.Handle {
 		ctx := r.Context()
 		req := statusRequest{}
 		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
 		return ctx, req, nil
 	}
 	process := func(ctx context.Context, req statusRequest) (statusResponse, error) {
@@ -174,8 +281,23 @@ func (c *controller) handleStatus() httprouter.Handle {
 		if err != nil {
 			return statusResponse{}, errors.Wrap(err)
 		}
+		meta, err := c.serv.Metadata(ctx, album)
+		if err != nil {
+			return statusResponse{}, errors.Wrap(err)
+		}
+		res, err := c.serv.Results(ctx, album, req.owner)
+		if err != nil {
+			return statusResponse{}, errors.Wrap(err)
+		}
 		resp := statusResponse{}
+		resp.Album.Title = meta.Title
+		resp.Album.Description = meta.Description
 		resp.Album.Compression.Progress = p
+		resp.Album.Results.Policy = res.Policy
+		resp.Album.Results.Available = res.Available
+		if !res.AvailableAt.IsZero() {
+			resp.Album.Results.AvailableAt = res.AvailableAt.UTC().Format(time.RFC3339)
+		}
 		return resp, nil
 	}
 	output := func(ctx context.Context, w http.ResponseWriter, resp statusResponse) error {
@@ -210,6 +332,8 @@ func (c *controller) handlePair() httprouter.Handle {
 		ctx := r.Context()
 		req := pairRequest{}
 		req.album.id = ps.ByName("album")
+		req.voter = voter(r)
+		req.cred = credentials(r)
 		return ctx, req, nil
 	}
 	process := func(ctx context.Context, req pairRequest) (pairResponse, error) {
@@ -217,17 +341,10 @@ func (c *controller) handlePair() httprouter.Handle {
 		if err != nil {
 			return pairResponse{}, errors.Wrap(domain.ErrInvalidId)
 		}
-		img1, img2, err := c.serv.Pair(ctx, album)
+		resp, err := c.pair(ctx, album, req.voter, req.cred)
 		if err != nil {
 			return pairResponse{}, errors.Wrap(err)
 		}
-		resp := pairResponse{}
-		resp.Album.Img1.Src = img1.Src
-		img1TokenB64 := base64.FromUint64(img1.Token)
-		resp.Album.Img1.Token = img1TokenB64
-		resp.Album.Img2.Src = img2.Src
-		img2TokenB64 := base64.FromUint64(img2.Token)
-		resp.Album.Img2.Token = img2TokenB64
 		return resp, nil
 	}
 	output := func(ctx context.Context, w http.ResponseWriter, resp pairResponse) error {
@@ -262,6 +379,7 @@ func (c *controller) handleImage() httprouter.Handle {
 		ctx := r.Context()
 		req := imageRequest{}
 		req.image.token = ps.ByName("token")
+		req.cred = credentials(r)
 		return ctx, req, nil
 	}
 	process := func(ctx context.Context, req imageRequest) (imageResponse, error) {
@@ -269,7 +387,7 @@ func (c *controller) handleImage() httprouter.Handle {
 		if err != nil {
 			return imageResponse{}, errors.Wrap(domain.ErrInvalidId)
 		}
-		f, err := c.serv.Image(ctx, token)
+		f, err := c.serv.Image(ctx, token, req.cred)
 		if err != nil {
 			return imageResponse{}, errors.Wrap(err)
 		}
@@ -308,6 +426,8 @@ func (c *controller) handleVote() httprouter.Handle {
 		ctx := r.Context()
 		req := voteRequest{}
 		req.Album.id = ps.ByName("album")
+		req.voter = voter(r)
+		req.cred = credentials(r)
 		ct := r.Header.Get("Content-Type")
 		if !strings.HasPrefix(ct, "application/json") {
 			return nil, voteRequest{}, errors.Wrap(domain.ErrWrongContentType)
@@ -323,15 +443,7 @@ func (c *controller) handleVote() httprouter.Handle {
 		if err != nil {
 			return voteResponse{}, errors.Wrap(domain.ErrInvalidId)
 		}
-		imgFromToken, err := base64.ToUint64(req.Album.ImgFrom.Token)
-		if err != nil {
-			return voteResponse{}, errors.Wrap(domain.ErrInvalidId)
-		}
-		imgToToken, err := base64.ToUint64(req.Album.ImgTo.Token)
-		if err != nil {
-			return voteResponse{}, errors.Wrap(domain.ErrInvalidId)
-		}
-		err = c.serv.Vote(ctx, album, imgFromToken, imgToToken)
+		err = c.vote(ctx, album, req.voter, req.Album.ImgFrom.Token, req.Album.ImgTo.Token, req.Album.Outcome, req.cred)
 		if err != nil {
 			return voteResponse{}, errors.Wrap(err)
 		}
@@ -360,11 +472,198 @@ func (c *controller) handleVote() httprouter.Handle {
 	)
 }
 
+func (c *controller) handleSocket() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, socketRequest, error) {
+		ctx := r.Context()
+		req := socketRequest{}
+		req.album.id = ps.ByName("album")
+		req.voter = voter(r)
+		req.cred = credentials(r)
+		hash := fnv.New64a()
+		_, err := io.WriteString(hash, ip(r))
+		if err != nil {
+			return nil, socketRequest{}, errors.Wrap(err)
+		}
+		req.ip = hash.Sum64()
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req socketRequest) (socketResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return socketResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		// an unknown album is reported before the connection is upgraded
+		_, err = c.serv.Metadata(ctx, album)
+		if err != nil {
+			return socketResponse{}, errors.Wrap(err)
+		}
+		resp := socketResponse{album}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, r *http.Request, req socketRequest, resp socketResponse) error {
+		upgrader := websocket.Upgrader{
+			HandshakeTimeout: c.conf.WriteTimeout,
+			CheckOrigin:      c.checkOrigin,
+		}
+		// the upgrader replies with an error on its own
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			service.HandleInnerError(ctx, errors.Wrap(err))
+			return nil
+		}
+		err = c.session(ctx, conn, req, resp.album)
+		if err != nil {
+			service.HandleInnerError(ctx, errors.Wrap(err))
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, r, req, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+// session serves the messages of a socket until the client leaves or stops
+// answering the pings
+// checkOrigin lets a socket be opened from the same origin or from the one
+// allowed by the configuration, a browser sends the cookies along with the
+// upgrade, so a wildcard is never taken as allowing every origin
+func (c *controller) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return c.conf.CorsAllowOrigin != "*" && origin == c.conf.CorsAllowOrigin
+}
+
+func (c *controller) session(ctx context.Context, conn *websocket.Conn, req socketRequest, album uint64) error {
+	defer func() { _ = conn.Close() }()
+	conn.SetReadLimit(socketReadLimit)
+	err := conn.SetReadDeadline(time.Now().Add(c.conf.IdleTimeout))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.conf.IdleTimeout))
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(c.conf.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				_ = conn.Close()
+				return
+			case <-ticker.C:
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.conf.WriteTimeout))
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	for {
+		_, b, err := conn.ReadMessage()
+		if e := (*websocket.CloseError)(nil); errors.As(err, &e) {
+			return nil
+		}
+		if e := net.Error(nil); errors.As(err, &e) && e.Timeout() {
+			return nil
+		}
+		if err != nil && ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		err = conn.SetReadDeadline(time.Now().Add(c.conf.IdleTimeout))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		reply, err := c.message(ctx, req, album, b)
+		if err != nil {
+			err = errors.Wrap(err)
+			service.HandleInnerError(ctx, err)
+			resp := outerError(err)
+			reply = socketReply{Type: "error", errorResponse: &resp}
+		}
+		err = conn.SetWriteDeadline(time.Now().Add(c.conf.WriteTimeout))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		err = conn.WriteJSON(reply)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+}
+
+// message answers both a request for a pair and a vote with the next pair,
+// so that a comparison takes a single round trip
+func (c *controller) message(ctx context.Context, req socketRequest, album uint64, b []byte) (socketReply, error) {
+	allowed, err := c.lim.Allow(ctx, req.ip)
+	if err != nil {
+		return socketReply{}, errors.Wrap(err)
+	}
+	if !allowed {
+		return socketReply{}, errors.Wrap(domain.ErrTooManyRequests)
+	}
+	msg := socketMessage{}
+	err = json.Unmarshal(b, &msg)
+	if err != nil {
+		return socketReply{}, errors.Wrap(domain.ErrMessageInvalid)
+	}
+	switch msg.Type {
+	case "pair":
+	case "vote":
+		err = c.vote(ctx, album, req.voter, msg.Album.ImgFrom.Token, msg.Album.ImgTo.Token, msg.Album.Outcome, req.cred)
+		if err != nil {
+			return socketReply{}, errors.Wrap(err)
+		}
+	default:
+		return socketReply{}, errors.Wrap(domain.ErrMessageInvalid)
+	}
+	resp, err := c.pair(ctx, album, req.voter, req.cred)
+	if err != nil {
+		return socketReply{}, errors.Wrap(err)
+	}
+	reply := socketReply{Type: "pair", pairResponse: &resp}
+	return reply, nil
+}
+
 func (c *controller) handleTop() httprouter.Handle {
 	input := func(r *http.Request, ps httprouter.Params) (context.Context, topRequest, error) {
 		ctx := r.Context()
 		req := topRequest{}
 		req.album.id = ps.ByName("album")
+		req.cred = credentials(r)
+		page, err := pagination(r.URL.Query())
+		if err != nil {
+			return nil, topRequest{}, errors.Wrap(err)
+		}
+		req.page = page
+		req.match = r.Header.Get("If-None-Match")
 		return ctx, req, nil
 	}
 	process := func(ctx context.Context, req topRequest) (topResponse, error) {
@@ -372,19 +671,46 @@ func (c *controller) handleTop() httprouter.Handle {
 		if err != nil {
 			return topResponse{}, errors.Wrap(domain.ErrInvalidId)
 		}
-		imgs, err := c.serv.Top(ctx, album)
+		imgs, version, err := c.serv.Top(ctx, album, req.cred, req.page)
 		if err != nil {
 			return topResponse{}, errors.Wrap(err)
 		}
 		resp := topResponse{}
+		resp.etag = `"` + base64.FromUint64(version) + `"`
+		resp.private = req.cred.Code != "" || req.cred.Pass != 0x0 || req.cred.Owner != ""
+		if etagMatch(req.match, resp.etag) {
+			resp.notModified = true
+			return resp, nil
+		}
+		meta, err := c.serv.Metadata(ctx, album)
+		if err != nil {
+			return topResponse{}, errors.Wrap(err)
+		}
+		resp.Album.Title = meta.Title
+		resp.Album.Description = meta.Description
 		resp.Album.Images = make([]image, 0, len(imgs))
 		for _, img := range imgs {
-			image := image{img.Src, img.Rating}
+			imageB64 := base64.FromUint64(img.Id)
+			image := image{imageB64, img.Src, img.Caption, img.Rating, img.RatingLow, img.RatingHigh, img.Comparisons}
 			resp.Album.Images = append(resp.Album.Images, image)
 		}
+		if req.page.Limit > 0 && len(imgs) == req.page.Limit {
+			last := imgs[len(imgs)-1]
+			resp.Album.Next = cursor(model.Cursor{Rating: last.Rating, Id: last.Id})
+		}
 		return resp, nil
 	}
 	output := func(ctx context.Context, w http.ResponseWriter, resp topResponse) error {
+		w.Header().Set("ETag", resp.etag)
+		if resp.private {
+			w.Header().Set("Cache-Control", "private, no-cache")
+		} else {
+			w.Header().Set("Cache-Control", "public, no-cache")
+		}
+		if resp.notModified {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		err := json.NewEncoder(w).Encode(resp)
 		if err != nil {
@@ -411,11 +737,170 @@ func (c *controller) handleTop() httprouter.Handle {
 	)
 }
 
-func (c *controller) handleHealth() httprouter.Handle {
+func (c *controller) handleEvents() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, eventsRequest, error) {
+		ctx := r.Context()
+		req := eventsRequest{}
+		req.album.id = ps.ByName("album")
+		req.cred = credentials(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req eventsRequest) (eventsResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return eventsResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		events, err := c.serv.Events(ctx, album, req.cred)
+		if err != nil {
+			return eventsResponse{}, errors.Wrap(err)
+		}
+		resp := eventsResponse{events}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp eventsResponse) error {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			return errors.Wrap(domain.ErrUnknown)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		f.Flush()
+		// the stream is closed before the server write timeout hits, the
+		// client reconnects on its own
+		timeout := time.NewTimer(c.conf.EventsTimeout)
+		defer timeout.Stop()
+		keepAlive := time.NewTicker(c.conf.EventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			err := error(nil)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-timeout.C:
+				return nil
+			case <-keepAlive.C:
+				_, err = io.WriteString(w, ": keep-alive\n\n")
+			case event, ok := <-resp.events:
+				if !ok {
+					return nil
+				}
+				err = writeEvent(w, event)
+			}
+			if err != nil {
+				return errors.Wrap(err)
+			}
+			f.Flush()
+		}
+	}
 	return handleHttpRouterError(
 		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
-			ctx := r.Context()
-			_, err := c.serv.Health(ctx)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func writeEvent(w io.Writer, event model.Event) error {
+	data := any(nil)
+	switch event.Type {
+	case service.EventProgress:
+		e := progressEvent{}
+		e.Album.Compression.Progress = event.Progress
+		data = e
+	case service.EventRatings:
+		e := ratingsEvent{}
+		e.Album.Votes = event.Votes
+		for _, img := range event.Images {
+			imageB64 := base64.FromUint64(img.Id)
+			image := image{imageB64, img.Src, img.Caption, img.Rating, img.RatingLow, img.RatingHigh, img.Comparisons}
+			e.Album.Images = append(e.Album.Images, image)
+		}
+		data = e
+	default:
+		return nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, b)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (c *controller) handleBracket() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, bracketRequest, error) {
+		ctx := r.Context()
+		req := bracketRequest{}
+		req.album.id = ps.ByName("album")
+		req.cred = credentials(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req bracketRequest) (bracketResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return bracketResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		tour, imgs, err := c.serv.Bracket(ctx, album, req.cred)
+		if err != nil {
+			return bracketResponse{}, errors.Wrap(err)
+		}
+		srcs := make(map[uint64]string, len(imgs))
+		for _, img := range imgs {
+			srcs[img.Id] = img.Src
+		}
+		resp := bracketResponse{}
+		resp.Album.Mode = tour.Mode
+		resp.Album.Round = tour.Round
+		resp.Album.Rounds = tour.Rounds
+		resp.Album.Matches = make([]match, 0, len(tour.Matches))
+		for _, m := range tour.Matches {
+			match := match{m.Round, srcs[m.Image1], srcs[m.Image2], srcs[m.Winner], m.Done}
+			resp.Album.Matches = append(resp.Album.Matches, match)
+		}
+		if tour.Scores != nil {
+			resp.Album.Standings = make([]standing, 0, len(imgs))
+			for _, img := range imgs {
+				standing := standing{img.Src, tour.Scores[img.Id]}
+				resp.Album.Standings = append(resp.Album.Standings, standing)
+			}
+		}
+		resp.Album.Champion = srcs[tour.Champion]
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp bracketResponse) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
 			if err != nil {
 				return ctx, errors.Wrap(err)
 			}
@@ -423,3 +908,1288 @@ func (c *controller) handleHealth() httprouter.Handle {
 		},
 	)
 }
+
+func (c *controller) handleAccess() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, accessRequest, error) {
+		ctx := r.Context()
+		req := accessRequest{}
+		req.Album.id = ps.ByName("album")
+		req.cred = credentials(r)
+		ct := r.Header.Get("Content-Type")
+		if !strings.HasPrefix(ct, "application/json") {
+			return nil, accessRequest{}, errors.Wrap(domain.ErrWrongContentType)
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, accessRequest{}, errors.Wrap(err)
+		}
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req accessRequest) (accessResponse, error) {
+		album, err := base64.ToUint64(req.Album.id)
+		if err != nil {
+			return accessResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		pass, err := c.serv.Access(ctx, album, req.Album.Code, req.cred)
+		if err != nil {
+			return accessResponse{}, errors.Wrap(err)
+		}
+		resp := accessResponse{pass}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp accessResponse) error {
+		if resp.pass == 0x0 {
+			return nil
+		}
+		passB64 := base64.FromUint64(resp.pass)
+		http.SetCookie(w, &http.Cookie{Name: "access_pass", Value: passB64, Path: "/api/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleDelete() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, deleteRequest, error) {
+		ctx := r.Context()
+		req := deleteRequest{}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req deleteRequest) (deleteResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return deleteResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		err = c.serv.Delete(ctx, album, req.owner)
+		if err != nil {
+			return deleteResponse{}, errors.Wrap(err)
+		}
+		resp := deleteResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp deleteResponse) error {
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleFreeze() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, freezeRequest, error) {
+		ctx := r.Context()
+		req := freezeRequest{}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req freezeRequest) (freezeResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return freezeResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		err = c.serv.Freeze(ctx, album, req.owner)
+		if err != nil {
+			return freezeResponse{}, errors.Wrap(err)
+		}
+		resp := freezeResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp freezeResponse) error {
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleReopen() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, reopenRequest, error) {
+		ctx := r.Context()
+		req := reopenRequest{}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req reopenRequest) (reopenResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return reopenResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		err = c.serv.Reopen(ctx, album, req.owner)
+		if err != nil {
+			return reopenResponse{}, errors.Wrap(err)
+		}
+		resp := reopenResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp reopenResponse) error {
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleExtend() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, extendRequest, error) {
+		ctx := r.Context()
+		req := extendRequest{}
+		req.Album.id = ps.ByName("album")
+		req.owner = owner(r)
+		ct := r.Header.Get("Content-Type")
+		if !strings.HasPrefix(ct, "application/json") {
+			return nil, extendRequest{}, errors.Wrap(domain.ErrWrongContentType)
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, extendRequest{}, errors.Wrap(err)
+		}
+		if req.Album.Duration == "" {
+			return nil, extendRequest{}, errors.Wrap(domain.ErrDurationNotSet)
+		}
+		dur, err := time.ParseDuration(req.Album.Duration)
+		if err != nil {
+			return nil, extendRequest{}, errors.Wrap(domain.ErrDurationInvalid)
+		}
+		req.dur = dur
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req extendRequest) (extendResponse, error) {
+		album, err := base64.ToUint64(req.Album.id)
+		if err != nil {
+			return extendResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		err = c.serv.Extend(ctx, album, req.owner, req.dur)
+		if err != nil {
+			return extendResponse{}, errors.Wrap(err)
+		}
+		resp := extendResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp extendResponse) error {
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleAddImages() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, addImagesRequest, error) {
+		ctx := r.Context()
+		ct := r.Header.Get("Content-Type")
+		if !strings.HasPrefix(ct, "multipart/form-data") {
+			return nil, addImagesRequest{}, errors.Wrap(domain.ErrWrongContentType)
+		}
+		maxBodySize := int64(c.conf.MaxNumberOfFiles) * c.conf.MaxFileSize
+		if r.ContentLength > maxBodySize {
+			return nil, addImagesRequest{}, errors.Wrap(domain.ErrBodyTooLarge)
+		}
+		err := parseMultipartForm(r)
+		if err != nil {
+			return nil, addImagesRequest{}, errors.Wrap(err)
+		}
+		if v2(r) {
+			err := imagesValues(r.MultipartForm)
+			if err != nil {
+				_ = r.MultipartForm.RemoveAll()
+				return nil, addImagesRequest{}, errors.Wrap(err)
+			}
+		}
+		fhs := r.MultipartForm.File["images"]
+		if len(fhs) < 1 {
+			return nil, addImagesRequest{}, errors.Wrap(domain.ErrNotEnoughImages)
+		}
+		if len(fhs) > c.conf.MaxNumberOfFiles {
+			return nil, addImagesRequest{}, errors.Wrap(domain.ErrTooManyImages)
+		}
+		req := addImagesRequest{multi: r.MultipartForm}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		defer func() {
+			for _, f := range req.ff {
+				_ = f.Close()
+			}
+			_ = req.multi.RemoveAll()
+		}()
+		ff, err := c.files(fhs)
+		if err != nil {
+			return nil, addImagesRequest{}, errors.Wrap(err)
+		}
+		req.ff = ff
+		captions, err := c.captions(r.MultipartForm.Value["captions"], len(fhs))
+		if err != nil {
+			return nil, addImagesRequest{}, errors.Wrap(err)
+		}
+		req.captions = captions
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req addImagesRequest) (addImagesResponse, error) {
+		defer func() {
+			for _, f := range req.ff {
+				_ = f.Close()
+			}
+			_ = req.multi.RemoveAll()
+		}()
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return addImagesResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		images, err := c.serv.AddImages(ctx, album, req.owner, req.ff, req.captions)
+		if err != nil {
+			return addImagesResponse{}, errors.Wrap(err)
+		}
+		resp := addImagesResponse{}
+		resp.Album.Images = make([]addedImage, 0, len(images))
+		for _, image := range images {
+			imageB64 := base64.FromUint64(image)
+			resp.Album.Images = append(resp.Album.Images, addedImage{imageB64})
+		}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp addImagesResponse) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(201)
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleRemoveImage() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, removeImageRequest, error) {
+		ctx := r.Context()
+		req := removeImageRequest{}
+		req.album.id = ps.ByName("album")
+		req.image.id = ps.ByName("image")
+		req.owner = owner(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req removeImageRequest) (removeImageResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return removeImageResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		image, err := base64.ToUint64(req.image.id)
+		if err != nil {
+			return removeImageResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		err = c.serv.RemoveImage(ctx, album, req.owner, image)
+		if err != nil {
+			return removeImageResponse{}, errors.Wrap(err)
+		}
+		resp := removeImageResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp removeImageResponse) error {
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleExport() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, exportRequest, error) {
+		ctx := r.Context()
+		req := exportRequest{}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		req.format = r.URL.Query().Get("format")
+		if req.format == "" {
+			req.format = formatCsv
+		}
+		if !exportFormat(req.format) {
+			return nil, exportRequest{}, errors.Wrap(domain.ErrFormatInvalid)
+		}
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req exportRequest) (exportResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return exportResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		exp, err := c.serv.Export(ctx, album, req.owner)
+		if err != nil {
+			return exportResponse{}, errors.Wrap(err)
+		}
+		resp := exportResponse{req.album.id, req.format, exp}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp exportResponse) error {
+		w.Header().Set("Content-Type", exportContentType(resp.format))
+		w.Header().Set("Content-Disposition", `attachment; filename="`+resp.album+"."+resp.format+`"`)
+		err := error(nil)
+		switch resp.format {
+		case formatJsonl:
+			err = writeJsonl(w, resp.exp)
+		case formatZip:
+			err = writeZip(ctx, w, resp.exp)
+		default:
+			err = writeCsv(w, resp.exp)
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleImport() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, importRequest, error) {
+		ctx := r.Context()
+		req := importRequest{}
+		req.album.id = ps.ByName("album")
+		req.owner = owner(r)
+		if r.ContentLength > c.conf.MaxFileSize {
+			return nil, importRequest{}, errors.Wrap(domain.ErrBodyTooLarge)
+		}
+		ct := r.Header.Get("Content-Type")
+		err := error(nil)
+		switch {
+		case strings.HasPrefix(ct, "text/csv"):
+			req.edgs, err = readCsv(r.Body, c.conf.MaxImportEdges, c.conf.MaxImportCount)
+		case strings.HasPrefix(ct, "application/x-ndjson"), strings.HasPrefix(ct, "application/jsonl"):
+			req.edgs, err = readJsonl(r.Body, c.conf.MaxImportEdges, c.conf.MaxImportCount)
+		default:
+			return nil, importRequest{}, errors.Wrap(domain.ErrWrongContentType)
+		}
+		if err != nil {
+			return nil, importRequest{}, errors.Wrap(err)
+		}
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req importRequest) (importResponse, error) {
+		album, err := base64.ToUint64(req.album.id)
+		if err != nil {
+			return importResponse{}, errors.Wrap(domain.ErrInvalidId)
+		}
+		n, err := c.serv.Import(ctx, album, req.owner, req.edgs)
+		if err != nil {
+			return importResponse{}, errors.Wrap(err)
+		}
+		resp := importResponse{}
+		resp.Album.Votes = n
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp importResponse) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleTusOptions() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, tusOptionsRequest, error) {
+		ctx := r.Context()
+		req := tusOptionsRequest{}
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req tusOptionsRequest) (tusOptionsResponse, error) {
+		resp := tusOptionsResponse{}
+		resp.maxSize = c.conf.MaxFileSize
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp tusOptionsResponse) error {
+		w.Header().Set("Tus-Extension", tusExtensions)
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(resp.maxSize, 10))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			tusHeaders(w)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleCreateUpload() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, createUploadRequest, error) {
+		ctx := r.Context()
+		err := tusResumable(r)
+		if err != nil {
+			return nil, createUploadRequest{}, errors.Wrap(err)
+		}
+		req := createUploadRequest{}
+		size, err := uploadLength(r)
+		if err != nil {
+			return nil, createUploadRequest{}, errors.Wrap(err)
+		}
+		if size > c.conf.MaxFileSize {
+			return nil, createUploadRequest{}, errors.Wrap(domain.ErrImageTooLarge)
+		}
+		req.size = size
+		name, err := uploadFilename(r)
+		if err != nil {
+			return nil, createUploadRequest{}, errors.Wrap(err)
+		}
+		req.name = name
+		req.v2 = v2(r)
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req createUploadRequest) (createUploadResponse, error) {
+		upl, err := c.stage.Create(ctx, req.size, req.name)
+		if err != nil {
+			return createUploadResponse{}, errors.Wrap(err)
+		}
+		resp := createUploadResponse{}
+		resp.location = uploadLocation(req.v2, upl.Id)
+		resp.expires = upl.Expires
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp createUploadResponse) error {
+		w.Header().Set("Location", resp.location)
+		w.Header().Set("Upload-Expires", uploadExpires(resp.expires))
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			tusHeaders(w)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleUploadOffset() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, uploadOffsetRequest, error) {
+		ctx := r.Context()
+		err := tusResumable(r)
+		if err != nil {
+			return nil, uploadOffsetRequest{}, errors.Wrap(err)
+		}
+		req := uploadOffsetRequest{}
+		req.upload.id = ps.ByName("upload")
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req uploadOffsetRequest) (uploadOffsetResponse, error) {
+		upload, err := base64.ToUint64(req.upload.id)
+		if err != nil {
+			return uploadOffsetResponse{}, errors.Wrap(domain.ErrUploadNotFound)
+		}
+		upl, err := c.stage.Upload(ctx, upload)
+		if err != nil {
+			return uploadOffsetResponse{}, errors.Wrap(err)
+		}
+		resp := uploadOffsetResponse{}
+		resp.size = upl.Size
+		resp.offset = upl.Offset
+		resp.expires = upl.Expires
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp uploadOffsetResponse) error {
+		w.Header().Set("Upload-Length", strconv.FormatInt(resp.size, 10))
+		w.Header().Set("Upload-Offset", strconv.FormatInt(resp.offset, 10))
+		w.Header().Set("Upload-Expires", uploadExpires(resp.expires))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			tusHeaders(w)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleAppendUpload() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, appendUploadRequest, error) {
+		ctx := r.Context()
+		err := tusResumable(r)
+		if err != nil {
+			return nil, appendUploadRequest{}, errors.Wrap(err)
+		}
+		ct := r.Header.Get("Content-Type")
+		if ct != tusChunk {
+			return nil, appendUploadRequest{}, errors.Wrap(domain.ErrWrongContentType)
+		}
+		req := appendUploadRequest{}
+		req.upload.id = ps.ByName("upload")
+		offset, err := uploadOffset(r)
+		if err != nil {
+			return nil, appendUploadRequest{}, errors.Wrap(err)
+		}
+		req.offset = offset
+		req.body = r.Body
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req appendUploadRequest) (appendUploadResponse, error) {
+		upload, err := base64.ToUint64(req.upload.id)
+		if err != nil {
+			return appendUploadResponse{}, errors.Wrap(domain.ErrUploadNotFound)
+		}
+		offset, err := c.stage.Append(ctx, upload, req.offset, req.body)
+		if err != nil {
+			return appendUploadResponse{}, errors.Wrap(err)
+		}
+		upl, err := c.stage.Upload(ctx, upload)
+		if err != nil {
+			return appendUploadResponse{}, errors.Wrap(err)
+		}
+		resp := appendUploadResponse{}
+		resp.offset = offset
+		resp.expires = upl.Expires
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp appendUploadResponse) error {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(resp.offset, 10))
+		w.Header().Set("Upload-Expires", uploadExpires(resp.expires))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			tusHeaders(w)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleTerminateUpload() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, terminateUploadRequest, error) {
+		ctx := r.Context()
+		err := tusResumable(r)
+		if err != nil {
+			return nil, terminateUploadRequest{}, errors.Wrap(err)
+		}
+		req := terminateUploadRequest{}
+		req.upload.id = ps.ByName("upload")
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req terminateUploadRequest) (terminateUploadResponse, error) {
+		upload, err := base64.ToUint64(req.upload.id)
+		if err != nil {
+			return terminateUploadResponse{}, errors.Wrap(domain.ErrUploadNotFound)
+		}
+		err = c.stage.Remove(ctx, upload)
+		if err != nil {
+			return terminateUploadResponse{}, errors.Wrap(err)
+		}
+		resp := terminateUploadResponse{}
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp terminateUploadResponse) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			tusHeaders(w)
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleDirect() httprouter.Handle {
+	input := func(r *http.Request, ps httprouter.Params) (context.Context, directRequest, error) {
+		ctx := r.Context()
+		req := directRequest{}
+		return ctx, req, nil
+	}
+	process := func(ctx context.Context, req directRequest) (directResponse, error) {
+		direct, err := rand.Id()
+		if err != nil {
+			return directResponse{}, errors.Wrap(err)
+		}
+		src, fields, expires, err := c.stor.PresignPost(ctx, direct, c.conf.MaxFileSize)
+		if err != nil {
+			return directResponse{}, errors.Wrap(err)
+		}
+		resp := directResponse{}
+		resp.Direct.Id = base64.FromUint64(direct)
+		resp.Direct.Url = src
+		resp.Direct.Fields = fields
+		resp.Direct.Expires = expires.UTC().Format(time.RFC3339)
+		return resp, nil
+	}
+	output := func(ctx context.Context, w http.ResponseWriter, resp directResponse) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(201)
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx, req, err := input(r, ps)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			resp, err := process(ctx, req)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			err = output(ctx, w, resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleHealth() httprouter.Handle {
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx := r.Context()
+			_, err := c.serv.Health(ctx)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleOpenapi() httprouter.Handle {
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx := r.Context()
+			b, err := openapi()
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, err = w.Write(b)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleProblems() httprouter.Handle {
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx := r.Context()
+			resp := problemsResponse{}
+			for _, e := range errorResponses() {
+				resp.Problems = append(resp.Problems, problem(e))
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			err := json.NewEncoder(w).Encode(resp)
+			if err != nil {
+				return ctx, errors.Wrap(err)
+			}
+			return ctx, nil
+		},
+	)
+}
+
+func (c *controller) handleProblem() httprouter.Handle {
+	return handleHttpRouterError(
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error) {
+			ctx := r.Context()
+			typ := ps.ByName("type")
+			for _, e := range errorResponses() {
+				if e.Error.typ != typ {
+					continue
+				}
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				err := json.NewEncoder(w).Encode(problem(e))
+				if err != nil {
+					return ctx, errors.Wrap(err)
+				}
+				return ctx, nil
+			}
+			return ctx, errors.Wrap(domain.ErrProblemNotFound)
+		},
+	)
+}
+
+func (c *controller) pair(ctx context.Context, album uint64, voterSession string, cred model.Credentials) (pairResponse, error) {
+	voter, err := voterId(voterSession)
+	if err != nil {
+		return pairResponse{}, errors.Wrap(err)
+	}
+	img1, img2, err := c.serv.Pair(ctx, album, voter, cred)
+	if err != nil {
+		return pairResponse{}, errors.Wrap(err)
+	}
+	meta, err := c.serv.Metadata(ctx, album)
+	if err != nil {
+		return pairResponse{}, errors.Wrap(err)
+	}
+	resp := pairResponse{}
+	resp.Album.Title = meta.Title
+	resp.Album.Description = meta.Description
+	resp.Album.Img1.Src = img1.Src
+	resp.Album.Img1.Caption = img1.Caption
+	img1TokenB64 := base64.FromUint64(img1.Token)
+	resp.Album.Img1.Token = img1TokenB64
+	resp.Album.Img2.Src = img2.Src
+	resp.Album.Img2.Caption = img2.Caption
+	img2TokenB64 := base64.FromUint64(img2.Token)
+	resp.Album.Img2.Token = img2TokenB64
+	return resp, nil
+}
+
+func (c *controller) vote(ctx context.Context, album uint64, voterSession string, imgFrom string, imgTo string, outcome string, cred model.Credentials) error {
+	voter, err := voterId(voterSession)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	imgFromToken, err := base64.ToUint64(imgFrom)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	imgToToken, err := base64.ToUint64(imgTo)
+	if err != nil {
+		return errors.Wrap(domain.ErrInvalidId)
+	}
+	err = c.serv.Vote(ctx, album, voter, imgFromToken, imgToToken, outcome, cred)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (c *controller) files(fhs []*multipart.FileHeader) ([]model.File, error) {
+	ff := make([]model.File, 0, len(fhs))
+	for _, fh := range fhs {
+		f, err := c.file(fh)
+		if err != nil {
+			for _, f := range ff {
+				_ = f.Close()
+			}
+			return nil, errors.Wrap(err)
+		}
+		ff = append(ff, f)
+	}
+	return ff, nil
+}
+
+// urls downloads the images in parallel, the first failure cancels the
+// rest and the images that did arrive are closed
+func (c *controller) urls(ctx context.Context, urls []string) ([]model.File, error) {
+	ff := make([]model.File, len(urls))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, rawUrl := range urls {
+		i, rawUrl := i, rawUrl
+		g.Go(func() error {
+			f, err := c.fetch.Fetch(ctx, rawUrl)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+			ff[i] = f
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		for _, f := range ff {
+			_ = f.Close()
+		}
+		return nil, errors.Wrap(err)
+	}
+	return ff, nil
+}
+
+// staged opens the finished uploads, their content is checked the same
+// way as the content of the files of a form
+func (c *controller) staged(ctx context.Context, ids []string) ([]model.File, []uint64, error) {
+	ff := make([]model.File, 0, len(ids))
+	uploads := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		upload, err := base64.ToUint64(id)
+		if err != nil {
+			for _, f := range ff {
+				_ = f.Close()
+			}
+			return nil, nil, errors.Wrap(domain.ErrUploadNotFound)
+		}
+		f, err := c.stage.Open(ctx, upload)
+		if err != nil {
+			for _, f := range ff {
+				_ = f.Close()
+			}
+			return nil, nil, errors.Wrap(err)
+		}
+		ff = append(ff, f)
+		b := make([]byte, 512)
+		n, err := io.ReadFull(f.Reader, b)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			for _, f := range ff {
+				_ = f.Close()
+			}
+			return nil, nil, errors.Wrap(err)
+		}
+		typ := http.DetectContentType(b[:n])
+		if !strings.HasPrefix(typ, "image/") {
+			for _, f := range ff {
+				_ = f.Close()
+			}
+			return nil, nil, errors.Wrap(domain.ErrNotImage)
+		}
+		ff[len(ff)-1].Reader = io.MultiReader(bytes.NewReader(b[:n]), f.Reader)
+		uploads = append(uploads, upload)
+	}
+	return ff, uploads, nil
+}
+
+// direct checks the images the clients put straight into the storage, the
+// policy of the form has already bounded their size, only their first
+// bytes are read here and they are copied into the album by the storage
+func (c *controller) direct(ctx context.Context, ids []string) ([]model.File, []uint64, error) {
+	ff := make([]model.File, 0, len(ids))
+	directs := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		direct, err := base64.ToUint64(id)
+		if err != nil {
+			return nil, nil, errors.Wrap(domain.ErrDirectNotFound)
+		}
+		size, head, err := c.stor.StatDirect(ctx, direct)
+		if err != nil {
+			return nil, nil, errors.Wrap(err)
+		}
+		if size > c.conf.MaxFileSize {
+			return nil, nil, errors.Wrap(domain.ErrImageTooLarge)
+		}
+		typ := http.DetectContentType(head)
+		if !strings.HasPrefix(typ, "image/") {
+			return nil, nil, errors.Wrap(domain.ErrNotImage)
+		}
+		ff = append(ff, model.File{Size: size, Direct: direct})
+		directs = append(directs, direct)
+	}
+	return ff, directs, nil
+}
+
+func (c *controller) file(fh *multipart.FileHeader) (model.File, error) {
+	if fh.Size > c.conf.MaxFileSize {
+		return model.File{}, errors.Wrap(domain.ErrImageTooLarge)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
+	b := make([]byte, 512)
+	_, err = f.Read(b)
+	if err != nil {
+		_ = f.Close()
+		return model.File{}, errors.Wrap(err)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		_ = f.Close()
+		return model.File{}, errors.Wrap(err)
+	}
+	typ := http.DetectContentType(b)
+	if !strings.HasPrefix(typ, "image/") {
+		_ = f.Close()
+		return model.File{}, errors.Wrap(domain.ErrNotImage)
+	}
+	F := model.File{}
+	switch v := f.(type) {
+	case *os.File:
+		closeFn := func() error {
+			_ = v.Close()
+			_ = os.Remove(v.Name())
+			return nil
+		}
+		F = model.NewFile(v, closeFn, fh.Size)
+	case multipart.File:
+		closeFn := func() error {
+			_ = v.Close()
+			return nil
+		}
+		F = model.NewFile(v, closeFn, fh.Size)
+	default:
+		return model.File{}, errors.Wrap(domain.ErrUnknown)
+	}
+	F.Name = fh.Filename
+	return F, nil
+}
+
+func (c *controller) captions(vals []string, n int) ([]string, error) {
+	if len(vals) > n {
+		return nil, errors.Wrap(domain.ErrTooManyCaptions)
+	}
+	for _, caption := range vals {
+		if utf8.RuneCountInString(caption) > c.conf.MaxCaptionLength {
+			return nil, errors.Wrap(domain.ErrCaptionTooLong)
+		}
+	}
+	return vals, nil
+}
+
+// pagination reads the limit, the cursor and the min_comparisons query parameters
+func pagination(query url.Values) (model.Page, error) {
+	page := model.Page{}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return model.Page{}, errors.Wrap(domain.ErrLimitInvalid)
+		}
+		page.Limit = limit
+	}
+	if s := query.Get("cursor"); s != "" {
+		if len(s) != 22 {
+			return model.Page{}, errors.Wrap(domain.ErrCursorInvalid)
+		}
+		rating, err := base64.ToUint64(s[:11])
+		if err != nil {
+			return model.Page{}, errors.Wrap(domain.ErrCursorInvalid)
+		}
+		id, err := base64.ToUint64(s[11:])
+		if err != nil {
+			return model.Page{}, errors.Wrap(domain.ErrCursorInvalid)
+		}
+		page.After = &model.Cursor{Rating: math.Float64frombits(rating), Id: id}
+	}
+	if s := query.Get("min_comparisons"); s != "" {
+		comparisons, err := strconv.Atoi(s)
+		if err != nil || comparisons < 0 {
+			return model.Page{}, errors.Wrap(domain.ErrComparisonsInvalid)
+		}
+		page.MinComparisons = comparisons
+	}
+	return page, nil
+}
+
+// cursor is the opaque form of a position in the leaderboard, the rating
+// followed by the id of the last image of a page
+func cursor(cur model.Cursor) string {
+	return base64.FromUint64(math.Float64bits(cur.Rating)) + base64.FromUint64(cur.Id)
+}
+
+// etagMatch reports whether the If-None-Match header lists the entity tag,
+// weak tags are compared weakly as RFC 7232 requires
+func etagMatch(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func owner(r *http.Request) string {
+	return r.Header.Get("X-Owner-Token")
+}
+
+func credentials(r *http.Request) model.Credentials {
+	cred := model.Credentials{}
+	cred.Code = r.Header.Get("X-Access-Code")
+	cred.Owner = owner(r)
+	cookie, err := r.Cookie("access_pass")
+	if err != nil {
+		return cred
+	}
+	pass, err := base64.ToUint64(cookie.Value)
+	if err != nil {
+		return cred
+	}
+	cred.Pass = pass
+	return cred
+}
+
+func voter(r *http.Request) string {
+	v := r.Header.Get("X-Voter-Session")
+	if v != "" {
+		return v
+	}
+	cookie, err := r.Cookie("voter_session")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// voterId hashes the session of a voter, a voter without one is anonymous
+func voterId(session string) (uint64, error) {
+	if session == "" {
+		return 0x0, nil
+	}
+	hash := fnv.New64a()
+	_, err := io.WriteString(hash, session)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	return hash.Sum64(), nil
+}
+
+// albumValues moves the metadata a v2 request sends as the json part album
+// into the form values a v1 request sends it in
+func albumValues(multi *multipart.Form) error {
+	part := albumPart{}
+	err := jsonPart(multi, &part)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	multi.Value = partValues(part)
+	return nil
+}
+
+// partValues lays the album fields out the way a v1 form would carry them
+func partValues(part albumPart) map[string][]string {
+	vals := map[string]string{
+		"duration":    part.Duration,
+		"ranking":     part.Ranking,
+		"mode":        part.Mode,
+		"title":       part.Title,
+		"description": part.Description,
+		"access":      part.Access,
+		"code":        part.Code,
+		"results":     part.Results,
+	}
+	values := map[string][]string{}
+	for key, val := range vals {
+		if val != "" {
+			values[key] = []string{val}
+		}
+	}
+	if len(part.Captions) > 0 {
+		values["captions"] = part.Captions
+	}
+	return values
+}
+
+// parseMultipartForm reads the form, a body cut off by the size limit is
+// reported as too large rather than as a broken form
+func parseMultipartForm(r *http.Request) error {
+	err := r.ParseMultipartForm(r.ContentLength)
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return errors.Wrap(domain.ErrBodyTooLarge)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func imagesValues(multi *multipart.Form) error {
+	part := imagesPart{}
+	err := jsonPart(multi, &part)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	multi.Value = map[string][]string{}
+	if len(part.Captions) > 0 {
+		multi.Value["captions"] = part.Captions
+	}
+	return nil
+}
+
+func jsonPart(multi *multipart.Form, v any) error {
+	vals := multi.Value["album"]
+	if len(vals) == 0 {
+		return nil
+	}
+	err := json.Unmarshal([]byte(vals[0]), v)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
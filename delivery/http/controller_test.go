@@ -1,21 +1,32 @@
 package http
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/domain/service"
+	"github.com/zitryss/aye-and-nay/infrastructure/fetcher"
+	"github.com/zitryss/aye-and-nay/infrastructure/stager"
+	"github.com/zitryss/aye-and-nay/infrastructure/storage"
 	. "github.com/zitryss/aye-and-nay/internal/testing"
 )
 
@@ -35,6 +46,7 @@ func TestControllerHandle(t *testing.T) {
 		code     int
 		typ      string
 		respBody string
+		cookie   string
 	}
 	contr := controller{}
 	payload := content{}
@@ -53,7 +65,7 @@ func TestControllerHandle(t *testing.T) {
 			want: want{
 				code:     http.StatusCreated,
 				typ:      "application/json; charset=utf-8",
-				respBody: `{"album":{"id":"rRsAAAAAAAA"}}` + "\n",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
 			},
 		},
 		{
@@ -154,71 +166,941 @@ func TestControllerHandle(t *testing.T) {
 				respBody: `{"error":{"code":9,"msg":"duration invalid"}}` + "\n",
 			},
 		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"title", "Cats"}, [2]string{"description", "Which one is the cutest?"}, [2]string{"captions", "Tom"}, [2]string{"captions", "Felix"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"access", "code"}, [2]string{"code", "hunter2"}, [2]string{"results", "closed"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"access", "code"}, [2]string{"code", "correct horse battery staple"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":42,"msg":"code invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"title", "Cats, dogs and parrots"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":29,"msg":"title too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"description", "Which one is the cutest of them all?"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":30,"msg":"description too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"captions", "Tom the grey tabby cat"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":31,"msg":"caption too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h", [2]string{"captions", "Tom"}, [2]string{"captions", "Felix"}, [2]string{"captions", "Garfield"}, [2]string{"captions", "Sylvester"}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":32,"msg":"too many captions"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","captions":["Alan","John"],"urls":["https://example.com/alan.jpg","https://example.com/john.bmp"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","urls":["https://example.com/alan.jpg"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":4,"msg":"not enough images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","urls":["https://example.com/alan.jpg","https://example.com/john.bmp","https://example.com/dennis.png","https://example.com/alan.jpg"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusRequestEntityTooLarge,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":5,"msg":"too many images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","urls":["https://example.com/alan.jpg","file:///etc/passwd"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":56,"msg":"image url invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","captions":["Alan","John"],"urls":["https://example.com/alan.jpg"],"direct":["lBGmGyYB1b0"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"duration":"1h","direct":["lBGmGyYB1b0","!"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusNotFound,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":66,"msg":"direct upload not found"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`{"urls":["https://example.com/alan.jpg","https://example.com/john.bmp"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":8,"msg":"duration not set"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h&captions=Alan&captions=John",
+				reqBody: archiveBody(t, archiveZip, "photos/", "photos/alan.jpg", "photos/audio.ogg", "photos/john.bmp"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveTar, "photos/", "photos/alan.jpg", "photos/audio.ogg", "photos/john.bmp"),
+				headers: map[string]string{"Content-Type": "application/x-tar"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveZip, "alan.jpg", "audio.ogg"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":4,"msg":"not enough images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveTar, "alan.jpg", "john.bmp", "dennis.png", "tim.gif"),
+				headers: map[string]string{"Content-Type": "application/x-tar"},
+			},
+			want: want{
+				code:     http.StatusRequestEntityTooLarge,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":5,"msg":"too many images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveZip, "alan.jpg", "big.jpg"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusRequestEntityTooLarge,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":6,"msg":"image too large"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveZip, "alan.jpg", "../john.bmp"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":65,"msg":"archive invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: archiveBody(t, archiveTar, "/alan.jpg", "john.bmp"),
+				headers: map[string]string{"Content-Type": "application/x-tar"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":65,"msg":"archive invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/?duration=1h",
+				reqBody: strings.NewReader("alan.jpg john.bmp"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":65,"msg":"archive invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: archiveBody(t, archiveZip, "alan.jpg", "john.bmp"),
+				headers: map[string]string{"Content-Type": "application/zip"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":8,"msg":"duration not set"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/albums/",
+				reqBody: strings.NewReader(`duration=1h`),
+				headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			},
+			want: want{
+				code:     http.StatusUnsupportedMediaType,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":3,"msg":"unsupported media type"}}` + "\n",
+			},
+		},
 		{
 			give: give{
 				handle: contr.handleStatus,
 				method: http.MethodGet,
-				target: "/api/albums/rRsAAAAAAAA/status",
-				params: httprouter.Params{httprouter.Param{Key: "album", Value: "rRsAAAAAAAA"}},
+				target: "/api/albums/rRsAAAAAAAA/status",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "rRsAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"title":"Cats","description":"Which one is the cutest?","compression":{"progress":1},"results":{"policy":"always","available":true}}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handlePair,
+				method: http.MethodGet,
+				target: "/api/albums/nkUAAAAAAAA/",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "nkUAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"title":"Cats","description":"Which one is the cutest?","img1":{"token":"f8cAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/21EAAAAAAAA","caption":"Tom"},"img2":{"token":"iakAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/K2IAAAAAAAA","caption":"Felix"}}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleImage,
+				method: http.MethodGet,
+				target: "/api/images/8v7AAAAAAAA/",
+				params: httprouter.Params{httprouter.Param{Key: "token", Value: "8v7AAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "image/png",
+				respBody: png(),
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleVote,
+				method:  http.MethodPatch,
+				target:  "/api/albums/fIIAAAAAAAA/",
+				reqBody: strings.NewReader(`{"album":{"imgFrom":{"token":"fYIAAAAAAAA"},"imgTo":{"token":"foIAAAAAAAA"}}}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAccess,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/access/",
+				reqBody: strings.NewReader(`{"album":{"code":"hunter2"}}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+				cookie:   "access_pass=VQoAAAAAAAA; Path=/api/; HttpOnly; SameSite=Strict",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAccess,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/access/",
+				reqBody: strings.NewReader(`{"album":{"code":"hunter2"}}`),
+				headers: map[string]string{"Content-Type": "text/plain"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusUnsupportedMediaType,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":3,"msg":"unsupported media type"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleDelete,
+				method:  http.MethodDelete,
+				target:  "/api/albums/fIIAAAAAAAA/",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleFreeze,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/freeze/",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleReopen,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/reopen/",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExtend,
+				method:  http.MethodPatch,
+				target:  "/api/albums/fIIAAAAAAAA/expiry/",
+				reqBody: strings.NewReader(`{"album":{"duration":"2h"}}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExtend,
+				method:  http.MethodPatch,
+				target:  "/api/albums/fIIAAAAAAAA/expiry/",
+				reqBody: strings.NewReader(`{"album":{"duration":"2h"}}`),
+				headers: map[string]string{"Content-Type": "text/plain", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusUnsupportedMediaType,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":3,"msg":"unsupported media type"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExtend,
+				method:  http.MethodPatch,
+				target:  "/api/albums/fIIAAAAAAAA/expiry/",
+				reqBody: strings.NewReader(`{"album":{}}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":8,"msg":"duration not set"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExtend,
+				method:  http.MethodPatch,
+				target:  "/api/albums/fIIAAAAAAAA/expiry/",
+				reqBody: strings.NewReader(`{"album":{"duration":"2 hours"}}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":9,"msg":"duration invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAddImages,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/images/",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp"}, false, "", [2]string{"captions", "Alan"}),
+				headers: map[string]string{"Content-Type": payload.boundary, "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"images":[{"id":"HzoAAAAAAAA"},{"id":"LHsAAAAAAAA"}]}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAddImages,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/images/",
+				reqBody: payload.body(t, []string{}, false, ""),
+				headers: map[string]string{"Content-Type": payload.boundary, "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":4,"msg":"not enough images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAddImages,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/images/",
+				reqBody: payload.body(t, []string{"alan.jpg", "audio.ogg"}, false, ""),
+				headers: map[string]string{"Content-Type": payload.boundary, "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusUnsupportedMediaType,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":7,"msg":"unsupported media type"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAddImages,
+				method:  http.MethodPost,
+				target:  "/api/albums/fIIAAAAAAAA/images/",
+				reqBody: payload.body(t, []string{"alan.jpg"}, false, "", [2]string{"captions", "Alan"}, [2]string{"captions", "John"}),
+				headers: map[string]string{"Content-Type": payload.boundary, "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":32,"msg":"too many captions"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/v2/albums",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, false, "", [2]string{"album", `{"duration":"1h","captions":["Alan"]}`}),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/v2/albums",
+				reqBody: payload.body(t, []string{"alan.jpg", "john.bmp", "dennis.png"}, true, "1h"),
+				headers: map[string]string{"Content-Type": payload.boundary},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/problem+json",
+				respBody: `{"type":"/api/v2/problems/duration-not-set","title":"duration not set","status":400,"instance":"/api/v2/albums","code":8}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAlbum,
+				method:  http.MethodPost,
+				target:  "/api/v2/albums",
+				reqBody: strings.NewReader(`{"duration":"1h","urls":["https://example.com/alan.jpg","ftp://example.com/john.bmp"]}`),
+				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/problem+json",
+				respBody: `{"type":"/api/v2/problems/url-invalid","title":"image url invalid","status":400,"instance":"/api/v2/albums","code":56}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleAddImages,
+				method:  http.MethodPost,
+				target:  "/api/v2/albums/fIIAAAAAAAA/images",
+				reqBody: payload.body(t, []string{"alan.jpg"}, false, "", [2]string{"album", `{"captions":["Alan","John"]}`}),
+				headers: map[string]string{"Content-Type": payload.boundary, "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/problem+json",
+				respBody: `{"type":"/api/v2/problems/too-many-captions","title":"too many captions","status":400,"instance":"/api/v2/albums/fIIAAAAAAAA/images","code":32}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleProblem,
+				method:  http.MethodGet,
+				target:  "/api/v2/problems/album-not-found",
+				reqBody: http.NoBody,
+				params:  httprouter.Params{httprouter.Param{Key: "type", Value: "album-not-found"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"type":"/api/v2/problems/album-not-found","title":"album not found","status":404,"code":10}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleProblem,
+				method:  http.MethodGet,
+				target:  "/api/v2/problems/album-lost",
+				reqBody: http.NoBody,
+				params:  httprouter.Params{httprouter.Param{Key: "type", Value: "album-lost"}},
+			},
+			want: want{
+				code:     http.StatusNotFound,
+				typ:      "application/problem+json",
+				respBody: `{"type":"/api/v2/problems/problem-not-found","title":"problem not found","status":404,"instance":"/api/v2/problems/album-lost","code":47}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleRemoveImage,
+				method:  http.MethodDelete,
+				target:  "/api/albums/fIIAAAAAAAA/images/HzoAAAAAAAA/",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}, httprouter.Param{Key: "image", Value: "HzoAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleTop,
+				method: http.MethodGet,
+				target: "/api/albums/byYAAAAAAAA/top/",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"title":"Cats","description":"Which one is the cutest?","images":[{"id":"yFwAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1},{"id":"jVgAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1}]}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleTop,
+				method: http.MethodGet,
+				target: "/api/albums/byYAAAAAAAA/top/?limit=1&min_comparisons=1",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"title":"Cats","description":"Which one is the cutest?","images":[{"id":"yFwAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1}],"next":"AAAAAAAA4D8yFwAAAAAAAA"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleTop,
+				method: http.MethodGet,
+				target: "/api/albums/byYAAAAAAAA/top/?limit=2&cursor=AAAAAAAA4D8yFwAAAAAAAA",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"title":"Cats","description":"Which one is the cutest?","images":[{"id":"yFwAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1},{"id":"jVgAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1}],"next":"AAAAAAAA4D8jVgAAAAAAAA"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleTop,
+				method:  http.MethodGet,
+				target:  "/api/albums/byYAAAAAAAA/top/",
+				headers: map[string]string{"If-None-Match": `"AAAAAAAAAAA", W/"BwAAAAAAAAA"`},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusNotModified,
+				typ:      "",
+				respBody: ``,
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleTop,
+				method: http.MethodGet,
+				target: "/api/albums/byYAAAAAAAA/top/?limit=0",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
 			},
 			want: want{
-				code:     http.StatusOK,
+				code:     http.StatusBadRequest,
 				typ:      "application/json; charset=utf-8",
-				respBody: `{"album":{"compression":{"progress":1}}}` + "\n",
+				respBody: `{"error":{"code":48,"msg":"limit invalid"}}` + "\n",
 			},
 		},
 		{
 			give: give{
-				handle: contr.handlePair,
+				handle: contr.handleTop,
 				method: http.MethodGet,
-				target: "/api/albums/nkUAAAAAAAA/",
-				params: httprouter.Params{httprouter.Param{Key: "album", Value: "nkUAAAAAAAA"}},
+				target: "/api/albums/byYAAAAAAAA/top/?cursor=yFwAAAAAAAA",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
 			},
 			want: want{
-				code:     http.StatusOK,
+				code:     http.StatusBadRequest,
 				typ:      "application/json; charset=utf-8",
-				respBody: `{"album":{"img1":{"token":"f8cAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/21EAAAAAAAA"},"img2":{"token":"iakAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/K2IAAAAAAAA"}}}` + "\n",
+				respBody: `{"error":{"code":49,"msg":"cursor invalid"}}` + "\n",
 			},
 		},
 		{
 			give: give{
-				handle: contr.handleImage,
+				handle: contr.handleTop,
 				method: http.MethodGet,
-				target: "/api/images/8v7AAAAAAAA/",
-				params: httprouter.Params{httprouter.Param{Key: "token", Value: "8v7AAAAAAAA"}},
+				target: "/api/v2/albums/byYAAAAAAAA/top?min_comparisons=-1",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/problem+json",
+				respBody: `{"type":"/api/v2/problems/comparisons-invalid","title":"min comparisons invalid","status":400,"instance":"/api/v2/albums/byYAAAAAAAA/top","code":50}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExport,
+				method:  http.MethodGet,
+				target:  "/api/albums/byYAAAAAAAA/export/",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code: http.StatusOK,
+				typ:  "text/csv; charset=utf-8",
+				respBody: "record,id,caption,rating,rating_low,rating_high,comparisons,from,to,weight\n" +
+					"image,yFwAAAAAAAA,Tom,0.75,0.5,1,1,,,\n" +
+					"image,jVgAAAAAAAA,,0.25,0,0.5,1,,,\n" +
+					"edge,,,,,,,jVgAAAAAAAA,yFwAAAAAAAA,1\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExport,
+				method:  http.MethodGet,
+				target:  "/api/albums/byYAAAAAAAA/export/?format=jsonl",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code: http.StatusOK,
+				typ:  "application/x-ndjson",
+				respBody: `{"type":"image","id":"yFwAAAAAAAA","caption":"Tom","rating":0.75,"ratingLow":0.5,"ratingHigh":1,"comparisons":1}` + "\n" +
+					`{"type":"image","id":"jVgAAAAAAAA","rating":0.25,"ratingLow":0,"ratingHigh":0.5,"comparisons":1}` + "\n" +
+					`{"type":"edge","from":"jVgAAAAAAAA","to":"yFwAAAAAAAA","weight":1}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleExport,
+				method:  http.MethodGet,
+				target:  "/api/albums/byYAAAAAAAA/export/?format=xml",
+				headers: map[string]string{"X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":52,"msg":"format invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader("winner,loser,count\nTom,Felix,3\n\"Felix, the cat\",tom.png\n"),
+				headers: map[string]string{"Content-Type": "text/csv", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
 			},
 			want: want{
 				code:     http.StatusOK,
-				typ:      "image/png",
-				respBody: png(),
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"votes":4}}` + "\n",
 			},
 		},
 		{
 			give: give{
-				handle:  contr.handleVote,
-				method:  http.MethodPatch,
-				target:  "/api/albums/fIIAAAAAAAA/",
-				reqBody: strings.NewReader(`{"album":{"imgFrom":{"token":"fYIAAAAAAAA"},"imgTo":{"token":"foIAAAAAAAA"}}}`),
-				headers: map[string]string{"Content-Type": "application/json; charset=utf-8"},
-				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}},
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader(`{"winner":"Tom","loser":"Felix","count":2}` + "\n" + `{"winner":"Felix","loser":"Tom"}` + "\n"),
+				headers: map[string]string{"Content-Type": "application/x-ndjson", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
 			},
 			want: want{
 				code:     http.StatusOK,
-				typ:      "",
-				respBody: ``,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"album":{"votes":3}}` + "\n",
 			},
 		},
 		{
 			give: give{
-				handle: contr.handleTop,
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader("Tom,Felix,many\n"),
+				headers: map[string]string{"Content-Type": "text/csv", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":54,"msg":"import invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader("Tom,Felix,2000000000\n"),
+				headers: map[string]string{"Content-Type": "text/csv", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":54,"msg":"import invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader(strings.Repeat(`{"winner":"Tom","loser":"Felix"}`+"\n", DefaultControllerConfig.MaxImportEdges+1)),
+				headers: map[string]string{"Content-Type": "application/x-ndjson", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":54,"msg":"import invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle:  contr.handleImport,
+				method:  http.MethodPost,
+				target:  "/api/albums/byYAAAAAAAA/import/",
+				reqBody: strings.NewReader(`{"winner":"Tom","loser":"Felix"}`),
+				headers: map[string]string{"Content-Type": "application/json", "X-Owner-Token": "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"},
+				params:  httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusUnsupportedMediaType,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":3,"msg":"unsupported media type"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleEvents,
 				method: http.MethodGet,
-				target: "/api/albums/byYAAAAAAAA/top/",
+				target: "/api/albums/byYAAAAAAAA/events/",
+				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
+			},
+			want: want{
+				code:     http.StatusOK,
+				typ:      "text/event-stream",
+				respBody: "event: progress\ndata: {\"album\":{\"compression\":{\"progress\":1}}}\n\n" + `event: ratings` + "\n" + `data: {"album":{"votes":1,"images":[{"id":"yFwAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1},{"id":"jVgAAAAAAAA","src":"/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA","rating":0.5,"ratingLow":0.25,"ratingHigh":0.75,"comparisons":1}]}}` + "\n\n",
+			},
+		},
+		{
+			give: give{
+				handle: contr.handleBracket,
+				method: http.MethodGet,
+				target: "/api/albums/byYAAAAAAAA/bracket/",
 				params: httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}},
 			},
 			want: want{
 				code:     http.StatusOK,
 				typ:      "application/json; charset=utf-8",
-				respBody: `{"album":{"images":[{"src":"/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA","rating":0.5},{"src":"/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA","rating":0.5}]}}` + "\n",
+				respBody: `{"album":{"mode":"single-elimination","round":1,"rounds":1,"matches":[{"round":1,"img1":"/aye-and-nay/albums/byYAAAAAAAA/images/cFwAAAAAAAA","img2":"/aye-and-nay/albums/byYAAAAAAAA/images/Oh4AAAAAAAA","winner":"/aye-and-nay/albums/byYAAAAAAAA/images/Oh4AAAAAAAA","done":true}],"champion":"/aye-and-nay/albums/byYAAAAAAAA/images/Oh4AAAAAAAA"}}` + "\n",
 			},
 		},
 		{
@@ -238,7 +1120,7 @@ func TestControllerHandle(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			err := error(nil)
 			serv := service.NewMock(err)
-			contr = newController(DefaultControllerConfig, serv)
+			contr = newController(DefaultControllerConfig, serv, limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
 			fn := tt.give.handle()
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest(tt.give.method, tt.give.target, tt.give.reqBody)
@@ -248,6 +1130,86 @@ func TestControllerHandle(t *testing.T) {
 			fn(w, r, tt.give.params)
 			AssertStatusCode(t, w, tt.want.code)
 			AssertHeader(t, w, "Content-Type", tt.want.typ)
+			AssertHeader(t, w, "Set-Cookie", tt.want.cookie)
+			AssertBody(t, w, tt.want.respBody)
+		})
+	}
+}
+
+func TestControllerMaxBytes(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	type give struct {
+		target    string
+		filenames []string
+		length    int64
+	}
+	type want struct {
+		code     int
+		respBody string
+	}
+	tests := []struct {
+		give
+		want
+	}{
+		{
+			give: give{
+				target:    "/api/albums/fIIAAAAAAAA/images/",
+				filenames: []string{"alan.jpg", "tim.gif", "dennis.png"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				respBody: `{"album":{"images":[{"id":"HzoAAAAAAAA"},{"id":"LHsAAAAAAAA"}]}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				target:    "/api/v2/albums/fIIAAAAAAAA/images",
+				filenames: []string{"alan.jpg", "tim.gif", "dennis.png"},
+			},
+			want: want{
+				code:     http.StatusCreated,
+				respBody: `{"album":{"images":[{"id":"HzoAAAAAAAA"},{"id":"LHsAAAAAAAA"}]}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				target:    "/api/albums/fIIAAAAAAAA/images/",
+				filenames: []string{"alan.jpg", "tim.gif", "dennis.png", "john.bmp"},
+				length:    1,
+			},
+			want: want{
+				code:     http.StatusRequestEntityTooLarge,
+				respBody: `{"error":{"code":2,"msg":"body too large"}}` + "\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			contrConf := DefaultControllerConfig
+			contrConf.MaxFileSize = 40 * kb
+			middleConf := DefaultMiddlewareConfig
+			middleConf.MaxFileSize = 40 * kb
+			serv := service.NewMock(nil)
+			contr := newController(contrConf, serv, limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+			middle := NewMiddleware(middleConf, limiterMockPos{})
+			params := httprouter.Params{httprouter.Param{Key: "album", Value: "fIIAAAAAAAA"}}
+			fn := contr.handleAddImages()
+			handler := middle.maxBytes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fn(w, r, params)
+			}))
+			payload := content{}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, tt.give.target, payload.body(t, tt.give.filenames, false, ""))
+			r.Header.Set("Content-Type", payload.boundary)
+			r.Header.Set("X-Owner-Token", "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw")
+			if tt.give.length > 0 {
+				// a body that lies about its length is cut off by the limit
+				r.ContentLength = tt.give.length
+			}
+			handler.ServeHTTP(w, r)
+			AssertStatusCode(t, w, tt.want.code)
 			AssertBody(t, w, tt.want.respBody)
 		})
 	}
@@ -257,7 +1219,7 @@ type content struct {
 	boundary string
 }
 
-func (c *content) body(t *testing.T, filenames []string, durationOn bool, duration string) io.Reader {
+func (c *content) body(t *testing.T, filenames []string, durationOn bool, duration string, fields ...[2]string) io.Reader {
 	t.Helper()
 	body := bytes.Buffer{}
 	multi := multipart.NewWriter(&body)
@@ -269,19 +1231,245 @@ func (c *content) body(t *testing.T, filenames []string, durationOn bool, durati
 		_, err = part.Write(b)
 		assert.NoError(t, err)
 	}
-	if durationOn {
-		err := multi.WriteField("duration", duration)
-		assert.NoError(t, err)
+	if durationOn {
+		err := multi.WriteField("duration", duration)
+		assert.NoError(t, err)
+	}
+	for _, field := range fields {
+		err := multi.WriteField(field[0], field[1])
+		assert.NoError(t, err)
+	}
+	err := multi.Close()
+	assert.NoError(t, err)
+	c.boundary = multi.FormDataContentType()
+	return &body
+}
+
+// archiveBody packs the test images into a zip or a tar, the content of
+// an entry is the file of the same base name, a trailing slash makes a
+// directory
+func archiveBody(t *testing.T, kind string, names ...string) io.Reader {
+	t.Helper()
+	body := bytes.Buffer{}
+	zw := zip.NewWriter(&body)
+	tw := tar.NewWriter(&body)
+	for _, name := range names {
+		b := []byte(nil)
+		if !strings.HasSuffix(name, "/") {
+			var err error
+			b, err = os.ReadFile("../../testdata/" + path.Base(name))
+			require.NoError(t, err)
+		}
+		switch kind {
+		case archiveZip:
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write(b)
+			require.NoError(t, err)
+		case archiveTar:
+			hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(b)), Typeflag: tar.TypeReg}
+			if strings.HasSuffix(name, "/") {
+				hdr.Mode = 0o755
+				hdr.Typeflag = tar.TypeDir
+			}
+			err := tw.WriteHeader(hdr)
+			require.NoError(t, err)
+			_, err = tw.Write(b)
+			require.NoError(t, err)
+		}
+	}
+	switch kind {
+	case archiveZip:
+		require.NoError(t, zw.Close())
+	case archiveTar:
+		require.NoError(t, tw.Close())
+	}
+	return &body
+}
+
+func png() string {
+	body, _ := io.ReadAll(Png())
+	return string(body)
+}
+
+func TestControllerSocket(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	type give struct {
+		lim  domain.Limiter
+		msgs []string
+	}
+	type want struct {
+		replies []string
+	}
+	pair := `{"type":"pair","album":{"title":"Cats","description":"Which one is the cutest?","img1":{"token":"f8cAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/21EAAAAAAAA","caption":"Tom"},"img2":{"token":"iakAAAAAAAA","src":"/aye-and-nay/albums/nkUAAAAAAAA/images/K2IAAAAAAAA","caption":"Felix"}}}` + "\n"
+	tests := []struct {
+		give
+		want
+	}{
+		{
+			give: give{
+				lim: limiterMockPos{},
+				msgs: []string{
+					`{"type":"pair"}`,
+					`{"type":"vote","album":{"imgFrom":{"token":"f8cAAAAAAAA"},"imgTo":{"token":"iakAAAAAAAA"}}}`,
+				},
+			},
+			want: want{
+				replies: []string{pair, pair},
+			},
+		},
+		{
+			give: give{
+				lim: limiterMockPos{},
+				msgs: []string{
+					`{"type":"pair"`,
+					`{"type":"skip"}`,
+					`{"type":"vote","album":{"imgFrom":{"token":"f8cAAAAAAAA"},"imgTo":{"token":"!"}}}`,
+					`{"type":"pair"}`,
+				},
+			},
+			want: want{
+				replies: []string{
+					`{"type":"error","error":{"code":46,"msg":"message invalid"}}` + "\n",
+					`{"type":"error","error":{"code":46,"msg":"message invalid"}}` + "\n",
+					`{"type":"error","error":{"code":23,"msg":"id invalid"}}` + "\n",
+					pair,
+				},
+			},
+		},
+		{
+			give: give{
+				lim: limiterMockNeg{},
+				msgs: []string{
+					`{"type":"pair"}`,
+				},
+			},
+			want: want{
+				replies: []string{
+					`{"type":"error","error":{"code":1,"msg":"too many requests"}}` + "\n",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			serv := service.NewMock(nil)
+			contr := newController(DefaultControllerConfig, serv, tt.give.lim, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+			fn := contr.handleSocket()
+			params := httprouter.Params{httprouter.Param{Key: "album", Value: "nkUAAAAAAAA"}}
+			mockserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fn(w, r, params)
+			}))
+			defer mockserver.Close()
+			url := "ws" + strings.TrimPrefix(mockserver.URL, "http") + "/api/albums/nkUAAAAAAAA/socket/"
+			conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+			for i, msg := range tt.give.msgs {
+				err = conn.WriteMessage(websocket.TextMessage, []byte(msg))
+				assert.NoError(t, err)
+				_, b, err := conn.ReadMessage()
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.replies[i], string(b))
+			}
+		})
+	}
+}
+
+func TestControllerSocketOrigin(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	type give struct {
+		allowOrigin string
+		origin      string
+		same        bool
+	}
+	type want struct {
+		ok bool
+	}
+	tests := []struct {
+		give
+		want
+	}{
+		{
+			give: give{
+				allowOrigin: "*",
+			},
+			want: want{
+				ok: true,
+			},
+		},
+		{
+			give: give{
+				allowOrigin: "*",
+				same:        true,
+			},
+			want: want{
+				ok: true,
+			},
+		},
+		{
+			give: give{
+				allowOrigin: "https://example.com",
+				origin:      "https://example.com",
+			},
+			want: want{
+				ok: true,
+			},
+		},
+		{
+			give: give{
+				allowOrigin: "*",
+				origin:      "https://example.org",
+			},
+			want: want{
+				ok: false,
+			},
+		},
+		{
+			give: give{
+				allowOrigin: "https://example.com",
+				origin:      "https://example.org",
+			},
+			want: want{
+				ok: false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			conf := DefaultControllerConfig
+			conf.CorsAllowOrigin = tt.give.allowOrigin
+			serv := service.NewMock(nil)
+			contr := newController(conf, serv, limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+			fn := contr.handleSocket()
+			params := httprouter.Params{httprouter.Param{Key: "album", Value: "nkUAAAAAAAA"}}
+			mockserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fn(w, r, params)
+			}))
+			defer mockserver.Close()
+			url := "ws" + strings.TrimPrefix(mockserver.URL, "http") + "/api/albums/nkUAAAAAAAA/socket/"
+			header := http.Header{}
+			if tt.give.origin != "" {
+				header.Set("Origin", tt.give.origin)
+			}
+			if tt.give.same {
+				header.Set("Origin", mockserver.URL)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if !tt.want.ok {
+				assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+				require.NotNil(t, resp)
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			defer conn.Close()
+		})
 	}
-	err := multi.Close()
-	assert.NoError(t, err)
-	c.boundary = multi.FormDataContentType()
-	return &body
-}
-
-func png() string {
-	body, _ := io.ReadAll(Png())
-	return string(body)
 }
 
 func TestControllerError(t *testing.T) {
@@ -520,6 +1708,236 @@ func TestControllerError(t *testing.T) {
 				respBody: `{"error":{"code":22,"msg":"internal server error"}}` + "\n",
 			},
 		},
+		{
+			give: give{
+				err: domain.ErrRankingInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":24,"msg":"ranking invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrOutcomeInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":25,"msg":"outcome invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrModeInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":26,"msg":"mode invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTournamentFinished,
+			},
+			want: want{
+				code:     http.StatusConflict,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":27,"msg":"tournament finished"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTournamentNotFound,
+			},
+			want: want{
+				code:     http.StatusNotFound,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":28,"msg":"tournament not found"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTitleTooLong,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":29,"msg":"title too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrDescriptionTooLong,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":30,"msg":"description too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrCaptionTooLong,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":31,"msg":"caption too long"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTooManyCaptions,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":32,"msg":"too many captions"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrDeleteForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":33,"msg":"not allowed to delete the album"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrFreezeForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":34,"msg":"not allowed to freeze voting"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrReopenForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":35,"msg":"not allowed to reopen voting"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrExtendForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":36,"msg":"not allowed to change the expiry"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrVotingFrozen,
+			},
+			want: want{
+				code:     http.StatusConflict,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":37,"msg":"voting frozen"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrAddForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":38,"msg":"not allowed to add images"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrRemoveForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":39,"msg":"not allowed to remove the image"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrTournamentImmutable,
+			},
+			want: want{
+				code:     http.StatusConflict,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":40,"msg":"tournament cannot be changed"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrAccessInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":41,"msg":"access invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrCodeInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":42,"msg":"code invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrForbidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":43,"msg":"forbidden"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrResultsInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":44,"msg":"results invalid"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrResultsHidden,
+			},
+			want: want{
+				code:     http.StatusForbidden,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":45,"msg":"results hidden"}}` + "\n",
+			},
+		},
+		{
+			give: give{
+				err: domain.ErrMessageInvalid,
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				typ:      "application/json; charset=utf-8",
+				respBody: `{"error":{"code":46,"msg":"message invalid"}}` + "\n",
+			},
+		},
 		{
 			give: give{
 				err: context.Canceled,
@@ -544,7 +1962,7 @@ func TestControllerError(t *testing.T) {
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
 			serv := service.NewMock(tt.give.err)
-			contr := newController(DefaultControllerConfig, serv)
+			contr := newController(DefaultControllerConfig, serv, limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
 			fn := contr.handleHealth()
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest(http.MethodGet, "/api/health/", http.NoBody)
@@ -555,3 +1973,301 @@ func TestControllerError(t *testing.T) {
 		})
 	}
 }
+
+func TestControllerProblems(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	contr := controller{}
+	fn := contr.handleProblems()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/problems", http.NoBody)
+	fn(w, r, nil)
+	AssertStatusCode(t, w, http.StatusOK)
+	AssertHeader(t, w, "Content-Type", "application/json; charset=utf-8")
+	resp := problemsResponse{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	require.NoError(t, err)
+	assert.Len(t, resp.Problems, len(domain.Errors())+3)
+	assert.Contains(t, resp.Problems, problemResponse{Type: "/api/v2/problems/request-canceled", Title: "internal server error", Status: 500, Code: -1})
+	assert.Contains(t, resp.Problems, problemResponse{Type: "/api/v2/problems/voting-frozen", Title: "voting frozen", Status: 409, Code: 37})
+}
+
+func TestControllerTrailingSlash(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	router := newRouter(controller{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/problems/", http.NoBody)
+	router.ServeHTTP(w, r)
+	AssertStatusCode(t, w, http.StatusMovedPermanently)
+	AssertHeader(t, w, "Location", "/api/v2/problems")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/albums/rRsAAAAAAAA/top", http.NoBody)
+	router.ServeHTTP(w, r)
+	AssertStatusCode(t, w, http.StatusMovedPermanently)
+	AssertHeader(t, w, "Location", "/api/albums/rRsAAAAAAAA/top/")
+}
+
+func TestControllerTopCache(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+	fn := contr.handleTop()
+	params := httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/albums/byYAAAAAAAA/top/", http.NoBody)
+	fn(w, r, params)
+	AssertStatusCode(t, w, http.StatusOK)
+	AssertHeader(t, w, "ETag", `"BwAAAAAAAAA"`)
+	AssertHeader(t, w, "Cache-Control", "public, no-cache")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/albums/byYAAAAAAAA/top/", http.NoBody)
+	r.Header.Set("X-Access-Code", "secret")
+	r.Header.Set("If-None-Match", `"BwAAAAAAAAA"`)
+	fn(w, r, params)
+	AssertStatusCode(t, w, http.StatusNotModified)
+	AssertHeader(t, w, "ETag", `"BwAAAAAAAAA"`)
+	AssertHeader(t, w, "Cache-Control", "private, no-cache")
+	AssertBody(t, w, "")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/albums/byYAAAAAAAA/top/", http.NoBody)
+	r.Header.Set("If-None-Match", `"AAAAAAAAAAA"`)
+	fn(w, r, params)
+	AssertStatusCode(t, w, http.StatusOK)
+}
+
+func TestControllerUpload(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	conf := stager.DefaultMemConfig
+	conf.TimeToLive = time.Hour
+	stag := stager.NewMem(conf)
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stag, storage.NewMock())
+	img, err := io.ReadAll(Png())
+	require.NoError(t, err)
+	size := strconv.Itoa(len(img))
+	params := func(location string) httprouter.Params {
+		id := strings.TrimSuffix(strings.TrimPrefix(location, "/api/uploads/"), "/")
+		return httprouter.Params{httprouter.Param{Key: "upload", Value: id}}
+	}
+	create := func(length string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/uploads/", http.NoBody)
+		r.Header.Set("Tus-Resumable", "1.0.0")
+		r.Header.Set("Upload-Length", length)
+		r.Header.Set("Upload-Metadata", "filename YWxhbi5wbmc=,private")
+		contr.handleCreateUpload()(w, r, nil)
+		return w
+	}
+	head := func(location string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodHead, location, http.NoBody)
+		r.Header.Set("Tus-Resumable", "1.0.0")
+		contr.handleUploadOffset()(w, r, params(location))
+		return w
+	}
+	patch := func(location string, offset int, chunk []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, location, bytes.NewReader(chunk))
+		r.Header.Set("Tus-Resumable", "1.0.0")
+		r.Header.Set("Content-Type", "application/offset+octet-stream")
+		r.Header.Set("Upload-Offset", strconv.Itoa(offset))
+		contr.handleAppendUpload()(w, r, params(location))
+		return w
+	}
+	album := func(locations ...string) *httptest.ResponseRecorder {
+		ids := []string(nil)
+		for _, location := range locations {
+			ids = append(ids, `"`+params(location).ByName("upload")+`"`)
+		}
+		body := `{"duration":"1h","uploads":[` + strings.Join(ids, ",") + `]}`
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/albums/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+		contr.handleAlbum()(w, r, nil)
+		return w
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodOptions, "/api/uploads/", http.NoBody)
+	contr.handleTusOptions()(w, r, nil)
+	AssertStatusCode(t, w, http.StatusNoContent)
+	AssertHeader(t, w, "Tus-Version", "1.0.0")
+	AssertHeader(t, w, "Tus-Extension", "creation,expiration,termination")
+	AssertHeader(t, w, "Tus-Max-Size", "524288")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/uploads/", http.NoBody)
+	r.Header.Set("Upload-Length", size)
+	contr.handleCreateUpload()(w, r, nil)
+	AssertStatusCode(t, w, http.StatusPreconditionFailed)
+	AssertHeader(t, w, "Tus-Version", "1.0.0")
+	AssertStatusCode(t, create("0"), http.StatusBadRequest)
+	AssertStatusCode(t, create("524289"), http.StatusRequestEntityTooLarge)
+	w = create(size)
+	AssertStatusCode(t, w, http.StatusCreated)
+	AssertHeader(t, w, "Tus-Resumable", "1.0.0")
+	location1 := w.Header().Get("Location")
+	assert.Regexp(t, `^/api/uploads/[A-Za-z0-9_-]{11}/$`, location1)
+	w = head(location1)
+	AssertStatusCode(t, w, http.StatusOK)
+	AssertHeader(t, w, "Upload-Offset", "0")
+	AssertHeader(t, w, "Upload-Length", size)
+	AssertHeader(t, w, "Cache-Control", "no-store")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, location1, bytes.NewReader(img))
+	r.Header.Set("Tus-Resumable", "1.0.0")
+	r.Header.Set("Content-Type", "application/octet-stream")
+	r.Header.Set("Upload-Offset", "0")
+	contr.handleAppendUpload()(w, r, params(location1))
+	AssertStatusCode(t, w, http.StatusUnsupportedMediaType)
+	w = patch(location1, 0, img[:10])
+	AssertStatusCode(t, w, http.StatusNoContent)
+	AssertHeader(t, w, "Upload-Offset", "10")
+	AssertStatusCode(t, patch(location1, 0, img[:10]), http.StatusConflict)
+	w = create(size)
+	AssertStatusCode(t, w, http.StatusCreated)
+	location2 := w.Header().Get("Location")
+	AssertStatusCode(t, patch(location2, 0, img), http.StatusNoContent)
+	w = album(location1, location2)
+	AssertStatusCode(t, w, http.StatusConflict)
+	AssertBody(t, w, `{"error":{"code":62,"msg":"upload incomplete"}}`+"\n")
+	w = patch(location1, 10, img[10:])
+	AssertStatusCode(t, w, http.StatusNoContent)
+	AssertHeader(t, w, "Upload-Offset", size)
+	w = album(location1, location2)
+	AssertStatusCode(t, w, http.StatusCreated)
+	AssertBody(t, w, `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}`+"\n")
+	AssertStatusCode(t, head(location1), http.StatusNotFound)
+	AssertStatusCode(t, head(location2), http.StatusNotFound)
+	w = create("12")
+	location3 := w.Header().Get("Location")
+	AssertStatusCode(t, patch(location3, 0, []byte("hello, world")), http.StatusNoContent)
+	w = create(size)
+	location4 := w.Header().Get("Location")
+	AssertStatusCode(t, patch(location4, 0, img), http.StatusNoContent)
+	AssertStatusCode(t, album(location3, location4), http.StatusUnsupportedMediaType)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, location3, http.NoBody)
+	r.Header.Set("Tus-Resumable", "1.0.0")
+	contr.handleTerminateUpload()(w, r, params(location3))
+	AssertStatusCode(t, w, http.StatusNoContent)
+	AssertStatusCode(t, head(location3), http.StatusNotFound)
+	AssertStatusCode(t, head(location4), http.StatusOK)
+}
+
+func TestControllerExportZip(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+	fn := contr.handleExport()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/albums/byYAAAAAAAA/export/?format=zip", http.NoBody)
+	r.Header.Set("X-Owner-Token", "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw")
+	fn(w, r, httprouter.Params{httprouter.Param{Key: "album", Value: "byYAAAAAAAA"}})
+	AssertStatusCode(t, w, http.StatusOK)
+	AssertHeader(t, w, "Content-Type", "application/zip")
+	AssertHeader(t, w, "Content-Disposition", `attachment; filename="byYAAAAAAAA.zip"`)
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = string(b)
+	}
+	want := map[string]string{
+		"ratings.csv":            "id,caption,rating,rating_low,rating_high,comparisons\nyFwAAAAAAAA,Tom,0.75,0.5,1,1\njVgAAAAAAAA,,0.25,0,0.5,1\n",
+		"edges.csv":              "from,to,weight\njVgAAAAAAAA,yFwAAAAAAAA,1\n",
+		"images/yFwAAAAAAAA.png": png(),
+		"images/jVgAAAAAAAA.png": png(),
+	}
+	assert.Equal(t, want, files)
+}
+
+func TestControllerAlbumZipBomb(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	body := bytes.Buffer{}
+	zw := zip.NewWriter(&body)
+	for _, name := range []string{"alan.png", "john.png"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, png())
+		require.NoError(t, err)
+		_, err = w.Write(make([]byte, 64*DefaultControllerConfig.MaxFileSize))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.Less(t, int64(body.Len()), DefaultControllerConfig.MaxFileSize)
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+	fn := contr.handleAlbum()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/albums/?duration=1h", &body)
+	r.Header.Set("Content-Type", "application/zip")
+	fn(w, r, nil)
+	AssertStatusCode(t, w, http.StatusRequestEntityTooLarge)
+	AssertBody(t, w, `{"error":{"code":6,"msg":"image too large"}}`+"\n")
+}
+
+func TestControllerAlbumZipLarge(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	body := bytes.Buffer{}
+	zw := zip.NewWriter(&body)
+	for _, name := range []string{"alan.png", "john.png"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = io.WriteString(w, png())
+		require.NoError(t, err)
+		_, err = w.Write(make([]byte, DefaultControllerConfig.MaxFileSize-kb))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.Greater(t, int64(body.Len()), DefaultMiddlewareConfig.MaxFileSize)
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+	middle := NewMiddleware(DefaultMiddlewareConfig, limiterMockPos{})
+	handler := middle.Chain(newRouter(contr))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/albums/?duration=1h", &body)
+	r.Header.Set("Content-Type", "application/zip")
+	handler.ServeHTTP(w, r)
+	AssertStatusCode(t, w, http.StatusCreated)
+	AssertBody(t, w, `{"album":{"id":"rRsAAAAAAAA","owner":"9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw"}}`+"\n")
+}
+
+func TestControllerDirect(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	contr := newController(DefaultControllerConfig, service.NewMock(nil), limiterMockPos{}, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock())
+	fn := contr.handleDirect()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/direct/", http.NoBody)
+	fn(w, r, nil)
+	AssertStatusCode(t, w, http.StatusCreated)
+	AssertHeader(t, w, "Content-Type", "application/json; charset=utf-8")
+	resp := directResponse{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, "/aye-and-nay", resp.Direct.Url)
+	assert.Equal(t, map[string]string{"key": "direct/" + resp.Direct.Id}, resp.Direct.Fields)
+	expires, err := time.Parse(time.RFC3339, resp.Direct.Expires)
+	require.NoError(t, err)
+	assert.True(t, expires.After(time.Now()))
+	fn = contr.handleAlbum()
+	w = httptest.NewRecorder()
+	body := `{"duration":"1h","direct":["` + resp.Direct.Id + `","` + resp.Direct.Id + `"]}`
+	r = httptest.NewRequest(http.MethodPost, "/api/albums/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	fn(w, r, nil)
+	AssertStatusCode(t, w, http.StatusCreated)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -12,13 +13,15 @@ import (
 	"github.com/zitryss/aye-and-nay/pkg/errors"
 )
 
+const problemPath = "/api/v2/problems/"
+
 func handleHttpRouterError(fn func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (context.Context, error)) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		ctx, err := fn(w, r, ps)
 		if err == nil {
 			return
 		}
-		handleError(ctx, w, err)
+		handleError(ctx, w, r, err)
 	}
 }
 
@@ -28,42 +31,86 @@ func handleHttpError(fn func(w http.ResponseWriter, r *http.Request) (context.Co
 		if err == nil {
 			return
 		}
-		handleError(ctx, w, err)
+		handleError(ctx, w, r, err)
 	}
 }
 
-func handleError(ctx context.Context, w http.ResponseWriter, err error) {
+func handleError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
 	service.HandleInnerError(ctx, err)
-	handleOuterError(w, err)
+	handleOuterError(w, r, err)
 }
 
-func handleOuterError(w http.ResponseWriter, err error) {
+func handleOuterError(w http.ResponseWriter, r *http.Request, err error) {
+	resp := outerError(err)
+	if v2(r) {
+		prob := problem(resp)
+		prob.Instance = r.URL.Path
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(prob.Status)
+		_ = json.NewEncoder(w).Encode(prob)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(resp.Error.statusCode)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func outerError(err error) errorResponse {
 	resp := errorResponse{}
-	defer func() {
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		w.WriteHeader(resp.Error.statusCode)
-		_ = json.NewEncoder(w).Encode(resp)
-	}()
 	cause := errors.Cause(err)
 	if e := domain.Error(nil); errors.As(cause, &e) {
 		out := e.Outer()
 		resp.Error.statusCode = out.StatusCode
 		resp.Error.AppCode = out.AppCode
 		resp.Error.UserMsg = out.UserMsg
-		return
+		resp.Error.typ = out.Type
+		return resp
 	}
 	switch cause {
 	case context.Canceled:
 		resp.Error.statusCode = http.StatusInternalServerError
 		resp.Error.AppCode = -1
 		resp.Error.UserMsg = "internal server error"
+		resp.Error.typ = "request-canceled"
 	case context.DeadlineExceeded:
 		resp.Error.statusCode = http.StatusInternalServerError
 		resp.Error.AppCode = -2
 		resp.Error.UserMsg = "internal server error"
+		resp.Error.typ = "deadline-exceeded"
 	default:
 		resp.Error.statusCode = http.StatusInternalServerError
 		resp.Error.AppCode = -3
 		resp.Error.UserMsg = "internal server error"
+		resp.Error.typ = "internal-server-error"
+	}
+	return resp
+}
+
+// v2 tells whether a request belongs to the v2 api, its errors are reported
+// as problem details
+func v2(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/v2/")
+}
+
+func problem(resp errorResponse) problemResponse {
+	prob := problemResponse{}
+	prob.Type = problemPath + resp.Error.typ
+	prob.Title = resp.Error.UserMsg
+	prob.Status = resp.Error.statusCode
+	prob.Code = resp.Error.AppCode
+	return prob
+}
+
+// errorResponses returns every error response the api can send, nil stands
+// for an error the api does not know about
+func errorResponses() []errorResponse {
+	errs := []error{context.Canceled, context.DeadlineExceeded, nil}
+	for _, e := range domain.Errors() {
+		errs = append(errs, e)
+	}
+	resps := make([]errorResponse, 0, len(errs))
+	for _, err := range errs {
+		resps = append(resps, outerError(err))
 	}
+	return resps
 }
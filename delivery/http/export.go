@@ -0,0 +1,209 @@
+package http
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/base64"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	formatCsv   = "csv"
+	formatJsonl = "jsonl"
+	formatZip   = "zip"
+)
+
+func exportFormat(format string) bool {
+	return format == formatCsv || format == formatJsonl || format == formatZip
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case formatJsonl:
+		return "application/x-ndjson"
+	case formatZip:
+		return "application/zip"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+type edge struct {
+	from   uint64
+	to     uint64
+	weight float64
+}
+
+// edges flattens the vote edges in a stable order
+func edges(edgs map[uint64]map[uint64]float64) []edge {
+	ee := []edge(nil)
+	for from, tos := range edgs {
+		for to, weight := range tos {
+			ee = append(ee, edge{from, to, weight})
+		}
+	}
+	slices.SortFunc(ee, func(a, b edge) bool {
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		return a.to < b.to
+	})
+	return ee
+}
+
+func imageRecord(img model.Image) []string {
+	return []string{
+		base64.FromUint64(img.Id),
+		img.Caption,
+		strconv.FormatFloat(img.Rating, 'f', -1, 64),
+		strconv.FormatFloat(img.RatingLow, 'f', -1, 64),
+		strconv.FormatFloat(img.RatingHigh, 'f', -1, 64),
+		strconv.Itoa(img.Comparisons),
+	}
+}
+
+func edgeRecord(e edge) []string {
+	return []string{
+		base64.FromUint64(e.from),
+		base64.FromUint64(e.to),
+		strconv.FormatFloat(e.weight, 'f', -1, 64),
+	}
+}
+
+// writeCsv writes the ratings and the edges as one table, the record
+// column tells them apart and the columns of the other kind stay empty
+func writeCsv(w io.Writer, exp model.Export) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"record", "id", "caption", "rating", "rating_low", "rating_high", "comparisons", "from", "to", "weight"})
+	for _, img := range exp.Images {
+		rec := append([]string{"image"}, imageRecord(img)...)
+		_ = cw.Write(append(rec, "", "", ""))
+	}
+	for _, e := range edges(exp.Edges) {
+		rec := []string{"edge", "", "", "", "", "", ""}
+		_ = cw.Write(append(rec, edgeRecord(e)...))
+	}
+	cw.Flush()
+	err := cw.Error()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func writeJsonl(w io.Writer, exp model.Export) error {
+	enc := json.NewEncoder(w)
+	for _, img := range exp.Images {
+		line := exportImage{"image", base64.FromUint64(img.Id), img.Caption, img.Rating, img.RatingLow, img.RatingHigh, img.Comparisons}
+		err := enc.Encode(line)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	for _, e := range edges(exp.Edges) {
+		line := exportEdge{"edge", base64.FromUint64(e.from), base64.FromUint64(e.to), e.weight}
+		err := enc.Encode(line)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// writeZip writes the ratings and the edges as two tables next to the
+// images, the images are copied from the storage one at a time
+func writeZip(ctx context.Context, w io.Writer, exp model.Export) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "ratings.csv", Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	cw := csv.NewWriter(fw)
+	_ = cw.Write([]string{"id", "caption", "rating", "rating_low", "rating_high", "comparisons"})
+	for _, img := range exp.Images {
+		_ = cw.Write(imageRecord(img))
+	}
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	fw, err = zw.CreateHeader(&zip.FileHeader{Name: "edges.csv", Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	cw = csv.NewWriter(fw)
+	_ = cw.Write([]string{"from", "to", "weight"})
+	for _, e := range edges(exp.Edges) {
+		_ = cw.Write(edgeRecord(e))
+	}
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for _, img := range exp.Images {
+		err := zipImage(ctx, zw, exp, img, now)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// zipImage stores the image as it is, it has been compressed already
+func zipImage(ctx context.Context, zw *zip.Writer, exp model.Export, img model.Image, now time.Time) error {
+	f, err := exp.Open(ctx, img.Id)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer f.Close()
+	br := bufio.NewReader(f.Reader)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err)
+	}
+	name := "images/" + base64.FromUint64(img.Id) + extension(http.DetectContentType(head))
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: now})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, err = io.Copy(fw, br)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	default:
+		return ""
+	}
+}
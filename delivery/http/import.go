@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+// readCsv reads winner,loser,count rows, the count defaults to one and an
+// optional header row is skipped
+func readCsv(r io.Reader, maxEdges int, maxCount int) ([]model.Edge, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	edgs := []model.Edge(nil)
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(domain.ErrImportInvalid, "line %d: %s", line, err)
+		}
+		if len(rec) != 2 && len(rec) != 3 {
+			return nil, errors.Wrapf(domain.ErrImportInvalid, "line %d", line)
+		}
+		if line == 1 && strings.EqualFold(rec[0], "winner") && strings.EqualFold(rec[1], "loser") {
+			continue
+		}
+		e := model.Edge{Winner: rec[0], Loser: rec[1], Count: 1}
+		if len(rec) == 3 {
+			e.Count, err = strconv.Atoi(rec[2])
+			if err != nil {
+				return nil, errors.Wrapf(domain.ErrImportInvalid, "line %d", line)
+			}
+		}
+		err = limit(edgs, e, line, maxEdges, maxCount)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		edgs = append(edgs, e)
+	}
+	return edgs, nil
+}
+
+// readJsonl reads one importEdge object per line, the count defaults to one
+func readJsonl(r io.Reader, maxEdges int, maxCount int) ([]model.Edge, error) {
+	dec := json.NewDecoder(r)
+	edgs := []model.Edge(nil)
+	for line := 1; ; line++ {
+		ie := importEdge{Count: 1}
+		err := dec.Decode(&ie)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(domain.ErrImportInvalid, "line %d: %s", line, err)
+		}
+		e := model.Edge{Winner: ie.Winner, Loser: ie.Loser, Count: ie.Count}
+		err = limit(edgs, e, line, maxEdges, maxCount)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		edgs = append(edgs, e)
+	}
+	return edgs, nil
+}
+
+// limit caps the number of edges and the votes a single edge stands for,
+// the file is refused as a whole before anything is saved
+func limit(edgs []model.Edge, e model.Edge, line int, maxEdges int, maxCount int) error {
+	if len(edgs) == maxEdges {
+		return errors.Wrapf(domain.ErrImportInvalid, "line %d: more than %d edges", line, maxEdges)
+	}
+	if e.Count > maxCount {
+		return errors.Wrapf(domain.ErrImportInvalid, "line %d: count above %d", line, maxCount)
+	}
+	return nil
+}
@@ -31,7 +31,9 @@ type Middleware struct {
 func (m *Middleware) Chain(h http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{m.conf.CorsAllowOrigin},
-		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPatch},
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Origin", "Accept", "Content-Type", "X-Requested-With", "X-Voter-Session", "X-Owner-Token", "X-Access-Code", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata"},
+		ExposedHeaders: []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Length", "Upload-Offset", "Upload-Expires"},
 		MaxAge:         86400, // Firefox caps the value at 86400 (24 hours) while all Chromium-based browsers cap it at 7200 (2 hours)
 	})
 	if m.conf.Debug {
@@ -41,15 +43,12 @@ func (m *Middleware) Chain(h http.Handler) http.Handler {
 		m.recover(
 			m.limit(
 				c.Handler(
-					http.TimeoutHandler(
-						http.MaxBytesHandler(
+					m.timeout(
+						m.maxBytes(
 							m.requestId(
 								m.headers(h),
 							),
-							m.conf.MaxFileSize,
 						),
-						m.conf.WriteTimeout,
-						"",
 					),
 				),
 			),
@@ -95,12 +94,35 @@ func (m *Middleware) limit(h http.Handler) http.Handler {
 			if !allowed {
 				return ctx, errors.Wrap(domain.ErrTooManyRequests)
 			}
+			if creates(r) {
+				allowed, err := m.lim.AllowCreate(ctx, hash.Sum64())
+				if err != nil {
+					return ctx, errors.Wrap(err)
+				}
+				if !allowed {
+					return ctx, errors.Wrap(domain.ErrTooManyRequests)
+				}
+			}
 			h.ServeHTTP(w, r)
 			return ctx, nil
 		},
 	)
 }
 
+// creates tells the requests that take up room in the storage, a new album
+// and a presigned direct upload are limited far more strictly than the rest
+func creates(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/api/albums", "/api/v2/albums", "/api/direct", "/api/v2/direct":
+		return true
+	default:
+		return false
+	}
+}
+
 func ip(r *http.Request) string {
 	xff := r.Header.Get("X-Forwarded-For")
 	if xff != "" {
@@ -111,6 +133,64 @@ func ip(r *http.Request) string {
 	return before
 }
 
+func (m *Middleware) timeout(h http.Handler) http.Handler {
+	th := http.TimeoutHandler(h, m.conf.WriteTimeout, "")
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// the timeout handler buffers the response, an event stream
+			// has to be flushed as it goes and a socket has to be hijacked,
+			// both limit their lifetime themselves, an export would be held
+			// in memory as a whole and is left to the server write timeout
+			path := strings.TrimSuffix(r.URL.Path, "/")
+			if strings.HasSuffix(path, "/events") || strings.HasSuffix(path, "/socket") || strings.HasSuffix(path, "/export") {
+				h.ServeHTTP(w, r)
+				return
+			}
+			th.ServeHTTP(w, r)
+		},
+	)
+}
+
+func (m *Middleware) maxBytes(h http.Handler) http.Handler {
+	ah := http.MaxBytesHandler(h, int64(m.conf.MaxNumberOfFiles)*m.conf.MaxFileSize)
+	fh := http.MaxBytesHandler(h, m.conf.MaxFileSize)
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// a new album carries all of its images in one body, be it a
+			// form or an archive, and so may the images added to an album,
+			// the controller checks every file on its own, any other body
+			// holds a single file at most
+			if r.Method == http.MethodPost && albumBody(r.URL.Path) {
+				ah.ServeHTTP(w, r)
+				return
+			}
+			fh.ServeHTTP(w, r)
+		},
+	)
+}
+
+// albumBody tells whether the path takes the images of an album in one body
+func albumBody(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	if path == "/api/albums" || path == "/api/v2/albums" {
+		return true
+	}
+	if !strings.HasSuffix(path, "/images") {
+		return false
+	}
+	path = strings.TrimSuffix(path, "/images")
+	album := ""
+	switch {
+	case strings.HasPrefix(path, "/api/albums/"):
+		album = strings.TrimPrefix(path, "/api/albums/")
+	case strings.HasPrefix(path, "/api/v2/albums/"):
+		album = strings.TrimPrefix(path, "/api/v2/albums/")
+	default:
+		return false
+	}
+	return album != "" && !strings.Contains(album, "/")
+}
+
 func (m *Middleware) requestId(h http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
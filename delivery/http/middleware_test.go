@@ -19,12 +19,44 @@ func (l limiterMockPos) Allow(_ context.Context, _ uint64) (bool, error) {
 	return true, nil
 }
 
+func (l limiterMockPos) AllowCode(_ context.Context, _ uint64) (bool, error) {
+	return true, nil
+}
+
+func (l limiterMockPos) FailCode(_ context.Context, _ uint64) error {
+	return nil
+}
+
+func (l limiterMockPos) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return true, nil
+}
+
+type limiterMockCreateNeg struct {
+	limiterMockPos
+}
+
+func (l limiterMockCreateNeg) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
 type limiterMockNeg struct{}
 
 func (l limiterMockNeg) Allow(_ context.Context, _ uint64) (bool, error) {
 	return false, nil
 }
 
+func (l limiterMockNeg) AllowCode(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
+func (l limiterMockNeg) FailCode(_ context.Context, _ uint64) error {
+	return nil
+}
+
+func (l limiterMockNeg) AllowCreate(_ context.Context, _ uint64) (bool, error) {
+	return false, nil
+}
+
 func TestMiddlewareRecover(t *testing.T) {
 	if !*unit {
 		t.Skip()
@@ -103,6 +135,25 @@ func TestMiddlewareLimit(t *testing.T) {
 		AssertHeader(t, w, "Content-Type", "application/json; charset=utf-8")
 		AssertBody(t, w, `{"error":{"code":1,"msg":"too many requests"}}`+"\n")
 	})
+	t.Run("Negative2", func(t *testing.T) {
+		t.Parallel()
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(418)
+		}
+		lim := limiterMockCreateNeg{}
+		middle := NewMiddleware(DefaultMiddlewareConfig, lim)
+		handler := middle.limit(http.HandlerFunc(fn))
+		for _, target := range []string{"/api/albums/", "/api/v2/albums", "/api/direct/", "/api/v2/direct"} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, target, http.NoBody)
+			handler.ServeHTTP(w, r)
+			AssertStatusCode(t, w, 429)
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/albums/rRsAAAAAAAA/pair/", http.NoBody)
+		handler.ServeHTTP(w, r)
+		AssertStatusCode(t, w, 418)
+	})
 }
 
 func TestIP(t *testing.T) {
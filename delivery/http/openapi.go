@@ -0,0 +1,83 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+//go:embed openapi.yml
+var openapiYml []byte
+
+var (
+	openapiOnce sync.Once
+	openapiJson []byte
+	openapiErr  error
+)
+
+// openapi returns the api description converted to json, the error codes
+// and the problem types are filled in from the domain errors
+func openapi() ([]byte, error) {
+	openapiOnce.Do(func() {
+		openapiJson, openapiErr = newOpenapi()
+	})
+	return openapiJson, openapiErr
+}
+
+func newOpenapi() ([]byte, error) {
+	doc := map[string]any{}
+	err := yaml.Unmarshal(openapiYml, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	code, err := lookup(doc, "components", "schemas", "ErrorResponse", "properties", "error", "properties", "code")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	typ, err := lookup(doc, "components", "schemas", "Problem", "properties", "type")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	resps := errorResponses()
+	codes := make([]any, 0, len(resps))
+	types := make([]any, 0, len(resps))
+	codeTable := strings.Builder{}
+	codeTable.WriteString(fmt.Sprint(code["description"]))
+	codeTable.WriteString("\n\n| code | status | message |\n| ---: | ---: | --- |\n")
+	typeTable := strings.Builder{}
+	typeTable.WriteString(fmt.Sprint(typ["description"]))
+	typeTable.WriteString("\n\n| type | code | status | title |\n| --- | ---: | ---: | --- |\n")
+	for _, resp := range resps {
+		prob := problem(resp)
+		codes = append(codes, prob.Code)
+		types = append(types, prob.Type)
+		_, _ = fmt.Fprintf(&codeTable, "| %d | %d | %s |\n", prob.Code, prob.Status, prob.Title)
+		_, _ = fmt.Fprintf(&typeTable, "| %s | %d | %d | %s |\n", prob.Type, prob.Code, prob.Status, prob.Title)
+	}
+	code["enum"] = codes
+	code["description"] = codeTable.String()
+	typ["enum"] = types
+	typ["description"] = typeTable.String()
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return b, nil
+}
+
+func lookup(doc map[string]any, keys ...string) (map[string]any, error) {
+	for _, key := range keys {
+		next, ok := doc[key].(map[string]any)
+		if !ok {
+			return nil, errors.Wrapf(errors.New("key not found"), "%s", key)
+		}
+		doc = next
+	}
+	return doc, nil
+}
@@ -0,0 +1,286 @@
+package http
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenapiRoutes(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	doc := openapiDoc(t)
+	f, err := parser.ParseFile(token.NewFileSet(), "router.go", nil, 0)
+	require.NoError(t, err)
+	param := regexp.MustCompile(`:(\w+)`)
+	routes := []string(nil)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || x.Name != "router" || len(call.Args) == 0 {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		path, err := strconv.Unquote(lit.Value)
+		require.NoError(t, err)
+		routes = append(routes, sel.Sel.Name+" "+param.ReplaceAllString(path, "{$1}"))
+		return true
+	})
+	paths := []string(nil)
+	for path, item := range doc["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			paths = append(paths, strings.ToUpper(method)+" "+path)
+		}
+	}
+	assert.NotEmpty(t, routes)
+	assert.ElementsMatch(t, routes, paths)
+}
+
+func TestOpenapiPayloads(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	doc := openapiDoc(t)
+	payloads := map[string]any{
+		"AlbumResponse":     albumResponse{},
+		"StatusResponse":    statusResponse{},
+		"PairResponse":      pairResponse{},
+		"VoteRequest":       voteRequest{},
+		"TopResponse":       topResponse{},
+		"Image":             image{},
+		"ProgressEvent":     progressEvent{},
+		"RatingsEvent":      ratingsEvent{},
+		"BracketResponse":   bracketResponse{},
+		"ExtendRequest":     extendRequest{},
+		"AddImagesResponse": addImagesResponse{},
+		"AccessRequest":     accessRequest{},
+		"SocketMessage":     socketMessage{},
+		"SocketReply":       socketReply{},
+		"ErrorResponse":     errorResponse{},
+		"AlbumPart":         albumPart{},
+		"AlbumJsonRequest":  albumJsonRequest{},
+		"ImagesPart":        imagesPart{},
+		"Problem":           problemResponse{},
+		"ProblemList":       problemsResponse{},
+		"ExportImage":       exportImage{},
+		"ExportEdge":        exportEdge{},
+		"ImportEdge":        importEdge{},
+		"ImportResponse":    importResponse{},
+		"DirectResponse":    directResponse{},
+	}
+	// multipart forms are not json payloads, nested payloads are checked
+	// together with the payloads containing them
+	multipart := []string{"AlbumRequest", "AddImagesRequest", "AlbumRequestV2", "AddImagesRequestV2"}
+	nested := []string{"addedImage", "match", "standing"}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	for name, schema := range schemas {
+		typ := schema.(map[string]any)["type"]
+		if typ != "object" && schema.(map[string]any)["allOf"] == nil {
+			continue
+		}
+		if _, ok := payloads[name]; !ok {
+			assert.Contains(t, multipart, name, "schema %s has no payload", name)
+		}
+	}
+	types := map[string]bool{}
+	for _, v := range payloads {
+		types[reflect.TypeOf(v).Name()] = true
+	}
+	for _, name := range easyjsonTypes(t, "requests.go", "responses.go") {
+		if !types[name] {
+			assert.Contains(t, nested, name, "payload %s has no schema", name)
+		}
+	}
+	for name, v := range payloads {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := schemas[name].(map[string]any)
+			require.True(t, ok)
+			assert.Equal(t, shape(reflect.TypeOf(v)), normalize(t, schemas, schema))
+		})
+	}
+}
+
+func TestOpenapiErrors(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	doc := openapiDoc(t)
+	f, err := parser.ParseFile(token.NewFileSet(), "../../domain/domain/error.go", nil, 0)
+	require.NoError(t, err)
+	codes := []float64{-1, -2, -3}
+	types := []string{problemPath + "request-canceled", problemPath + "deadline-exceeded", problemPath + "internal-server-error"}
+	ast.Inspect(f, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != "AppCode" && key.Name != "Type" {
+			return true
+		}
+		lit, ok := kv.Value.(*ast.BasicLit)
+		require.True(t, ok)
+		if key.Name == "Type" {
+			typ, err := strconv.Unquote(lit.Value)
+			require.NoError(t, err)
+			types = append(types, problemPath+typ)
+			return true
+		}
+		code, err := strconv.ParseInt(lit.Value, 0, 64)
+		require.NoError(t, err)
+		codes = append(codes, float64(code))
+		return true
+	})
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	errResp := schemas["ErrorResponse"].(map[string]any)["properties"].(map[string]any)["error"]
+	code := errResp.(map[string]any)["properties"].(map[string]any)["code"].(map[string]any)
+	enum := []float64(nil)
+	for _, v := range code["enum"].([]any) {
+		enum = append(enum, v.(float64))
+	}
+	assert.ElementsMatch(t, codes, enum)
+	assert.Contains(t, code["description"], "| 10 | 404 | album not found |")
+	assert.Contains(t, code["description"], "| -2 | 500 | internal server error |")
+	prob := schemas["Problem"].(map[string]any)["properties"].(map[string]any)["type"].(map[string]any)
+	enumTypes := []string(nil)
+	for _, v := range prob["enum"].([]any) {
+		enumTypes = append(enumTypes, v.(string))
+	}
+	assert.ElementsMatch(t, types, enumTypes)
+	assert.Contains(t, prob["description"], "| /api/v2/problems/album-not-found | 10 | 404 | album not found |")
+}
+
+func openapiDoc(t *testing.T) map[string]any {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	newRouter(controller{}).ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	doc := map[string]any{}
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	require.NoError(t, err)
+	return doc
+}
+
+func easyjsonTypes(t *testing.T, filenames ...string) []string {
+	t.Helper()
+	names := []string(nil)
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+		require.NoError(t, err)
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE || gen.Doc == nil || !strings.Contains(gen.Doc.Text(), "easyjson:json") {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				names = append(names, spec.(*ast.TypeSpec).Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// shape describes a type the way encoding/json sees it
+func shape(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return shape(t.Elem())
+	case reflect.Struct:
+		props := map[string]any{}
+		fields(t, props)
+		return map[string]any{"type": "object", "properties": props}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": shape(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": shape(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": t.Kind().String()}
+	}
+}
+
+func fields(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			fields(ft, props)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		props[name] = shape(f.Type)
+	}
+}
+
+// normalize resolves the references of a schema and keeps only what shape
+// describes
+func normalize(t *testing.T, schemas map[string]any, schema map[string]any) map[string]any {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		next, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+		require.True(t, ok, ref)
+		return normalize(t, schemas, next)
+	}
+	if all, ok := schema["allOf"].([]any); ok {
+		props := map[string]any{}
+		for _, s := range all {
+			n := normalize(t, schemas, s.(map[string]any))
+			for k, v := range n["properties"].(map[string]any) {
+				props[k] = v
+			}
+		}
+		return map[string]any{"type": "object", "properties": props}
+	}
+	switch schema["type"] {
+	case "object":
+		if add, ok := schema["additionalProperties"].(map[string]any); ok {
+			return map[string]any{"type": "object", "additionalProperties": normalize(t, schemas, add)}
+		}
+		props := map[string]any{}
+		for k, v := range schema["properties"].(map[string]any) {
+			props[k] = normalize(t, schemas, v.(map[string]any))
+		}
+		return map[string]any{"type": "object", "properties": props}
+	case "array":
+		return map[string]any{"type": "array", "items": normalize(t, schemas, schema["items"].(map[string]any))}
+	default:
+		return map[string]any{"type": schema["type"]}
+	}
+}
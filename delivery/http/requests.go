@@ -3,6 +3,7 @@
 package http
 
 import (
+	"io"
 	"mime/multipart"
 	"time"
 
@@ -10,27 +11,54 @@ import (
 )
 
 type albumRequest struct {
-	ff    []model.File
-	multi *multipart.Form
-	dur   time.Duration
+	ff      []model.File
+	multi   *multipart.Form
+	uploads []uint64
+	directs []uint64
+	opts    model.AlbumOptions
+}
+
+//easyjson:json
+type albumPart struct {
+	Duration    string   `json:"duration"`
+	Ranking     string   `json:"ranking"`
+	Mode        string   `json:"mode"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Captions    []string `json:"captions"`
+	Access      string   `json:"access"`
+	Code        string   `json:"code"`
+	Results     string   `json:"results"`
+}
+
+//easyjson:json
+type albumJsonRequest struct {
+	albumPart
+	Urls    []string `json:"urls"`
+	Uploads []string `json:"uploads"`
+	Direct  []string `json:"direct"`
 }
 
 type statusRequest struct {
 	album struct {
 		id string
 	}
+	owner string
 }
 
 type pairRequest struct {
 	album struct {
 		id string
 	}
+	voter string
+	cred  model.Credentials
 }
 
 type imageRequest struct {
 	image struct {
 		token string
 	}
+	cred model.Credentials
 }
 
 //easyjson:json
@@ -43,6 +71,32 @@ type voteRequest struct {
 		ImgTo struct {
 			Token string `json:"token"`
 		} `json:"imgTo"`
+		Outcome string `json:"outcome"`
+	} `json:"album"`
+	voter string
+	cred  model.Credentials
+}
+
+type socketRequest struct {
+	album struct {
+		id string
+	}
+	voter string
+	cred  model.Credentials
+	ip    uint64
+}
+
+//easyjson:json
+type socketMessage struct {
+	Type  string `json:"type"`
+	Album struct {
+		ImgFrom struct {
+			Token string `json:"token"`
+		} `json:"imgFrom"`
+		ImgTo struct {
+			Token string `json:"token"`
+		} `json:"imgTo"`
+		Outcome string `json:"outcome"`
 	} `json:"album"`
 }
 
@@ -50,4 +104,141 @@ type topRequest struct {
 	album struct {
 		id string
 	}
+	cred  model.Credentials
+	page  model.Page
+	match string
+}
+
+type eventsRequest struct {
+	album struct {
+		id string
+	}
+	cred model.Credentials
+}
+
+type bracketRequest struct {
+	album struct {
+		id string
+	}
+	cred model.Credentials
+}
+
+//easyjson:json
+type accessRequest struct {
+	Album struct {
+		id   string
+		Code string `json:"code"`
+	} `json:"album"`
+	cred model.Credentials
+}
+
+type deleteRequest struct {
+	album struct {
+		id string
+	}
+	owner string
+}
+
+type freezeRequest struct {
+	album struct {
+		id string
+	}
+	owner string
+}
+
+type reopenRequest struct {
+	album struct {
+		id string
+	}
+	owner string
+}
+
+//easyjson:json
+type extendRequest struct {
+	Album struct {
+		id       string
+		Duration string `json:"duration"`
+	} `json:"album"`
+	owner string
+	dur   time.Duration
+}
+
+type addImagesRequest struct {
+	album struct {
+		id string
+	}
+	owner    string
+	ff       []model.File
+	multi    *multipart.Form
+	captions []string
+}
+
+//easyjson:json
+type imagesPart struct {
+	Captions []string `json:"captions"`
+}
+
+type removeImageRequest struct {
+	album struct {
+		id string
+	}
+	image struct {
+		id string
+	}
+	owner string
+}
+
+type exportRequest struct {
+	album struct {
+		id string
+	}
+	owner  string
+	format string
+}
+
+type importRequest struct {
+	album struct {
+		id string
+	}
+	owner string
+	edgs  []model.Edge
+}
+
+type tusOptionsRequest struct {
+}
+
+type createUploadRequest struct {
+	size int64
+	name string
+	v2   bool
+}
+
+type uploadOffsetRequest struct {
+	upload struct {
+		id string
+	}
+}
+
+type appendUploadRequest struct {
+	upload struct {
+		id string
+	}
+	offset int64
+	body   io.Reader
+}
+
+type terminateUploadRequest struct {
+	upload struct {
+		id string
+	}
+}
+
+type directRequest struct {
+}
+
+//easyjson:json
+type importEdge struct {
+	Winner string `json:"winner"`
+	Loser  string `json:"loser"`
+	Count  int    `json:"count"`
 }
@@ -3,35 +3,49 @@
 package http
 
 import (
+	"time"
+
 	"github.com/zitryss/aye-and-nay/domain/model"
 )
 
 //easyjson:json
 type albumResponse struct {
 	Album struct {
-		Id string `json:"id"`
+		Id    string `json:"id"`
+		Owner string `json:"owner"`
 	} `json:"album"`
 }
 
 //easyjson:json
 type statusResponse struct {
 	Album struct {
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
 		Compression struct {
 			Progress float64 `json:"progress"`
 		} `json:"compression"`
+		Results struct {
+			Policy      string `json:"policy"`
+			Available   bool   `json:"available"`
+			AvailableAt string `json:"availableAt,omitempty"`
+		} `json:"results"`
 	} `json:"album"`
 }
 
 //easyjson:json
 type pairResponse struct {
 	Album struct {
-		Img1 struct {
-			Token string `json:"token"`
-			Src   string `json:"src"`
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+		Img1        struct {
+			Token   string `json:"token"`
+			Src     string `json:"src"`
+			Caption string `json:"caption,omitempty"`
 		} `json:"img1"`
 		Img2 struct {
-			Token string `json:"token"`
-			Src   string `json:"src"`
+			Token   string `json:"token"`
+			Src     string `json:"src"`
+			Caption string `json:"caption,omitempty"`
 		} `json:"img2"`
 	} `json:"album"`
 }
@@ -43,17 +57,183 @@ type imageResponse struct {
 type voteResponse struct {
 }
 
+type socketResponse struct {
+	album uint64
+}
+
+//easyjson:json
+type socketReply struct {
+	Type string `json:"type"`
+	*pairResponse
+	*errorResponse
+}
+
 //easyjson:json
 type topResponse struct {
 	Album struct {
-		Images []image `json:"images"`
+		Title       string  `json:"title,omitempty"`
+		Description string  `json:"description,omitempty"`
+		Images      []image `json:"images"`
+		Next        string  `json:"next,omitempty"`
 	} `json:"album"`
+	etag        string
+	private     bool
+	notModified bool
 }
 
 //easyjson:json
 type image struct {
-	Src    string  `json:"src"`
-	Rating float64 `json:"rating"`
+	Id          string  `json:"id"`
+	Src         string  `json:"src"`
+	Caption     string  `json:"caption,omitempty"`
+	Rating      float64 `json:"rating"`
+	RatingLow   float64 `json:"ratingLow"`
+	RatingHigh  float64 `json:"ratingHigh"`
+	Comparisons int     `json:"comparisons"`
+}
+
+type eventsResponse struct {
+	events <-chan model.Event
+}
+
+//easyjson:json
+type progressEvent struct {
+	Album struct {
+		Compression struct {
+			Progress float64 `json:"progress"`
+		} `json:"compression"`
+	} `json:"album"`
+}
+
+//easyjson:json
+type ratingsEvent struct {
+	Album struct {
+		Votes  int     `json:"votes"`
+		Images []image `json:"images,omitempty"`
+	} `json:"album"`
+}
+
+//easyjson:json
+type bracketResponse struct {
+	Album struct {
+		Mode      string     `json:"mode"`
+		Round     int        `json:"round"`
+		Rounds    int        `json:"rounds"`
+		Matches   []match    `json:"matches"`
+		Standings []standing `json:"standings,omitempty"`
+		Champion  string     `json:"champion,omitempty"`
+	} `json:"album"`
+}
+
+//easyjson:json
+type match struct {
+	Round  int    `json:"round"`
+	Img1   string `json:"img1"`
+	Img2   string `json:"img2,omitempty"`
+	Winner string `json:"winner,omitempty"`
+	Done   bool   `json:"done"`
+}
+
+//easyjson:json
+type standing struct {
+	Src   string  `json:"src"`
+	Score float64 `json:"score"`
+}
+
+type accessResponse struct {
+	pass uint64
+}
+
+type deleteResponse struct {
+}
+
+type freezeResponse struct {
+}
+
+type reopenResponse struct {
+}
+
+type extendResponse struct {
+}
+
+//easyjson:json
+type addImagesResponse struct {
+	Album struct {
+		Images []addedImage `json:"images"`
+	} `json:"album"`
+}
+
+//easyjson:json
+type addedImage struct {
+	Id string `json:"id"`
+}
+
+type removeImageResponse struct {
+}
+
+type exportResponse struct {
+	album  string
+	format string
+	exp    model.Export
+}
+
+//easyjson:json
+type exportImage struct {
+	Type        string  `json:"type"`
+	Id          string  `json:"id"`
+	Caption     string  `json:"caption,omitempty"`
+	Rating      float64 `json:"rating"`
+	RatingLow   float64 `json:"ratingLow"`
+	RatingHigh  float64 `json:"ratingHigh"`
+	Comparisons int     `json:"comparisons"`
+}
+
+//easyjson:json
+type exportEdge struct {
+	Type   string  `json:"type"`
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Weight float64 `json:"weight"`
+}
+
+//easyjson:json
+type importResponse struct {
+	Album struct {
+		Votes int `json:"votes"`
+	} `json:"album"`
+}
+
+type tusOptionsResponse struct {
+	maxSize int64
+}
+
+type createUploadResponse struct {
+	location string
+	expires  time.Time
+}
+
+type uploadOffsetResponse struct {
+	size    int64
+	offset  int64
+	expires time.Time
+}
+
+type appendUploadResponse struct {
+	offset  int64
+	expires time.Time
+}
+
+type terminateUploadResponse struct {
+}
+
+//easyjson:json
+type directResponse struct {
+	Direct struct {
+		Id      string            `json:"id"`
+		Url     string            `json:"url"`
+		Fields  map[string]string `json:"fields"`
+		Expires string            `json:"expires"`
+	} `json:"direct"`
 }
 
 //easyjson:json
@@ -62,5 +242,20 @@ type errorResponse struct {
 		statusCode int
 		AppCode    int    `json:"code"`
 		UserMsg    string `json:"msg"`
+		typ        string
 	} `json:"error"`
 }
+
+//easyjson:json
+type problemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Instance string `json:"instance,omitempty"`
+	Code     int    `json:"code"`
+}
+
+//easyjson:json
+type problemsResponse struct {
+	Problems []problemResponse `json:"problems"`
+}
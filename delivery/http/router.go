@@ -20,7 +20,72 @@ func newRouter(contr controller) http.Handler {
 	router.PATCH("/api/albums/:album/vote/", contr.handleVote())
 	// router.GET("/api/albums/:album/top", contr.handleTop())
 	router.GET("/api/albums/:album/top/", contr.handleTop())
+	// router.GET("/api/albums/:album/socket", contr.handleSocket())
+	router.GET("/api/albums/:album/socket/", contr.handleSocket())
+	// router.GET("/api/albums/:album/events", contr.handleEvents())
+	router.GET("/api/albums/:album/events/", contr.handleEvents())
+	// router.GET("/api/albums/:album/bracket", contr.handleBracket())
+	router.GET("/api/albums/:album/bracket/", contr.handleBracket())
+	// router.POST("/api/albums/:album/access", contr.handleAccess())
+	router.POST("/api/albums/:album/access/", contr.handleAccess())
+	// router.DELETE("/api/albums/:album", contr.handleDelete())
+	router.DELETE("/api/albums/:album/", contr.handleDelete())
+	// router.POST("/api/albums/:album/freeze", contr.handleFreeze())
+	router.POST("/api/albums/:album/freeze/", contr.handleFreeze())
+	// router.POST("/api/albums/:album/reopen", contr.handleReopen())
+	router.POST("/api/albums/:album/reopen/", contr.handleReopen())
+	// router.PATCH("/api/albums/:album/expiry", contr.handleExtend())
+	router.PATCH("/api/albums/:album/expiry/", contr.handleExtend())
+	// router.POST("/api/albums/:album/images", contr.handleAddImages())
+	router.POST("/api/albums/:album/images/", contr.handleAddImages())
+	// router.DELETE("/api/albums/:album/images/:image", contr.handleRemoveImage())
+	router.DELETE("/api/albums/:album/images/:image/", contr.handleRemoveImage())
+	// router.GET("/api/albums/:album/export", contr.handleExport())
+	router.GET("/api/albums/:album/export/", contr.handleExport())
+	// router.POST("/api/albums/:album/import", contr.handleImport())
+	router.POST("/api/albums/:album/import/", contr.handleImport())
+	// router.OPTIONS("/api/uploads", contr.handleTusOptions())
+	router.OPTIONS("/api/uploads/", contr.handleTusOptions())
+	// router.POST("/api/uploads", contr.handleCreateUpload())
+	router.POST("/api/uploads/", contr.handleCreateUpload())
+	// router.HEAD("/api/uploads/:upload", contr.handleUploadOffset())
+	router.HEAD("/api/uploads/:upload/", contr.handleUploadOffset())
+	// router.PATCH("/api/uploads/:upload", contr.handleAppendUpload())
+	router.PATCH("/api/uploads/:upload/", contr.handleAppendUpload())
+	// router.DELETE("/api/uploads/:upload", contr.handleTerminateUpload())
+	router.DELETE("/api/uploads/:upload/", contr.handleTerminateUpload())
+	// router.POST("/api/direct", contr.handleDirect())
+	router.POST("/api/direct/", contr.handleDirect())
 	// router.GET("/api/health", contr.handleHealth())
 	router.GET("/api/health/", contr.handleHealth())
+	router.GET("/api/openapi.json", contr.handleOpenapi())
+	// v2 paths have no trailing slash, a request with one is redirected
+	router.POST("/api/v2/albums", contr.handleAlbum())
+	router.GET("/api/v2/albums/:album/status", contr.handleStatus())
+	router.GET("/api/v2/albums/:album/pair", contr.handlePair())
+	router.GET("/api/v2/images/:token", contr.handleImage())
+	router.PATCH("/api/v2/albums/:album/vote", contr.handleVote())
+	router.GET("/api/v2/albums/:album/top", contr.handleTop())
+	router.GET("/api/v2/albums/:album/socket", contr.handleSocket())
+	router.GET("/api/v2/albums/:album/events", contr.handleEvents())
+	router.GET("/api/v2/albums/:album/bracket", contr.handleBracket())
+	router.POST("/api/v2/albums/:album/access", contr.handleAccess())
+	router.DELETE("/api/v2/albums/:album", contr.handleDelete())
+	router.POST("/api/v2/albums/:album/freeze", contr.handleFreeze())
+	router.POST("/api/v2/albums/:album/reopen", contr.handleReopen())
+	router.PATCH("/api/v2/albums/:album/expiry", contr.handleExtend())
+	router.POST("/api/v2/albums/:album/images", contr.handleAddImages())
+	router.DELETE("/api/v2/albums/:album/images/:image", contr.handleRemoveImage())
+	router.GET("/api/v2/albums/:album/export", contr.handleExport())
+	router.POST("/api/v2/albums/:album/import", contr.handleImport())
+	router.OPTIONS("/api/v2/uploads", contr.handleTusOptions())
+	router.POST("/api/v2/uploads", contr.handleCreateUpload())
+	router.HEAD("/api/v2/uploads/:upload", contr.handleUploadOffset())
+	router.PATCH("/api/v2/uploads/:upload", contr.handleAppendUpload())
+	router.DELETE("/api/v2/uploads/:upload", contr.handleTerminateUpload())
+	router.POST("/api/v2/direct", contr.handleDirect())
+	router.GET("/api/v2/health", contr.handleHealth())
+	router.GET("/api/v2/problems", contr.handleProblems())
+	router.GET("/api/v2/problems/:type", contr.handleProblem())
 	return router
 }
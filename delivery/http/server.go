@@ -21,9 +21,13 @@ func NewServer(
 	conf ServerConfig,
 	middle func(http.Handler) http.Handler,
 	serv domain.Servicer,
+	lim domain.Limiter,
+	fetch domain.Fetcher,
+	stage domain.Stager,
+	stor domain.Storager,
 	serverWait chan<- error,
 ) (*Server, error) {
-	contr := newController(conf.Controller, serv)
+	contr := newController(conf.Controller, serv, lim, fetch, stage, stor)
 	router := newRouter(contr)
 	handler := middle(router)
 	srv, err := newServer(conf, handler)
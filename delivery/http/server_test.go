@@ -14,6 +14,8 @@ import (
 	"github.com/zitryss/aye-and-nay/infrastructure/cache"
 	"github.com/zitryss/aye-and-nay/infrastructure/compressor"
 	"github.com/zitryss/aye-and-nay/infrastructure/database"
+	"github.com/zitryss/aye-and-nay/infrastructure/fetcher"
+	"github.com/zitryss/aye-and-nay/infrastructure/stager"
 	"github.com/zitryss/aye-and-nay/infrastructure/storage"
 	"github.com/zitryss/aye-and-nay/internal/client"
 )
@@ -40,7 +42,7 @@ func TestServer(t *testing.T) {
 
 	middle := NewMiddleware(DefaultMiddlewareConfig, cach)
 	srvWait := make(chan error, 1)
-	srv, err := NewServer(DefaultServerConfig, middle.Chain, serv, srvWait)
+	srv, err := NewServer(DefaultServerConfig, middle.Chain, serv, cach, fetcher.NewMock(), stager.NewMem(stager.DefaultMemConfig), storage.NewMock(), srvWait)
 	require.NoError(t, err)
 
 	mockserver := httptest.NewServer(srv.srv.Handler)
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	myb64 "github.com/zitryss/aye-and-nay/pkg/base64"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+// the uploads speak the core tus protocol with the creation, expiration
+// and termination extensions, see https://tus.io/protocols/resumable-upload
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+	tusChunk      = "application/offset+octet-stream"
+)
+
+// tusHeaders go out with every response, the supported version tells a
+// client that failed the version check what it should speak instead
+func tusHeaders(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+}
+
+func tusResumable(r *http.Request) error {
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		return errors.Wrap(domain.ErrTusVersion)
+	}
+	return nil
+}
+
+func uploadLength(r *http.Request) (int64, error) {
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 1 {
+		return 0, errors.Wrap(domain.ErrUploadInvalid)
+	}
+	return size, nil
+}
+
+func uploadOffset(r *http.Request) (int64, error) {
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, errors.Wrap(domain.ErrUploadInvalid)
+	}
+	return offset, nil
+}
+
+// uploadFilename reads the name of the file out of the Upload-Metadata
+// header, it holds comma separated keys each followed by a base64 encoded
+// value, the value may be left out
+func uploadFilename(r *http.Request) (string, error) {
+	header := r.Header.Get("Upload-Metadata")
+	if header == "" {
+		return "", nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return "", errors.Wrap(domain.ErrUploadInvalid)
+		}
+		b, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return "", errors.Wrap(domain.ErrUploadInvalid)
+		}
+		if key == "filename" {
+			return string(b), nil
+		}
+	}
+	return "", nil
+}
+
+func uploadLocation(v2 bool, upload uint64) string {
+	if v2 {
+		return "/api/v2/uploads/" + myb64.FromUint64(upload)
+	}
+	return "/api/uploads/" + myb64.FromUint64(upload) + "/"
+}
+
+func uploadExpires(expires time.Time) string {
+	return expires.UTC().Format(http.TimeFormat)
+}
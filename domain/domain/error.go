@@ -19,6 +19,7 @@ var (
 			StatusCode: http.StatusTooManyRequests,
 			AppCode:    0x1,
 			UserMsg:    "too many requests",
+			Type:       "too-many-requests",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -30,6 +31,7 @@ var (
 			StatusCode: http.StatusRequestEntityTooLarge,
 			AppCode:    0x2,
 			UserMsg:    "body too large",
+			Type:       "body-too-large",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -41,6 +43,7 @@ var (
 			StatusCode: http.StatusUnsupportedMediaType,
 			AppCode:    0x3,
 			UserMsg:    "unsupported media type",
+			Type:       "wrong-content-type",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -52,6 +55,7 @@ var (
 			StatusCode: http.StatusBadRequest,
 			AppCode:    0x4,
 			UserMsg:    "not enough images",
+			Type:       "not-enough-images",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -63,6 +67,7 @@ var (
 			StatusCode: http.StatusRequestEntityTooLarge,
 			AppCode:    0x5,
 			UserMsg:    "too many images",
+			Type:       "too-many-images",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -74,6 +79,7 @@ var (
 			StatusCode: http.StatusRequestEntityTooLarge,
 			AppCode:    0x6,
 			UserMsg:    "image too large",
+			Type:       "image-too-large",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -85,6 +91,7 @@ var (
 			StatusCode: http.StatusUnsupportedMediaType,
 			AppCode:    0x7,
 			UserMsg:    "unsupported media type",
+			Type:       "not-image",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -96,6 +103,7 @@ var (
 			StatusCode: http.StatusBadRequest,
 			AppCode:    0x8,
 			UserMsg:    "duration not set",
+			Type:       "duration-not-set",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -107,6 +115,7 @@ var (
 			StatusCode: http.StatusBadRequest,
 			AppCode:    0x9,
 			UserMsg:    "duration invalid",
+			Type:       "duration-invalid",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -118,6 +127,7 @@ var (
 			StatusCode: http.StatusBadRequest,
 			AppCode:    0x17,
 			UserMsg:    "id invalid",
+			Type:       "invalid-id",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -129,6 +139,7 @@ var (
 			StatusCode: http.StatusNotFound,
 			AppCode:    0xA,
 			UserMsg:    "album not found",
+			Type:       "album-not-found",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -140,6 +151,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0xB,
 			UserMsg:    "internal server error",
+			Type:       "pair-not-found",
 		},
 		innerError: innerError{
 			Level:  LogError,
@@ -151,6 +163,7 @@ var (
 			StatusCode: http.StatusNotFound,
 			AppCode:    0xC,
 			UserMsg:    "token not found",
+			Type:       "token-not-found",
 		},
 		innerError: innerError{
 			Level:  LogDebug,
@@ -162,6 +175,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0xD,
 			UserMsg:    "internal server error",
+			Type:       "image-not-found",
 		},
 		innerError: innerError{
 			Level:  LogError,
@@ -173,6 +187,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0xE,
 			UserMsg:    "internal server error",
+			Type:       "album-already-exists",
 		},
 		innerError: innerError{
 			Level:  LogError,
@@ -184,6 +199,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0xF,
 			UserMsg:    "internal server error",
+			Type:       "token-already-exists",
 		},
 		innerError: innerError{
 			Level:  LogError,
@@ -195,6 +211,7 @@ var (
 			StatusCode: http.StatusUnsupportedMediaType,
 			AppCode:    0x10,
 			UserMsg:    "unsupported media type",
+			Type:       "unsupported-media-type",
 		},
 		innerError: innerError{
 			Level:  LogError,
@@ -206,6 +223,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x11,
 			UserMsg:    "internal server error",
+			Type:       "third-party-unavailable",
 		},
 		innerError: innerError{
 			Level:  LogCritical,
@@ -217,6 +235,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x12,
 			UserMsg:    "internal server error",
+			Type:       "bad-health-compressor",
 		},
 		innerError: innerError{
 			Level:  LogCritical,
@@ -228,6 +247,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x13,
 			UserMsg:    "internal server error",
+			Type:       "bad-health-storage",
 		},
 		innerError: innerError{
 			Level:  LogCritical,
@@ -239,6 +259,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x14,
 			UserMsg:    "internal server error",
+			Type:       "bad-health-database",
 		},
 		innerError: innerError{
 			Level:  LogCritical,
@@ -250,6 +271,7 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x15,
 			UserMsg:    "internal server error",
+			Type:       "bad-health-cache",
 		},
 		innerError: innerError{
 			Level:  LogCritical,
@@ -261,14 +283,617 @@ var (
 			StatusCode: http.StatusInternalServerError,
 			AppCode:    0x16,
 			UserMsg:    "internal server error",
+			Type:       "unknown",
 		},
 		innerError: innerError{
 			Level:  LogError,
 			DevMsg: "unknown",
 		},
 	}
+	ErrRankingInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x18,
+			UserMsg:    "ranking invalid",
+			Type:       "ranking-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "ranking invalid",
+		},
+	}
+	ErrOutcomeInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x19,
+			UserMsg:    "outcome invalid",
+			Type:       "outcome-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "outcome invalid",
+		},
+	}
+	ErrModeInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x1A,
+			UserMsg:    "mode invalid",
+			Type:       "mode-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "mode invalid",
+		},
+	}
+	ErrTournamentFinished = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x1B,
+			UserMsg:    "tournament finished",
+			Type:       "tournament-finished",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "tournament finished",
+		},
+	}
+	ErrTournamentNotFound = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusNotFound,
+			AppCode:    0x1C,
+			UserMsg:    "tournament not found",
+			Type:       "tournament-not-found",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "tournament not found",
+		},
+	}
+	ErrTitleTooLong = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x1D,
+			UserMsg:    "title too long",
+			Type:       "title-too-long",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "title too long",
+		},
+	}
+	ErrDescriptionTooLong = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x1E,
+			UserMsg:    "description too long",
+			Type:       "description-too-long",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "description too long",
+		},
+	}
+	ErrCaptionTooLong = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x1F,
+			UserMsg:    "caption too long",
+			Type:       "caption-too-long",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "caption too long",
+		},
+	}
+	ErrTooManyCaptions = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x20,
+			UserMsg:    "too many captions",
+			Type:       "too-many-captions",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "too many captions",
+		},
+	}
+	ErrDeleteForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x21,
+			UserMsg:    "not allowed to delete the album",
+			Type:       "delete-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to delete the album",
+		},
+	}
+	ErrFreezeForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x22,
+			UserMsg:    "not allowed to freeze voting",
+			Type:       "freeze-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to freeze voting",
+		},
+	}
+	ErrReopenForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x23,
+			UserMsg:    "not allowed to reopen voting",
+			Type:       "reopen-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to reopen voting",
+		},
+	}
+	ErrExtendForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x24,
+			UserMsg:    "not allowed to change the expiry",
+			Type:       "extend-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to change the expiry",
+		},
+	}
+	ErrVotingFrozen = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x25,
+			UserMsg:    "voting frozen",
+			Type:       "voting-frozen",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "voting frozen",
+		},
+	}
+	ErrAddForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x26,
+			UserMsg:    "not allowed to add images",
+			Type:       "add-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to add images",
+		},
+	}
+	ErrRemoveForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x27,
+			UserMsg:    "not allowed to remove the image",
+			Type:       "remove-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to remove the image",
+		},
+	}
+	ErrTournamentImmutable = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x28,
+			UserMsg:    "tournament cannot be changed",
+			Type:       "tournament-immutable",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "tournament cannot be changed",
+		},
+	}
+	ErrAccessInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x29,
+			UserMsg:    "access invalid",
+			Type:       "access-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "access invalid",
+		},
+	}
+	ErrCodeInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x2A,
+			UserMsg:    "code invalid",
+			Type:       "code-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "code invalid",
+		},
+	}
+	ErrForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x2B,
+			UserMsg:    "forbidden",
+			Type:       "forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "forbidden",
+		},
+	}
+	ErrResultsInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x2C,
+			UserMsg:    "results invalid",
+			Type:       "results-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "results invalid",
+		},
+	}
+	ErrResultsHidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x2D,
+			UserMsg:    "results hidden",
+			Type:       "results-hidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "results hidden",
+		},
+	}
+	ErrMessageInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x2E,
+			UserMsg:    "message invalid",
+			Type:       "message-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "message invalid",
+		},
+	}
+	ErrProblemNotFound = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusNotFound,
+			AppCode:    0x2F,
+			UserMsg:    "problem not found",
+			Type:       "problem-not-found",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "problem not found",
+		},
+	}
+	ErrLimitInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x30,
+			UserMsg:    "limit invalid",
+			Type:       "limit-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "limit invalid",
+		},
+	}
+	ErrCursorInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x31,
+			UserMsg:    "cursor invalid",
+			Type:       "cursor-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "cursor invalid",
+		},
+	}
+	ErrComparisonsInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x32,
+			UserMsg:    "min comparisons invalid",
+			Type:       "comparisons-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "min comparisons invalid",
+		},
+	}
+	ErrExportForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x33,
+			UserMsg:    "not allowed to export the album",
+			Type:       "export-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to export the album",
+		},
+	}
+	ErrFormatInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x34,
+			UserMsg:    "format invalid",
+			Type:       "format-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "format invalid",
+		},
+	}
+	ErrImportForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusForbidden,
+			AppCode:    0x35,
+			UserMsg:    "not allowed to import into the album",
+			Type:       "import-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "not allowed to import into the album",
+		},
+	}
+	ErrImportInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x36,
+			UserMsg:    "import invalid",
+			Type:       "import-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "import invalid",
+		},
+	}
+	ErrReferenceInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x37,
+			UserMsg:    "image reference invalid",
+			Type:       "reference-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "image reference invalid",
+		},
+	}
+	ErrUrlInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x38,
+			UserMsg:    "image url invalid",
+			Type:       "url-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "image url invalid",
+		},
+	}
+	ErrUrlForbidden = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x39,
+			UserMsg:    "image url not allowed",
+			Type:       "url-forbidden",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "image url resolves to a private address",
+		},
+	}
+	ErrFetchFailed = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadGateway,
+			AppCode:    0x3A,
+			UserMsg:    "image could not be downloaded",
+			Type:       "fetch-failed",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "image could not be downloaded",
+		},
+	}
+	ErrUploadNotFound = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusNotFound,
+			AppCode:    0x3B,
+			UserMsg:    "upload not found",
+			Type:       "upload-not-found",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "upload not found",
+		},
+	}
+	ErrUploadInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x3C,
+			UserMsg:    "upload headers invalid",
+			Type:       "upload-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "upload headers invalid",
+		},
+	}
+	ErrUploadOffset = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x3D,
+			UserMsg:    "upload offset mismatch",
+			Type:       "upload-offset-mismatch",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "upload offset mismatch",
+		},
+	}
+	ErrUploadIncomplete = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x3E,
+			UserMsg:    "upload incomplete",
+			Type:       "upload-incomplete",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "upload incomplete",
+		},
+	}
+	ErrUploadLocked = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusLocked,
+			AppCode:    0x3F,
+			UserMsg:    "upload in progress",
+			Type:       "upload-locked",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "upload is being appended to by another request",
+		},
+	}
+	ErrTusVersion = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusPreconditionFailed,
+			AppCode:    0x40,
+			UserMsg:    "tus version not supported",
+			Type:       "tus-version",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "tus version not supported",
+		},
+	}
+	ErrArchiveInvalid = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusBadRequest,
+			AppCode:    0x41,
+			UserMsg:    "archive invalid",
+			Type:       "archive-invalid",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "archive invalid",
+		},
+	}
+	ErrDirectNotFound = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusNotFound,
+			AppCode:    0x42,
+			UserMsg:    "direct upload not found",
+			Type:       "direct-upload-not-found",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "direct upload not found",
+		},
+	}
+	ErrTournamentConflict = &domainError{
+		outerError: outerError{
+			StatusCode: http.StatusConflict,
+			AppCode:    0x43,
+			UserMsg:    "tournament changed",
+			Type:       "tournament-changed",
+		},
+		innerError: innerError{
+			Level:  LogDebug,
+			DevMsg: "tournament changed",
+		},
+	}
 )
 
+// Errors returns every error the api can respond with, the api description
+// lists them with their app codes
+func Errors() []Error {
+	return []Error{
+		ErrTooManyRequests,
+		ErrBodyTooLarge,
+		ErrWrongContentType,
+		ErrNotEnoughImages,
+		ErrTooManyImages,
+		ErrImageTooLarge,
+		ErrNotImage,
+		ErrDurationNotSet,
+		ErrDurationInvalid,
+		ErrInvalidId,
+		ErrAlbumNotFound,
+		ErrPairNotFound,
+		ErrTokenNotFound,
+		ErrImageNotFound,
+		ErrAlbumAlreadyExists,
+		ErrTokenAlreadyExists,
+		ErrUnsupportedMediaType,
+		ErrThirdPartyUnavailable,
+		ErrBadHealthCompressor,
+		ErrBadHealthStorage,
+		ErrBadHealthDatabase,
+		ErrBadHealthCache,
+		ErrUnknown,
+		ErrRankingInvalid,
+		ErrOutcomeInvalid,
+		ErrModeInvalid,
+		ErrTournamentFinished,
+		ErrTournamentNotFound,
+		ErrTitleTooLong,
+		ErrDescriptionTooLong,
+		ErrCaptionTooLong,
+		ErrTooManyCaptions,
+		ErrDeleteForbidden,
+		ErrFreezeForbidden,
+		ErrReopenForbidden,
+		ErrExtendForbidden,
+		ErrVotingFrozen,
+		ErrAddForbidden,
+		ErrRemoveForbidden,
+		ErrTournamentImmutable,
+		ErrAccessInvalid,
+		ErrCodeInvalid,
+		ErrForbidden,
+		ErrResultsInvalid,
+		ErrResultsHidden,
+		ErrMessageInvalid,
+		ErrProblemNotFound,
+		ErrLimitInvalid,
+		ErrCursorInvalid,
+		ErrComparisonsInvalid,
+		ErrExportForbidden,
+		ErrFormatInvalid,
+		ErrImportForbidden,
+		ErrImportInvalid,
+		ErrReferenceInvalid,
+		ErrUrlInvalid,
+		ErrUrlForbidden,
+		ErrFetchFailed,
+		ErrUploadNotFound,
+		ErrUploadInvalid,
+		ErrUploadOffset,
+		ErrUploadIncomplete,
+		ErrUploadLocked,
+		ErrTusVersion,
+		ErrArchiveInvalid,
+		ErrDirectNotFound,
+		ErrTournamentConflict,
+	}
+}
+
 type Error interface {
 	error
 	Outer
@@ -292,6 +917,7 @@ type outerError struct {
 	StatusCode int
 	AppCode    int
 	UserMsg    string
+	Type       string
 }
 
 type innerError struct {
@@ -2,18 +2,32 @@ package domain
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/zitryss/aye-and-nay/domain/model"
 )
 
 type Servicer interface {
-	Album(ctx context.Context, ff []model.File, dur time.Duration) (uint64, error)
-	Pair(ctx context.Context, album uint64) (model.Image, model.Image, error)
-	Image(ctx context.Context, token uint64) (model.File, error)
-	Vote(ctx context.Context, album uint64, tokenFrom uint64, tokenTo uint64) error
-	Top(ctx context.Context, album uint64) ([]model.Image, error)
+	Album(ctx context.Context, ff []model.File, opts model.AlbumOptions) (uint64, string, error)
+	Access(ctx context.Context, album uint64, code string, cred model.Credentials) (uint64, error)
+	Pair(ctx context.Context, album uint64, voter uint64, cred model.Credentials) (model.Image, model.Image, error)
+	Image(ctx context.Context, token uint64, cred model.Credentials) (model.File, error)
+	Vote(ctx context.Context, album uint64, voter uint64, tokenFrom uint64, tokenTo uint64, outcome string, cred model.Credentials) error
+	Top(ctx context.Context, album uint64, cred model.Credentials, page model.Page) ([]model.Image, uint64, error)
+	Bracket(ctx context.Context, album uint64, cred model.Credentials) (model.Tournament, []model.Image, error)
 	Progress(ctx context.Context, album uint64) (float64, error)
+	Metadata(ctx context.Context, album uint64) (model.Metadata, error)
+	Results(ctx context.Context, album uint64, owner string) (model.Results, error)
+	Events(ctx context.Context, album uint64, cred model.Credentials) (<-chan model.Event, error)
+	Delete(ctx context.Context, album uint64, owner string) error
+	Freeze(ctx context.Context, album uint64, owner string) error
+	Reopen(ctx context.Context, album uint64, owner string) error
+	Extend(ctx context.Context, album uint64, owner string, dur time.Duration) error
+	AddImages(ctx context.Context, album uint64, owner string, ff []model.File, captions []string) ([]uint64, error)
+	RemoveImage(ctx context.Context, album uint64, owner string, image uint64) error
+	Export(ctx context.Context, album uint64, owner string) (model.Export, error)
+	Import(ctx context.Context, album uint64, owner string, edgs []model.Edge) (int, error)
 	Checker
 }
 
@@ -22,10 +36,26 @@ type Compresser interface {
 	Checker
 }
 
+type Stager interface {
+	Create(ctx context.Context, size int64, name string) (model.Upload, error)
+	Append(ctx context.Context, upload uint64, offset int64, r io.Reader) (int64, error)
+	Upload(ctx context.Context, upload uint64) (model.Upload, error)
+	Open(ctx context.Context, upload uint64) (model.File, error)
+	Remove(ctx context.Context, upload uint64) error
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (model.File, error)
+}
+
 type Storager interface {
 	Put(ctx context.Context, album uint64, image uint64, f model.File) (string, error)
 	Get(ctx context.Context, album uint64, image uint64) (model.File, error)
 	Remove(ctx context.Context, album uint64, image uint64) error
+	PresignPost(ctx context.Context, direct uint64, maxSize int64) (string, map[string]string, time.Time, error)
+	StatDirect(ctx context.Context, direct uint64) (int64, []byte, error)
+	CopyDirect(ctx context.Context, direct uint64, album uint64, image uint64) (string, error)
+	RemoveDirect(ctx context.Context, direct uint64) error
 	Checker
 }
 
@@ -35,27 +65,63 @@ type Databaser interface {
 	CountImagesCompressed(ctx context.Context, album uint64) (int, error)
 	UpdateCompressionStatus(ctx context.Context, album uint64, image uint64) error
 	GetImageSrc(ctx context.Context, album uint64, image uint64) (string, error)
+	GetImageCaption(ctx context.Context, album uint64, image uint64) (string, error)
 	GetImagesIds(ctx context.Context, album uint64) ([]uint64, error)
-	SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64) error
-	GetEdges(ctx context.Context, album uint64) (map[uint64]map[uint64]int, error)
+	GetImagesIdsUncompressed(ctx context.Context, album uint64) ([]uint64, error)
+	SaveImages(ctx context.Context, album uint64, imgs []model.Image) error
+	DeleteImage(ctx context.Context, album uint64, image uint64) error
+	GetRanking(ctx context.Context, album uint64) (string, error)
+	GetMetadata(ctx context.Context, album uint64) (model.Metadata, error)
+	GetOwner(ctx context.Context, album uint64) ([]byte, error)
+	GetAccess(ctx context.Context, album uint64) (string, []byte, error)
+	GetResults(ctx context.Context, album uint64) (string, error)
+	GetFrozen(ctx context.Context, album uint64) (bool, error)
+	SaveFrozen(ctx context.Context, album uint64, frozen bool) error
+	GetExpires(ctx context.Context, album uint64) (time.Time, error)
+	SaveExpires(ctx context.Context, album uint64, expires time.Time) error
+	GetTournament(ctx context.Context, album uint64) (model.Tournament, error)
+	// SaveTournament saves the tournament only if it has not been saved
+	// since it was read, ErrTournamentConflict otherwise
+	SaveTournament(ctx context.Context, album uint64, tour model.Tournament) error
+	SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, tie bool) error
+	SaveVotes(ctx context.Context, album uint64, votes []model.Vote) error
+	SaveSkip(ctx context.Context, album uint64, image1 uint64, image2 uint64) error
+	GetEdges(ctx context.Context, album uint64) (map[uint64]map[uint64]float64, error)
 	UpdateRatings(ctx context.Context, album uint64, vector map[uint64]float64) error
-	GetImagesOrdered(ctx context.Context, album uint64) ([]model.Image, error)
+	UpdateConfidence(ctx context.Context, album uint64, vectorLow map[uint64]float64, vectorHigh map[uint64]float64, comparisons map[uint64]int) error
+	GetImagesOrdered(ctx context.Context, album uint64, page model.Page) ([]model.Image, error)
+	GetVersion(ctx context.Context, album uint64) (uint64, error)
 	DeleteAlbum(ctx context.Context, album uint64) error
 	AlbumsToBeDeleted(ctx context.Context) ([]model.Album, error)
 	Checker
 }
 
+type Ranker interface {
+	Rank(ctx context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, error)
+}
+
+type Selector interface {
+	Select(ctx context.Context, album uint64) ([][2]uint64, error)
+}
+
 type Cacher interface {
 	Limiter
 	Queuer
 	PQueuer
 	Stacker
 	Tokener
+	Historian
+	Passer
+	Broker
+	Tallier
 	Checker
 }
 
 type Limiter interface {
 	Allow(ctx context.Context, ip uint64) (bool, error)
+	AllowCode(ctx context.Context, album uint64) (bool, error)
+	FailCode(ctx context.Context, album uint64) error
+	AllowCreate(ctx context.Context, ip uint64) (bool, error)
 }
 
 type Queuer interface {
@@ -73,12 +139,37 @@ type PQueuer interface {
 type Stacker interface {
 	Push(ctx context.Context, album uint64, pairs [][2]uint64) error
 	Pop(ctx context.Context, album uint64) (uint64, uint64, error)
+	Drop(ctx context.Context, album uint64, image uint64) error
 }
 
 type Tokener interface {
 	Set(ctx context.Context, token uint64, album uint64, image uint64) error
 	Get(ctx context.Context, token uint64) (uint64, uint64, error)
 	Del(ctx context.Context, token uint64) error
+	DelImage(ctx context.Context, album uint64, image uint64) error
+}
+
+type Historian interface {
+	AddSeen(ctx context.Context, voter uint64, album uint64, image1 uint64, image2 uint64) error
+	GetSeen(ctx context.Context, voter uint64, album uint64) ([][2]uint64, error)
+	DelSeen(ctx context.Context, voter uint64, album uint64) error
+}
+
+type Passer interface {
+	AddPass(ctx context.Context, pass uint64, album uint64) error
+	HasPass(ctx context.Context, pass uint64, album uint64) (bool, error)
+}
+
+type Broker interface {
+	Publish(ctx context.Context, album uint64, event model.Event) error
+	Subscribe(ctx context.Context, album uint64) (<-chan model.Event, error)
+}
+
+type Tallier interface {
+	AddVote(ctx context.Context, album uint64, vote model.Vote) error
+	TakeVotes(ctx context.Context, album uint64) ([]model.Vote, model.Tally, error)
+	SaveTally(ctx context.Context, album uint64, tally model.Tally) error
+	DelTally(ctx context.Context, album uint64) error
 }
 
 type Checker interface {
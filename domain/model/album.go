@@ -5,8 +5,55 @@ import (
 )
 
 type Album struct {
-	Id      uint64
-	Images  []Image
-	Edges   map[uint64]map[uint64]int
-	Expires time.Time
+	Id          uint64
+	Images      []Image
+	Edges       map[uint64]map[uint64]float64
+	Expires     time.Time
+	Ranking     string
+	Tournament  Tournament
+	Title       string
+	Description string
+	// Owner - hash of the owner token
+	Owner  []byte
+	Frozen bool
+	Access string
+	// Code - hash of the access code
+	Code    []byte
+	Results string
+	// Version - counter bumped every time the leaderboard changes
+	Version uint64
+}
+
+// AlbumOptions - everything a new album is made of besides its images
+type AlbumOptions struct {
+	// Duration - lifetime of the album, zero if it never expires
+	Duration time.Duration
+	Ranking  string
+	Mode     string
+	Metadata Metadata
+	Access   string
+	// Code - access code in the clear, it is hashed before it is saved
+	Code    string
+	Results string
+}
+
+type Metadata struct {
+	Title       string
+	Description string
+	// Captions - captions of the images in the order of the files
+	Captions []string
+}
+
+type Credentials struct {
+	Code string
+	// Pass - pass issued in exchange for the access code
+	Pass  uint64
+	Owner string
+}
+
+type Results struct {
+	Policy    string
+	Available bool
+	// AvailableAt - moment the results are shown, zero if it is unknown
+	AvailableAt time.Time
 }
@@ -0,0 +1,9 @@
+package model
+
+type Event struct {
+	Type     string
+	Progress float64
+	// Votes - number of votes cast in the album so far
+	Votes  int
+	Images []Image
+}
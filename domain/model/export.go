@@ -0,0 +1,16 @@
+package model
+
+import (
+	"context"
+)
+
+type Export struct {
+	// Images - images ordered by rating
+	Images []Image
+	// Edges - vote edges, the weight of a tie is split between both
+	// directions
+	Edges map[uint64]map[uint64]float64
+	// Open - fetches the compressed image, the images are read one by one
+	// as the export is written
+	Open func(ctx context.Context, image uint64) (File, error)
+}
@@ -5,13 +5,19 @@ import (
 )
 
 func NewFile(reader io.Reader, close func() error, size int64) File {
-	return File{reader, close, size}
+	return File{Reader: reader, close: close, Size: size}
 }
 
 type File struct {
 	io.Reader
 	close func() error
 	Size  int64
+	// Name - name of the file on the client side, empty when unknown
+	Name string
+	// Direct - id of the direct upload the file already sits in the
+	// storage as, such a file is copied within the storage and has no
+	// reader
+	Direct uint64
 }
 
 func (f File) Close() error {
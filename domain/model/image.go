@@ -1,9 +1,16 @@
 package model
 
 type Image struct {
-	Id         uint64
-	Src        string
-	Token      uint64
-	Rating     float64
-	Compressed bool
+	Id      uint64
+	Src     string
+	Caption string
+	// Filename - name of the uploaded file, empty when unknown
+	Filename    string
+	Token       uint64
+	Rating      float64
+	RatingLow   float64
+	RatingHigh  float64
+	Comparisons int
+	Skips       int
+	Compressed  bool
 }
@@ -0,0 +1,9 @@
+package model
+
+// Edge - votes cast for one image over another, the images are referenced
+// by caption or by the name of the uploaded file
+type Edge struct {
+	Winner string
+	Loser  string
+	Count  int
+}
@@ -0,0 +1,17 @@
+package model
+
+type Page struct {
+	// Limit - maximum number of images, zero for all of them
+	Limit int
+	// After - position the page starts after, nil for the first page
+	After *Cursor
+	// MinComparisons - images compared fewer times are left out
+	MinComparisons int
+}
+
+// Cursor - position in the leaderboard, the images are ordered by rating
+// descending and by id ascending
+type Cursor struct {
+	Rating float64
+	Id     uint64
+}
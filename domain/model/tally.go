@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// Vote - a vote the ratings have not been updated with yet, a tie is
+// counted as two votes of half the weight
+type Vote struct {
+	From   uint64
+	To     uint64
+	Weight float64
+}
+
+type Tally struct {
+	// Since - number of votes applied incrementally since the last full
+	// recomputation
+	Since int
+	// Full - time of the last full recomputation, zero if there has been
+	// none yet
+	Full time.Time
+	// Bootstrap - time the confidence intervals were last resampled
+	Bootstrap time.Time
+}
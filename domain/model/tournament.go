@@ -0,0 +1,21 @@
+package model
+
+type Tournament struct {
+	Mode     string
+	Round    int
+	Rounds   int
+	Seeds    []uint64
+	Matches  []Match
+	Scores   map[uint64]float64
+	Champion uint64
+	// Version - bumped on every save, a stale copy is refused
+	Version uint64
+}
+
+type Match struct {
+	Round  int
+	Image1 uint64
+	Image2 uint64
+	Winner uint64
+	Done   bool
+}
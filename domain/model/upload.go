@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// Upload - file staged in chunks before an album is created from it
+type Upload struct {
+	Id uint64
+	// Size - length announced when the upload was created
+	Size int64
+	// Offset - number of bytes received so far, the upload is complete
+	// once it reaches the size
+	Offset int64
+	// Name - name of the file on the client side, empty when unknown
+	Name string
+	// Expires - the upload is discarded after this point unless it has
+	// been turned into an album
+	Expires time.Time
+}
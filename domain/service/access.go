@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	AccessPublic = "public"
+	// AccessUnlisted - behaves as public, there is no listing of the albums
+	// so far, it only marks an album that is not to be shown in one
+	AccessUnlisted = "unlisted"
+	AccessCode     = "code"
+)
+
+func (s *Service) Access(ctx context.Context, album uint64, code string, cred model.Credentials) (uint64, error) {
+	access, hash, err := s.pers.GetAccess(ctx, album)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	if access != AccessCode {
+		return 0x0, nil
+	}
+	ok, err := s.code(ctx, album, hash, code)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	if !ok {
+		return 0x0, errors.Wrap(domain.ErrForbidden)
+	}
+	// a visitor keeps one pass for all the albums it has opened
+	pass := cred.Pass
+	if pass == 0x0 {
+		pass, err = s.rand.id()
+		if err != nil {
+			return 0x0, errors.Wrap(err)
+		}
+	}
+	err = s.pass.AddPass(ctx, pass, album)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	return pass, nil
+}
+
+func (s *Service) authorize(ctx context.Context, album uint64, cred model.Credentials) error {
+	access, hash, err := s.pers.GetAccess(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if access != AccessCode {
+		return nil
+	}
+	if cred.Pass != 0x0 {
+		ok, err := s.pass.HasPass(ctx, cred.Pass, album)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if ok {
+			return nil
+		}
+	}
+	ok, err := s.code(ctx, album, hash, cred.Code)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrForbidden)
+	}
+	return nil
+}
+
+// code checks a code against the album, only a wrong one is counted and
+// it is counted against the album whoever sent it, so a guesser gets no
+// more attempts by changing the address
+func (s *Service) code(ctx context.Context, album uint64, hash []byte, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+	allowed, err := s.lim.AllowCode(ctx, album)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	if !allowed {
+		return false, errors.Wrap(domain.ErrTooManyRequests)
+	}
+	sum := sha256.Sum256([]byte(code))
+	if subtle.ConstantTimeCompare(sum[:], hash) == 1 {
+		return true, nil
+	}
+	err = s.lim.FailCode(ctx, album)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return false, nil
+}
@@ -1,10 +1,24 @@
 package service
 
+import (
+	"time"
+)
+
 type ServiceConfig struct {
-	TempLinks           bool    `mapstructure:"SERVICE_TEMP_LINKS"`
-	NumberOfWorkersCalc int     `mapstructure:"SERVICE_NUMBER_OF_WORKERS_CALC" validate:"required"`
-	NumberOfWorkersComp int     `mapstructure:"SERVICE_NUMBER_OF_WORKERS_COMP" validate:"required"`
-	Accuracy            float64 `mapstructure:"SERVICE_ACCURACY"               validate:"required"`
+	TempLinks           bool          `mapstructure:"SERVICE_TEMP_LINKS"`
+	NumberOfWorkersCalc int           `mapstructure:"SERVICE_NUMBER_OF_WORKERS_CALC" validate:"required"`
+	NumberOfWorkersComp int           `mapstructure:"SERVICE_NUMBER_OF_WORKERS_COMP" validate:"required"`
+	Tolerance           float64       `mapstructure:"SERVICE_TOLERANCE"              validate:"required"`
+	Ranking             string        `mapstructure:"SERVICE_RANKING"                validate:"required,oneof=pagerank elo glicko2 bradleyterry"`
+	EloK                float64       `mapstructure:"SERVICE_ELO_K"                  validate:"required,gt=0"`
+	Glicko2Tau          float64       `mapstructure:"SERVICE_GLICKO2_TAU"            validate:"required,gt=0"`
+	BootstrapSamples    int           `mapstructure:"SERVICE_BOOTSTRAP_SAMPLES"`
+	BootstrapInterval   time.Duration `mapstructure:"SERVICE_BOOTSTRAP_INTERVAL"`
+	Incremental         bool          `mapstructure:"SERVICE_INCREMENTAL"`
+	RecomputeVotes      int           `mapstructure:"SERVICE_RECOMPUTE_VOTES"`
+	RecomputeInterval   time.Duration `mapstructure:"SERVICE_RECOMPUTE_INTERVAL"`
+	PairSelection       string        `mapstructure:"SERVICE_PAIR_SELECTION"         validate:"required,oneof=random informative"`
+	ResultsGrace        time.Duration `mapstructure:"SERVICE_RESULTS_GRACE"`
 }
 
 var (
@@ -12,6 +26,16 @@ var (
 		TempLinks:           true,
 		NumberOfWorkersCalc: 2,
 		NumberOfWorkersComp: 2,
-		Accuracy:            0.625,
+		Tolerance:           0.000001,
+		Ranking:             RankingPageRank,
+		EloK:                32,
+		Glicko2Tau:          0.5,
+		BootstrapSamples:    20,
+		BootstrapInterval:   0,
+		Incremental:         false,
+		RecomputeVotes:      100,
+		RecomputeInterval:   1 * time.Minute,
+		PairSelection:       SelectionRandom,
+		ResultsGrace:        24 * time.Hour,
 	}
 )
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	EventProgress = "progress"
+	EventRatings  = "ratings"
+)
+
+func (s *Service) Events(ctx context.Context, album uint64, cred model.Credentials) (<-chan model.Event, error) {
+	err := s.authorize(ctx, album, cred)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	sub, err := s.pubsub.Subscribe(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	ch := make(chan model.Event)
+	go func() {
+		defer close(ch)
+		for event := range sub {
+			if event.Type == EventRatings {
+				// the policy is checked on every update as the album may
+				// have been closed in the meantime
+				res, err := s.Results(ctx, album, cred.Owner)
+				if err != nil || !res.Available {
+					event.Images = nil
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- event:
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (s *Service) publishProgress(ctx context.Context, album uint64) error {
+	p, err := s.Progress(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.pubsub.Publish(ctx, album, model.Event{Type: EventProgress, Progress: p})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) publishRatings(ctx context.Context, album uint64) error {
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// every vote is counted by both images it compares
+	comps := 0
+	for _, img := range imgs {
+		comps += img.Comparisons
+	}
+	err = s.pubsub.Publish(ctx, album, model.Event{Type: EventRatings, Votes: comps / 2, Images: imgs})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
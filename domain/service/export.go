@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+// Export hands the results of an album over to its owner, the images
+// themselves are left in the storage until they are opened
+func (s *Service) Export(ctx context.Context, album uint64, owner string) (model.Export, error) {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return model.Export{}, errors.Wrap(err)
+	}
+	if !ok {
+		return model.Export{}, errors.Wrap(domain.ErrExportForbidden)
+	}
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return model.Export{}, errors.Wrap(err)
+	}
+	edgs, err := s.pers.GetEdges(ctx, album)
+	if err != nil {
+		return model.Export{}, errors.Wrap(err)
+	}
+	open := func(ctx context.Context, image uint64) (model.File, error) {
+		f, err := s.stor.Get(ctx, album, image)
+		if err != nil {
+			return model.File{}, errors.Wrap(err)
+		}
+		return f, nil
+	}
+	exp := model.Export{Images: imgs, Edges: edgs, Open: open}
+	return exp, nil
+}
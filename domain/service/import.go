@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+// Import merges the votes collected elsewhere into an album, every edge is
+// resolved before the first vote is saved and the ratings are computed
+// once afterwards
+func (s *Service) Import(ctx context.Context, album uint64, owner string, edgs []model.Edge) (int, error) {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	if !ok {
+		return 0, errors.Wrap(domain.ErrImportForbidden)
+	}
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	if tournament(tour.Mode) {
+		return 0, errors.Wrap(domain.ErrTournamentImmutable)
+	}
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	refs := references(imgs)
+	votes := make([]model.Vote, 0, len(edgs))
+	n := 0
+	for _, e := range edgs {
+		if e.Count < 1 {
+			return 0, errors.Wrap(domain.ErrImportInvalid)
+		}
+		winner, ok := refs[e.Winner]
+		if !ok {
+			return 0, errors.Wrapf(domain.ErrReferenceInvalid, "%q", e.Winner)
+		}
+		loser, ok := refs[e.Loser]
+		if !ok {
+			return 0, errors.Wrapf(domain.ErrReferenceInvalid, "%q", e.Loser)
+		}
+		if winner == loser {
+			return 0, errors.Wrap(domain.ErrImportInvalid)
+		}
+		votes = append(votes, model.Vote{From: loser, To: winner, Weight: float64(e.Count)})
+		n += e.Count
+	}
+	// an edge is one write however many votes it stands for, and all of
+	// them are saved at once
+	err = s.pers.SaveVotes(ctx, album, votes)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	err = s.tally.DelTally(ctx, album)
+	if err != nil {
+		return n, errors.Wrap(err)
+	}
+	err = s.queue.calc.add(ctx, album)
+	if err != nil {
+		return n, errors.Wrap(err)
+	}
+	return n, nil
+}
+
+// references maps the captions and the filenames to the images, a caption
+// takes precedence over a filename and a reference shared by several
+// images is left out
+func references(imgs []model.Image) map[string]uint64 {
+	index := func(ref func(img model.Image) string) map[string]uint64 {
+		refs := map[string]uint64{}
+		shared := map[string]bool{}
+		for _, img := range imgs {
+			r := ref(img)
+			if r == "" || shared[r] {
+				continue
+			}
+			if _, ok := refs[r]; ok {
+				delete(refs, r)
+				shared[r] = true
+				continue
+			}
+			refs[r] = img.Id
+		}
+		return refs
+	}
+	refs := index(func(img model.Image) string { return img.Caption })
+	for r, image := range index(func(img model.Image) string { return img.Filename }) {
+		if _, ok := refs[r]; !ok {
+			refs[r] = image
+		}
+	}
+	return refs
+}
@@ -24,11 +24,11 @@ type Mock struct {
 	err error
 }
 
-func (m *Mock) Album(_ context.Context, _ []model.File, _ time.Duration) (uint64, error) {
+func (m *Mock) Album(_ context.Context, _ []model.File, _ model.AlbumOptions) (uint64, string, error) {
 	if m.err != nil {
-		return 0x0, m.err
+		return 0x0, "", m.err
 	}
-	return 0x1BAD, nil
+	return 0x1BAD, "9Wp1cF4sGdQx2LrTzXvB8nYkHmJ3aE6uO0iSlKqCbRw", nil
 }
 
 func (m *Mock) Progress(_ context.Context, _ uint64) (float64, error) {
@@ -38,16 +38,110 @@ func (m *Mock) Progress(_ context.Context, _ uint64) (float64, error) {
 	return 1, nil
 }
 
-func (m *Mock) Pair(_ context.Context, _ uint64) (model.Image, model.Image, error) {
+func (m *Mock) Metadata(_ context.Context, _ uint64) (model.Metadata, error) {
+	if m.err != nil {
+		return model.Metadata{}, m.err
+	}
+	return model.Metadata{Title: "Cats", Description: "Which one is the cutest?"}, nil
+}
+
+func (m *Mock) Results(_ context.Context, _ uint64, _ string) (model.Results, error) {
+	if m.err != nil {
+		return model.Results{}, m.err
+	}
+	return model.Results{Policy: "always", Available: true}, nil
+}
+
+func (m *Mock) Delete(_ context.Context, _ uint64, _ string) error {
+	if m.err != nil {
+		return m.err
+	}
+	return nil
+}
+
+func (m *Mock) Freeze(_ context.Context, _ uint64, _ string) error {
+	if m.err != nil {
+		return m.err
+	}
+	return nil
+}
+
+func (m *Mock) Reopen(_ context.Context, _ uint64, _ string) error {
+	if m.err != nil {
+		return m.err
+	}
+	return nil
+}
+
+func (m *Mock) Extend(_ context.Context, _ uint64, _ string, _ time.Duration) error {
+	if m.err != nil {
+		return m.err
+	}
+	return nil
+}
+
+func (m *Mock) AddImages(_ context.Context, _ uint64, _ string, _ []model.File, _ []string) ([]uint64, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []uint64{0x3A1F, 0x7B2C}, nil
+}
+
+func (m *Mock) RemoveImage(_ context.Context, _ uint64, _ string, _ uint64) error {
+	if m.err != nil {
+		return m.err
+	}
+	return nil
+}
+
+func (m *Mock) Export(_ context.Context, _ uint64, _ string) (model.Export, error) {
+	if m.err != nil {
+		return model.Export{}, m.err
+	}
+	img1 := model.Image{Id: 0x5CC8, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA", Caption: "Tom", Rating: 0.75, RatingLow: 0.5, RatingHigh: 1, Comparisons: 1}
+	img2 := model.Image{Id: 0x588D, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA", Rating: 0.25, RatingLow: 0, RatingHigh: 0.5, Comparisons: 1}
+	imgs := []model.Image{img1, img2}
+	edgs := map[uint64]map[uint64]float64{0x5CC8: {}, 0x588D: {0x5CC8: 1}}
+	open := func(_ context.Context, _ uint64) (model.File, error) {
+		f := Png()
+		buf := pool.GetBufferN(f.Size)
+		n, err := io.Copy(buf, f.Reader)
+		if err != nil {
+			return model.File{}, errors.Wrap(err)
+		}
+		return model.File{Reader: buf, Size: n}, nil
+	}
+	return model.Export{Images: imgs, Edges: edgs, Open: open}, nil
+}
+
+func (m *Mock) Import(_ context.Context, _ uint64, _ string, edgs []model.Edge) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	n := 0
+	for _, e := range edgs {
+		n += e.Count
+	}
+	return n, nil
+}
+
+func (m *Mock) Access(_ context.Context, _ uint64, _ string, _ model.Credentials) (uint64, error) {
+	if m.err != nil {
+		return 0x0, m.err
+	}
+	return 0xA55, nil
+}
+
+func (m *Mock) Pair(_ context.Context, _ uint64, _ uint64, _ model.Credentials) (model.Image, model.Image, error) {
 	if m.err != nil {
 		return model.Image{}, model.Image{}, m.err
 	}
-	img1 := model.Image{Src: "/aye-and-nay/albums/nkUAAAAAAAA/images/21EAAAAAAAA", Token: 0xC77F}
-	img2 := model.Image{Src: "/aye-and-nay/albums/nkUAAAAAAAA/images/K2IAAAAAAAA", Token: 0xA989}
+	img1 := model.Image{Src: "/aye-and-nay/albums/nkUAAAAAAAA/images/21EAAAAAAAA", Caption: "Tom", Token: 0xC77F}
+	img2 := model.Image{Src: "/aye-and-nay/albums/nkUAAAAAAAA/images/K2IAAAAAAAA", Caption: "Felix", Token: 0xA989}
 	return img1, img2, nil
 }
 
-func (m *Mock) Image(_ context.Context, _ uint64) (model.File, error) {
+func (m *Mock) Image(_ context.Context, _ uint64, _ model.Credentials) (model.File, error) {
 	if m.err != nil {
 		return model.File{}, m.err
 	}
@@ -60,21 +154,49 @@ func (m *Mock) Image(_ context.Context, _ uint64) (model.File, error) {
 	return model.File{Reader: buf, Size: n}, nil
 }
 
-func (m *Mock) Vote(_ context.Context, _ uint64, _ uint64, _ uint64) error {
+func (m *Mock) Vote(_ context.Context, _ uint64, _ uint64, _ uint64, _ uint64, _ string, _ model.Credentials) error {
 	if m.err != nil {
 		return m.err
 	}
 	return nil
 }
 
-func (m *Mock) Top(_ context.Context, _ uint64) ([]model.Image, error) {
+func (m *Mock) Top(_ context.Context, _ uint64, _ model.Credentials, page model.Page) ([]model.Image, uint64, error) {
 	if m.err != nil {
-		return nil, m.err
+		return nil, 0x0, m.err
+	}
+	img1 := model.Image{Id: 0x5CC8, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA", Rating: 0.5, RatingLow: 0.25, RatingHigh: 0.75, Comparisons: 1}
+	img2 := model.Image{Id: 0x588D, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA", Rating: 0.5, RatingLow: 0.25, RatingHigh: 0.75, Comparisons: 1}
+	imgs := []model.Image{img1, img2}
+	if page.Limit > 0 && page.Limit < len(imgs) {
+		imgs = imgs[:page.Limit]
 	}
-	img1 := model.Image{Src: "/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA", Rating: 0.5}
-	img2 := model.Image{Src: "/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA", Rating: 0.5}
+	return imgs, 0x7, nil
+}
+
+func (m *Mock) Bracket(_ context.Context, _ uint64, _ model.Credentials) (model.Tournament, []model.Image, error) {
+	if m.err != nil {
+		return model.Tournament{}, nil, m.err
+	}
+	img1 := model.Image{Id: 0x5C70, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/cFwAAAAAAAA"}
+	img2 := model.Image{Id: 0x1E3A, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/Oh4AAAAAAAA"}
 	imgs := []model.Image{img1, img2}
-	return imgs, nil
+	match := model.Match{Round: 1, Image1: 0x5C70, Image2: 0x1E3A, Winner: 0x1E3A, Done: true}
+	tour := model.Tournament{Mode: ModeSingleElimination, Round: 1, Rounds: 1, Seeds: []uint64{0x5C70, 0x1E3A}, Matches: []model.Match{match}, Champion: 0x1E3A}
+	return tour, imgs, nil
+}
+
+func (m *Mock) Events(_ context.Context, _ uint64, _ model.Credentials) (<-chan model.Event, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	img1 := model.Image{Id: 0x5CC8, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/yFwAAAAAAAA", Rating: 0.5, RatingLow: 0.25, RatingHigh: 0.75, Comparisons: 1}
+	img2 := model.Image{Id: 0x588D, Src: "/aye-and-nay/albums/byYAAAAAAAA/images/jVgAAAAAAAA", Rating: 0.5, RatingLow: 0.25, RatingHigh: 0.75, Comparisons: 1}
+	ch := make(chan model.Event, 2)
+	ch <- model.Event{Type: EventProgress, Progress: 1}
+	ch <- model.Event{Type: EventRatings, Votes: 1, Images: []model.Image{img1, img2}}
+	close(ch)
+	return ch, nil
 }
 
 func (m *Mock) Health(_ context.Context) (bool, error) {
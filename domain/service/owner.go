@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+func (s *Service) Delete(ctx context.Context, album uint64, owner string) error {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrDeleteForbidden)
+	}
+	// an album deleted by the owner is not kept for its results, so its
+	// expiry is put back past the grace period
+	expires := time.Now().Add(-s.conf.ResultsGrace)
+	err = s.pers.SaveExpires(ctx, album, expires)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.queue.del.add(ctx, album, expires)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) Freeze(ctx context.Context, album uint64, owner string) error {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrFreezeForbidden)
+	}
+	err = s.pers.SaveFrozen(ctx, album, true)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) Reopen(ctx context.Context, album uint64, owner string) error {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrReopenForbidden)
+	}
+	err = s.pers.SaveFrozen(ctx, album, false)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) Extend(ctx context.Context, album uint64, owner string, dur time.Duration) error {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrExtendForbidden)
+	}
+	expires := time.Now().Add(dur)
+	if dur == 0 {
+		expires = time.Time{}
+	}
+	err = s.pers.SaveExpires(ctx, album, expires)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// an album that never expires is dropped by the deletion worker once
+	// its previous entry comes up
+	if dur != 0 {
+		err = s.queue.del.add(ctx, album, expires)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) AddImages(ctx context.Context, album uint64, owner string, ff []model.File, captions []string) ([]uint64, error) {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if !ok {
+		return nil, errors.Wrap(domain.ErrAddForbidden)
+	}
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if tournament(tour.Mode) {
+		return nil, errors.Wrap(domain.ErrTournamentImmutable)
+	}
+	imgs, err := s.putImages(ctx, album, ff, captions)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = s.pers.SaveImages(ctx, album, imgs)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = s.queue.comp.add(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	images := make([]uint64, 0, len(imgs))
+	for _, img := range imgs {
+		images = append(images, img.Id)
+	}
+	return images, nil
+}
+
+func (s *Service) RemoveImage(ctx context.Context, album uint64, owner string, image uint64) error {
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !ok {
+		return errors.Wrap(domain.ErrRemoveForbidden)
+	}
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if tournament(tour.Mode) {
+		return errors.Wrap(domain.ErrTournamentImmutable)
+	}
+	n, err := s.pers.CountImages(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if n <= 2 {
+		return errors.Wrap(domain.ErrNotEnoughImages)
+	}
+	err = s.pers.DeleteImage(ctx, album, image)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.stor.Remove(ctx, album, image)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.pair.Drop(ctx, album, image)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.token.DelImage(ctx, album, image)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// the pending votes may involve the image, the ratings are computed
+	// from scratch
+	err = s.tally.DelTally(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.queue.calc.add(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// owner reports whether the token matches the one handed out on the album
+// creation, only its hash is stored
+func (s *Service) owner(ctx context.Context, album uint64, owner string) (bool, error) {
+	want, err := s.pers.GetOwner(ctx, album)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	if owner == "" || len(want) == 0 {
+		return false, nil
+	}
+	got := sha256.Sum256([]byte(owner))
+	return subtle.ConstantTimeCompare(got[:], want) == 1, nil
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/linalg"
+	"github.com/zitryss/aye-and-nay/pkg/ranking"
+)
+
+const (
+	RankingPageRank     = "pagerank"
+	RankingElo          = "elo"
+	RankingGlicko2      = "glicko2"
+	RankingBradleyTerry = "bradleyterry"
+)
+
+var (
+	_ domain.Ranker = (*pageRank)(nil)
+	_ domain.Ranker = (*elo)(nil)
+	_ domain.Ranker = (*glicko2)(nil)
+	_ domain.Ranker = (*bradleyTerry)(nil)
+	_ warmRanker    = (*pageRank)(nil)
+	_ warmRanker    = (*bradleyTerry)(nil)
+	_ onlineRanker  = (*elo)(nil)
+	_ spreadRanker  = (*glicko2)(nil)
+)
+
+// warmRanker starts the recomputation from the previous ratings
+type warmRanker interface {
+	RankFrom(ctx context.Context, edgs map[uint64]map[uint64]float64, prev map[uint64]float64) (map[uint64]float64, error)
+}
+
+// onlineRanker applies new votes to the previous ratings without the edges
+type onlineRanker interface {
+	Update(ctx context.Context, prev map[uint64]float64, votes []ranking.Vote) (map[uint64]float64, error)
+}
+
+// spreadRanker rates every image together with the deviation of its rating,
+// the confidence intervals are taken from the deviations then
+type spreadRanker interface {
+	RankSpread(ctx context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, map[uint64]float64, error)
+}
+
+func newRankers(conf ServiceConfig) map[string]domain.Ranker {
+	return map[string]domain.Ranker{
+		RankingPageRank:     &pageRank{conf.Tolerance},
+		RankingElo:          &elo{conf.EloK},
+		RankingGlicko2:      &glicko2{conf.Glicko2Tau},
+		RankingBradleyTerry: &bradleyTerry{conf.Tolerance},
+	}
+}
+
+type pageRank struct {
+	tolerance float64
+}
+
+func (pr *pageRank) Rank(_ context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+	return linalg.PageRankSparse(edgs, pr.tolerance), nil
+}
+
+func (pr *pageRank) RankFrom(_ context.Context, edgs map[uint64]map[uint64]float64, prev map[uint64]float64) (map[uint64]float64, error) {
+	return linalg.PageRankSparseFrom(edgs, pr.tolerance, prev), nil
+}
+
+type elo struct {
+	k float64
+}
+
+func (e *elo) Rank(_ context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+	return ranking.Elo(edgs, e.k), nil
+}
+
+func (e *elo) Update(_ context.Context, prev map[uint64]float64, votes []ranking.Vote) (map[uint64]float64, error) {
+	return ranking.EloUpdate(prev, votes, e.k), nil
+}
+
+type glicko2 struct {
+	tau float64
+}
+
+func (g *glicko2) Rank(ctx context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+	ratings, _, err := g.RankSpread(ctx, edgs)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return ratings, nil
+}
+
+func (g *glicko2) RankSpread(_ context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, map[uint64]float64, error) {
+	ratings, deviations := ranking.Glicko2(edgs, g.tau)
+	return ratings, deviations, nil
+}
+
+type bradleyTerry struct {
+	tolerance float64
+}
+
+func (bt *bradleyTerry) Rank(_ context.Context, edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+	return ranking.BradleyTerry(edgs, bt.tolerance), nil
+}
+
+func (bt *bradleyTerry) RankFrom(_ context.Context, edgs map[uint64]map[uint64]float64, prev map[uint64]float64) (map[uint64]float64, error) {
+	return ranking.BradleyTerryFrom(edgs, bt.tolerance, prev), nil
+}
+
+func (s *Service) confidence(
+	ctx context.Context,
+	r domain.Ranker,
+	edgs map[uint64]map[uint64]float64,
+	vect map[uint64]float64,
+) (map[uint64]float64, map[uint64]float64, error) {
+	rank := func(graph map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+		return r.Rank(ctx, graph)
+	}
+	vectLow, vectHigh, err := ranking.Bootstrap(edgs, s.conf.BootstrapSamples, 0.05, s.rand.intn, rank)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	// the interval must always contain the rating itself
+	for id, rating := range vect {
+		low, ok := vectLow[id]
+		if !ok || low > rating {
+			vectLow[id] = rating
+		}
+		high, ok := vectHigh[id]
+		if !ok || high < rating {
+			vectHigh[id] = rating
+		}
+	}
+	return vectLow, vectHigh, nil
+}
+
+// calc recomputes the ratings of an album from scratch, or in the
+// incremental mode applies the pending votes until enough of them pile up,
+// the confidence intervals are resampled at most once per bootstrap
+// interval, every resample costs as much as a recomputation
+func (s *Service) calc(ctx context.Context, album uint64, r domain.Ranker) error {
+	votes, tally, err := s.tally.TakeVotes(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	now := time.Now()
+	_, online := r.(onlineRanker)
+	_, warm := r.(warmRanker)
+	full := true
+	if s.conf.Incremental && (online || warm) {
+		tally.Since += len(votes)
+		full = tally.Full.IsZero() || tally.Since >= s.conf.RecomputeVotes || now.Sub(tally.Full) >= s.conf.RecomputeInterval
+	}
+	if full {
+		resample := now.Sub(tally.Bootstrap) >= s.conf.BootstrapInterval
+		err = s.calcFull(ctx, album, r, resample)
+		tally.Since = 0
+		tally.Full = now
+		if resample {
+			tally.Bootstrap = now
+		}
+	} else {
+		err = s.calcIncremental(ctx, album, r, votes)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.tally.SaveTally(ctx, album, tally)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) calcFull(ctx context.Context, album uint64, r domain.Ranker, resample bool) error {
+	edgs, err := s.pers.GetEdges(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	vect, vectLow, vectHigh := map[uint64]float64(nil), map[uint64]float64(nil), map[uint64]float64(nil)
+	if sr, ok := r.(spreadRanker); ok {
+		vect, vectLow, vectHigh, err = spread(ctx, sr, edgs)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	} else {
+		vect, err = r.Rank(ctx, edgs)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if resample {
+			vectLow, vectHigh, err = s.confidence(ctx, r, edgs, vect)
+		} else {
+			vectLow, vectHigh, err = s.stretch(ctx, album, vect)
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	comps := ranking.Comparisons(edgs)
+	err = s.pers.UpdateRatings(ctx, album, vect)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.pers.UpdateConfidence(ctx, album, vectLow, vectHigh, comps)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// spread turns the deviations into 95% intervals around the ratings, there
+// is no need to resample the votes
+func spread(ctx context.Context, sr spreadRanker, edgs map[uint64]map[uint64]float64) (map[uint64]float64, map[uint64]float64, map[uint64]float64, error) {
+	vect, devs, err := sr.RankSpread(ctx, edgs)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err)
+	}
+	vectLow := make(map[uint64]float64, len(vect))
+	vectHigh := make(map[uint64]float64, len(vect))
+	for id, rating := range vect {
+		vectLow[id] = rating - 1.96*devs[id]
+		vectHigh[id] = rating + 1.96*devs[id]
+	}
+	return vect, vectLow, vectHigh, nil
+}
+
+// stretch keeps the previous intervals until the next resample, they are
+// only widened to contain the new ratings
+func (s *Service) stretch(ctx context.Context, album uint64, vect map[uint64]float64) (map[uint64]float64, map[uint64]float64, error) {
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	vectLow := make(map[uint64]float64, len(imgs))
+	vectHigh := make(map[uint64]float64, len(imgs))
+	for _, img := range imgs {
+		vectLow[img.Id] = img.RatingLow
+		vectHigh[img.Id] = img.RatingHigh
+	}
+	for id, rating := range vect {
+		low, ok := vectLow[id]
+		if !ok || low > rating {
+			vectLow[id] = rating
+		}
+		high, ok := vectHigh[id]
+		if !ok || high < rating {
+			vectHigh[id] = rating
+		}
+	}
+	return vectLow, vectHigh, nil
+}
+
+func (s *Service) calcIncremental(ctx context.Context, album uint64, r domain.Ranker, pending []model.Vote) error {
+	votes := make([]ranking.Vote, 0, len(pending))
+	for _, vote := range pending {
+		votes = append(votes, ranking.Vote{Loser: vote.From, Winner: vote.To, Weight: vote.Weight})
+	}
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	prev := make(map[uint64]float64, len(imgs))
+	vectLow := make(map[uint64]float64, len(imgs))
+	vectHigh := make(map[uint64]float64, len(imgs))
+	comps := make(map[uint64]int, len(imgs))
+	for _, img := range imgs {
+		prev[img.Id] = img.Rating
+		vectLow[img.Id] = img.RatingLow
+		vectHigh[img.Id] = img.RatingHigh
+		comps[img.Id] = img.Comparisons
+	}
+	vect := map[uint64]float64(nil)
+	switch rr := r.(type) {
+	case onlineRanker:
+		vect, err = rr.Update(ctx, prev, votes)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	case warmRanker:
+		edgs, err := s.pers.GetEdges(ctx, album)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		vect, err = rr.RankFrom(ctx, edgs, prev)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	default:
+		return s.calcFull(ctx, album, r, true)
+	}
+	// a tie is split into two halves, so the weights are summed up first
+	weights := map[uint64]float64{}
+	for _, vote := range votes {
+		if vote.Loser == vote.Winner {
+			continue
+		}
+		weights[vote.Loser] += vote.Weight
+		weights[vote.Winner] += vote.Weight
+	}
+	for id, w := range weights {
+		comps[id] += int(math.Round(w))
+	}
+	// the interval is only stretched until the next full recomputation
+	for id, rating := range vect {
+		vectLow[id] = math.Min(vectLow[id], rating)
+		vectHigh[id] = math.Max(vectHigh[id], rating)
+	}
+	err = s.pers.UpdateRatings(ctx, album, vect)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = s.pers.UpdateConfidence(ctx, album, vectLow, vectHigh, comps)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
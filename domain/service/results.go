@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	ResultsAlways = "always"
+	ResultsOwner  = "owner"
+	ResultsClosed = "closed"
+)
+
+func resultsPolicy(policy string) bool {
+	return policy == ResultsAlways || policy == ResultsOwner || policy == ResultsClosed
+}
+
+// expired tells whether an album with the expiry is past it, an album
+// that never expires never is
+func expired(expires time.Time) bool {
+	return !expires.IsZero() && !time.Now().Before(expires)
+}
+
+func (s *Service) Results(ctx context.Context, album uint64, owner string) (model.Results, error) {
+	policy, err := s.pers.GetResults(ctx, album)
+	if err != nil {
+		return model.Results{}, errors.Wrap(err)
+	}
+	if policy == "" {
+		policy = ResultsAlways
+	}
+	res := model.Results{Policy: policy, Available: true}
+	if policy == ResultsAlways {
+		return res, nil
+	}
+	// the owner sees the results whatever the policy is
+	ok, err := s.owner(ctx, album, owner)
+	if err != nil {
+		return model.Results{}, errors.Wrap(err)
+	}
+	if ok || policy == ResultsOwner {
+		res.Available = ok
+		return res, nil
+	}
+	// an album is closed once the owner freezes it or it expires, an
+	// expired album is kept for the grace period to show the results
+	frozen, err := s.pers.GetFrozen(ctx, album)
+	if err != nil {
+		return model.Results{}, errors.Wrap(err)
+	}
+	expires, err := s.pers.GetExpires(ctx, album)
+	if err != nil {
+		return model.Results{}, errors.Wrap(err)
+	}
+	res.Available = frozen || expired(expires)
+	if !res.Available {
+		res.AvailableAt = expires
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	SelectionRandom      = "random"
+	SelectionInformative = "informative"
+)
+
+var (
+	_ domain.Selector = (*randomCycle)(nil)
+	_ domain.Selector = (*informative)(nil)
+)
+
+func newSelectors(pers domain.Databaser, shuffle func(n int, swap func(i int, j int))) map[string]domain.Selector {
+	return map[string]domain.Selector{
+		SelectionRandom:      &randomCycle{pers, shuffle},
+		SelectionInformative: &informative{pers, shuffle},
+	}
+}
+
+type randomCycle struct {
+	pers    domain.Databaser
+	shuffle func(n int, swap func(i int, j int))
+}
+
+func (rc *randomCycle) Select(ctx context.Context, album uint64) ([][2]uint64, error) {
+	images, err := rc.pers.GetImagesIds(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return cycle(images, rc.shuffle), nil
+}
+
+func cycle(images []uint64, shuffle func(n int, swap func(i int, j int))) [][2]uint64 {
+	shuffle(len(images), func(i, j int) { images[i], images[j] = images[j], images[i] })
+	images = append(images, images[0])
+	pairs := make([][2]uint64, 0, len(images)-1)
+	for i := 0; i < len(images)-1; i++ {
+		image1 := images[i]
+		image2 := images[i+1]
+		pairs = append(pairs, [2]uint64{image1, image2})
+	}
+	shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	return pairs
+}
+
+type informative struct {
+	pers    domain.Databaser
+	shuffle func(n int, swap func(i int, j int))
+}
+
+func (in *informative) Select(ctx context.Context, album uint64) ([][2]uint64, error) {
+	imgs, err := in.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if len(imgs) < 3 {
+		images := make([]uint64, 0, len(imgs))
+		for _, img := range imgs {
+			images = append(images, img.Id)
+		}
+		return cycle(images, in.shuffle), nil
+	}
+	edgs, err := in.pers.GetEdges(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	n := len(imgs)
+	// pos - position of an image in the leaderboard, ratings of different
+	// algorithms are not comparable, positions are
+	pos := make(map[uint64]int, n)
+	for i, img := range imgs {
+		pos[img.Id] = i
+	}
+	// comps - amount of comparisons per image, pairs - per pair of images
+	comps := make(map[uint64]float64, n)
+	pairs := make(map[[2]uint64]float64)
+	for from := range edgs {
+		for to, count := range edgs[from] {
+			if count <= 0 || from == to {
+				continue
+			}
+			comps[from] += count
+			comps[to] += count
+			pairs[key(from, to)] += count
+		}
+	}
+	order := make([]uint64, 0, n)
+	for _, img := range imgs {
+		order = append(order, img.Id)
+	}
+	in.shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	res := make([][2]uint64, 0, n)
+	for len(res) < n {
+		// the least compared image goes first
+		slices.SortStableFunc(order, func(a, b uint64) bool { return comps[a] < comps[b] })
+		image1 := order[0]
+		image2 := image1
+		best := math.Inf(1)
+		for _, id := range order[1:] {
+			// the closer two images are in the leaderboard and the less
+			// often they have met each other, the more a vote tells
+			dist := math.Abs(float64(pos[image1] - pos[id]))
+			cost := dist*(1+pairs[key(image1, id)]) + comps[id]/(1+comps[image1]+comps[id])
+			if cost < best {
+				best = cost
+				image2 = id
+			}
+		}
+		comps[image1]++
+		comps[image2]++
+		pairs[key(image1, image2)]++
+		pair := [2]uint64{image1, image2}
+		in.shuffle(2, func(i, j int) { pair[i], pair[j] = pair[j], pair[i] })
+		res = append(res, pair)
+	}
+	return res, nil
+}
+
+func key(image1 uint64, image2 uint64) [2]uint64 {
+	if image1 > image2 {
+		image1, image2 = image2, image1
+	}
+	return [2]uint64{image1, image2}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/infrastructure/database"
+	. "github.com/zitryss/aye-and-nay/internal/generator"
+	. "github.com/zitryss/aye-and-nay/internal/testing"
+)
+
+func TestSelectorRandom(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	ctx := context.Background()
+	id, ids := GenId()
+	mem := database.NewMem(database.DefaultMemConfig)
+	alb := AlbumFactory(id, ids)
+	err := mem.SaveAlbum(ctx, alb)
+	assert.NoError(t, err)
+	fnShuffle := func(n int, swap func(i int, j int)) {}
+	sel := newSelectors(mem, fnShuffle)[SelectionRandom]
+	pairs, err := sel.Select(ctx, ids.Uint64(0))
+	assert.NoError(t, err)
+	assert.Len(t, pairs, 5)
+	counts := map[uint64]int{}
+	for i, pair := range pairs {
+		assert.Equal(t, pairs[(i+1)%len(pairs)][0], pair[1])
+		counts[pair[0]]++
+		counts[pair[1]]++
+	}
+	for i := 1; i <= 5; i++ {
+		assert.Equal(t, 2, counts[ids.Uint64(i)])
+	}
+}
+
+func TestSelectorInformative(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		ctx := context.Background()
+		id, ids := GenId()
+		mem := database.NewMem(database.DefaultMemConfig)
+		alb := AlbumFactory(id, ids)
+		err := mem.SaveAlbum(ctx, alb)
+		assert.NoError(t, err)
+		fnShuffle := func(n int, swap func(i int, j int)) {}
+		sel := newSelectors(mem, fnShuffle)[SelectionInformative]
+		pairs, err := sel.Select(ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		want := [][2]uint64{
+			{ids.Uint64(4), ids.Uint64(1)},
+			{ids.Uint64(3), ids.Uint64(2)},
+			{ids.Uint64(5), ids.Uint64(2)},
+			{ids.Uint64(5), ids.Uint64(3)},
+			{ids.Uint64(4), ids.Uint64(1)},
+		}
+		assert.Equal(t, want, pairs)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		ctx := context.Background()
+		id, ids := GenId()
+		mem := database.NewMem(database.DefaultMemConfig)
+		alb := AlbumFactory(id, ids)
+		err := mem.SaveAlbum(ctx, alb)
+		assert.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			err = mem.SaveVote(ctx, ids.Uint64(0), ids.Uint64(4), ids.Uint64(1), false)
+			assert.NoError(t, err)
+		}
+		fnShuffle := func(n int, swap func(i int, j int)) {}
+		sel := newSelectors(mem, fnShuffle)[SelectionInformative]
+		pairs, err := sel.Select(ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Len(t, pairs, 5)
+		for _, pair := range pairs {
+			assert.NotEqual(t, pair[0], pair[1])
+			assert.NotEqual(t, key(ids.Uint64(4), ids.Uint64(1)), key(pair[0], pair[1]))
+		}
+	})
+	t.Run("Negative", func(t *testing.T) {
+		ctx := context.Background()
+		id, _ := GenId()
+		mem := database.NewMem(database.DefaultMemConfig)
+		fnShuffle := func(n int, swap func(i int, j int)) {}
+		sel := newSelectors(mem, fnShuffle)[SelectionInformative]
+		_, err := sel.Select(ctx, id())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
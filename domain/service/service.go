@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
 	"math/rand"
 	"time"
 
@@ -12,6 +13,12 @@ import (
 	myrand "github.com/zitryss/aye-and-nay/pkg/rand"
 )
 
+const (
+	OutcomeWin  = "win"
+	OutcomeTie  = "tie"
+	OutcomeSkip = "skip"
+)
+
 var (
 	_ domain.Servicer = (*Service)(nil)
 )
@@ -28,13 +35,19 @@ func New(
 	opts ...options,
 ) *Service {
 	s := &Service{
-		conf:  conf,
-		comp:  comp,
-		stor:  stor,
-		pers:  pers,
-		pair:  temp,
-		token: temp,
-		cache: temp,
+		conf:   conf,
+		comp:   comp,
+		stor:   stor,
+		pers:   pers,
+		pair:   temp,
+		token:  temp,
+		hist:   temp,
+		pass:   temp,
+		lim:    temp,
+		pubsub: temp,
+		cache:  temp,
+		rank:   newRankers(conf),
+		tally:  temp,
 		queue: struct {
 			calc *QueueCalc
 			comp *QueueComp
@@ -46,15 +59,20 @@ func New(
 		},
 		rand: struct {
 			id      func() (uint64, error)
+			token   func() (string, error)
 			shuffle func(n int, swap func(i int, j int))
+			intn    func(n int) int
 		}{
 			myrand.Id,
+			myrand.Token,
 			rand.Shuffle,
+			rand.Intn,
 		},
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	s.sel = newSelectors(s.pers, s.rand.shuffle)
 	return s
 }
 
@@ -96,6 +114,12 @@ func WithRandShuffle(fn func(int, func(int, int))) options {
 	}
 }
 
+func WithRandIntn(fn func(int) int) options {
+	return func(s *Service) {
+		s.rand.intn = fn
+	}
+}
+
 func WithHeartbeatCalc(ch chan<- any) options {
 	return func(s *Service) {
 		s.heartbeat.calc = ch
@@ -115,21 +139,30 @@ func WithHeartbeatDel(ch chan<- any) options {
 }
 
 type Service struct {
-	conf  ServiceConfig
-	comp  domain.Compresser
-	stor  domain.Storager
-	pers  domain.Databaser
-	pair  domain.Stacker
-	token domain.Tokener
-	cache domain.Checker
-	queue struct {
+	conf   ServiceConfig
+	comp   domain.Compresser
+	stor   domain.Storager
+	pers   domain.Databaser
+	pair   domain.Stacker
+	token  domain.Tokener
+	hist   domain.Historian
+	pass   domain.Passer
+	lim    domain.Limiter
+	pubsub domain.Broker
+	cache  domain.Checker
+	rank   map[string]domain.Ranker
+	tally  domain.Tallier
+	sel    map[string]domain.Selector
+	queue  struct {
 		calc *QueueCalc
 		comp *QueueComp
 		del  *QueueDel
 	}
 	rand struct {
 		id      func() (uint64, error)
+		token   func() (string, error)
 		shuffle func(n int, swap func(i, j int))
+		intn    func(n int) int
 	}
 	heartbeat struct {
 		calc chan<- any
@@ -138,47 +171,118 @@ type Service struct {
 	}
 }
 
-func (s *Service) Album(ctx context.Context, ff []model.File, dur time.Duration) (uint64, error) {
+func (s *Service) Album(ctx context.Context, ff []model.File, opts model.AlbumOptions) (uint64, string, error) {
+	if opts.Ranking == "" {
+		opts.Ranking = s.conf.Ranking
+	}
+	_, ok := s.rank[opts.Ranking]
+	if !ok {
+		return 0x0, "", errors.Wrap(domain.ErrRankingInvalid)
+	}
+	if opts.Mode != "" && opts.Mode != ModePairwise && !tournament(opts.Mode) {
+		return 0x0, "", errors.Wrap(domain.ErrModeInvalid)
+	}
+	if opts.Access == "" {
+		opts.Access = AccessPublic
+	}
+	if opts.Access != AccessPublic && opts.Access != AccessUnlisted && opts.Access != AccessCode {
+		return 0x0, "", errors.Wrap(domain.ErrAccessInvalid)
+	}
+	if opts.Access == AccessCode && opts.Code == "" {
+		return 0x0, "", errors.Wrap(domain.ErrCodeInvalid)
+	}
+	if opts.Results == "" {
+		opts.Results = ResultsAlways
+	}
+	if !resultsPolicy(opts.Results) {
+		return 0x0, "", errors.Wrap(domain.ErrResultsInvalid)
+	}
 	album, err := s.rand.id()
 	if err != nil {
-		return 0x0, errors.Wrap(err)
+		return 0x0, "", errors.Wrap(err)
 	}
-	imgs := make([]model.Image, 0, len(ff))
-	for _, f := range ff {
-		image, err := s.rand.id()
-		if err != nil {
-			return 0x0, errors.Wrap(err)
-		}
-		src, err := s.stor.Put(ctx, album, image, f)
-		if err != nil {
-			return 0x0, errors.Wrap(err)
-		}
-		img := model.Image{}
-		img.Id = image
-		img.Src = src
-		imgs = append(imgs, img)
+	imgs, err := s.putImages(ctx, album, ff, opts.Metadata.Captions)
+	if err != nil {
+		return 0x0, "", errors.Wrap(err)
 	}
-	edgs := map[uint64]map[uint64]int(nil)
-	expires := time.Now().Add(dur)
-	if dur == 0 {
+	expires := time.Now().Add(opts.Duration)
+	if opts.Duration == 0 {
 		expires = time.Time{}
 	}
-	alb := model.Album{album, imgs, edgs, expires}
+	tour := model.Tournament{}
+	if tournament(opts.Mode) {
+		images := make([]uint64, 0, len(imgs))
+		for _, img := range imgs {
+			images = append(images, img.Id)
+		}
+		tour = s.newTournament(opts.Mode, images)
+	}
+	owner, err := s.rand.token()
+	if err != nil {
+		return 0x0, "", errors.Wrap(err)
+	}
+	hash := sha256.Sum256([]byte(owner))
+	codeHash := []byte(nil)
+	if opts.Access == AccessCode {
+		sum := sha256.Sum256([]byte(opts.Code))
+		codeHash = sum[:]
+	}
+	alb := model.Album{
+		Id:          album,
+		Images:      imgs,
+		Expires:     expires,
+		Ranking:     opts.Ranking,
+		Tournament:  tour,
+		Title:       opts.Metadata.Title,
+		Description: opts.Metadata.Description,
+		Owner:       hash[:],
+		Access:      opts.Access,
+		Code:        codeHash,
+		Results:     opts.Results,
+	}
 	err = s.pers.SaveAlbum(ctx, alb)
 	if err != nil {
-		return 0x0, errors.Wrap(err)
+		return 0x0, "", errors.Wrap(err)
 	}
 	err = s.queue.comp.add(ctx, album)
 	if err != nil {
-		return 0x0, errors.Wrap(err)
+		return 0x0, "", errors.Wrap(err)
 	}
-	if dur != 0 {
+	if opts.Duration != 0 {
 		err = s.queue.del.add(ctx, album, expires)
 		if err != nil {
-			return 0x0, errors.Wrap(err)
+			return 0x0, "", errors.Wrap(err)
 		}
 	}
-	return alb.Id, nil
+	return alb.Id, owner, nil
+}
+
+func (s *Service) putImages(ctx context.Context, album uint64, ff []model.File, captions []string) ([]model.Image, error) {
+	imgs := make([]model.Image, 0, len(ff))
+	for i, f := range ff {
+		image, err := s.rand.id()
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		src := ""
+		if f.Direct != 0x0 {
+			src, err = s.stor.CopyDirect(ctx, f.Direct, album, image)
+		} else {
+			src, err = s.stor.Put(ctx, album, image, f)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		img := model.Image{}
+		img.Id = image
+		img.Src = src
+		if i < len(captions) {
+			img.Caption = captions[i]
+		}
+		img.Filename = f.Name
+		imgs = append(imgs, img)
+	}
+	return imgs, nil
 }
 
 func (s *Service) Progress(ctx context.Context, album uint64) (float64, error) {
@@ -193,14 +297,31 @@ func (s *Service) Progress(ctx context.Context, album uint64) (float64, error) {
 	return float64(n) / float64(all), nil
 }
 
-func (s *Service) Pair(ctx context.Context, album uint64) (model.Image, model.Image, error) {
-	image1, image2, err := s.pair.Pop(ctx, album)
-	if errors.Is(err, domain.ErrPairNotFound) {
-		err = s.genPairs(ctx, album)
-		if err != nil {
-			return model.Image{}, model.Image{}, errors.Wrap(err)
-		}
-		image1, image2, err = s.pair.Pop(ctx, album)
+func (s *Service) Metadata(ctx context.Context, album uint64) (model.Metadata, error) {
+	meta, err := s.pers.GetMetadata(ctx, album)
+	if err != nil {
+		return model.Metadata{}, errors.Wrap(err)
+	}
+	return meta, nil
+}
+
+func (s *Service) Pair(ctx context.Context, album uint64, voter uint64, cred model.Credentials) (model.Image, model.Image, error) {
+	err := s.authorize(ctx, album, cred)
+	if err != nil {
+		return model.Image{}, model.Image{}, errors.Wrap(err)
+	}
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return model.Image{}, model.Image{}, errors.Wrap(err)
+	}
+	image1, image2 := uint64(0x0), uint64(0x0)
+	switch {
+	case tournament(tour.Mode):
+		image1, image2, err = s.match(ctx, album, voter, tour)
+	case voter == 0x0:
+		image1, image2, err = s.pop(ctx, album)
+	default:
+		image1, image2, err = s.popUnseen(ctx, album, voter)
 	}
 	if err != nil {
 		return model.Image{}, model.Image{}, errors.Wrap(err)
@@ -240,16 +361,28 @@ func (s *Service) Pair(ctx context.Context, album uint64) (model.Image, model.Im
 			return model.Image{}, model.Image{}, errors.Wrap(err)
 		}
 	}
-	img1 := model.Image{Id: image1, Src: src1, Token: token1}
-	img2 := model.Image{Id: image2, Src: src2, Token: token2}
+	caption1, err := s.pers.GetImageCaption(ctx, album, image1)
+	if err != nil {
+		return model.Image{}, model.Image{}, errors.Wrap(err)
+	}
+	caption2, err := s.pers.GetImageCaption(ctx, album, image2)
+	if err != nil {
+		return model.Image{}, model.Image{}, errors.Wrap(err)
+	}
+	img1 := model.Image{Id: image1, Src: src1, Caption: caption1, Token: token1}
+	img2 := model.Image{Id: image2, Src: src2, Caption: caption2, Token: token2}
 	return img1, img2, nil
 }
 
-func (s *Service) Image(ctx context.Context, token uint64) (model.File, error) {
+func (s *Service) Image(ctx context.Context, token uint64, cred model.Credentials) (model.File, error) {
 	album, image, err := s.token.Get(ctx, token)
 	if err != nil {
 		return model.File{}, errors.Wrap(err)
 	}
+	err = s.authorize(ctx, album, cred)
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
 	f, err := s.stor.Get(ctx, album, image)
 	if err != nil {
 		return model.File{}, errors.Wrap(err)
@@ -257,20 +390,95 @@ func (s *Service) Image(ctx context.Context, token uint64) (model.File, error) {
 	return f, nil
 }
 
+func (s *Service) pop(ctx context.Context, album uint64) (uint64, uint64, error) {
+	image1, image2, err := s.pair.Pop(ctx, album)
+	if errors.Is(err, domain.ErrPairNotFound) {
+		err = s.genPairs(ctx, album)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+		image1, image2, err = s.pair.Pop(ctx, album)
+	}
+	if err != nil {
+		return 0x0, 0x0, errors.Wrap(err)
+	}
+	return image1, image2, nil
+}
+
+func (s *Service) popUnseen(ctx context.Context, album uint64, voter uint64) (uint64, uint64, error) {
+	n, err := s.pers.CountImages(ctx, album)
+	if err != nil {
+		return 0x0, 0x0, errors.Wrap(err)
+	}
+	pairs, err := s.hist.GetSeen(ctx, voter, album)
+	if err != nil {
+		return 0x0, 0x0, errors.Wrap(err)
+	}
+	seen := make(map[[2]uint64]struct{}, len(pairs))
+	for _, pair := range pairs {
+		seen[key(pair[0], pair[1])] = struct{}{}
+	}
+	if len(seen) >= n*(n-1)/2 {
+		err = s.hist.DelSeen(ctx, voter, album)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+		seen = map[[2]uint64]struct{}{}
+	}
+	image1, image2 := uint64(0x0), uint64(0x0)
+	found := false
+	skipped := [][2]uint64(nil)
+	for i := 0; i < n && !found; i++ {
+		image1, image2, err = s.pop(ctx, album)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+		_, ok := seen[key(image1, image2)]
+		if ok || image1 == image2 {
+			skipped = append(skipped, [2]uint64{image1, image2})
+			continue
+		}
+		found = true
+	}
+	// the pairs the voter has already seen are left to other voters
+	if len(skipped) > 0 {
+		err = s.pair.Push(ctx, album, skipped)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+	}
+	if !found {
+		images, err := s.pers.GetImagesIds(ctx, album)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+		s.rand.shuffle(len(images), func(i, j int) { images[i], images[j] = images[j], images[i] })
+		for i := 0; i < len(images) && !found; i++ {
+			for j := i + 1; j < len(images) && !found; j++ {
+				_, ok := seen[key(images[i], images[j])]
+				if ok {
+					continue
+				}
+				image1, image2 = images[i], images[j]
+				found = true
+			}
+		}
+	}
+	if !found {
+		return 0x0, 0x0, errors.Wrap(domain.ErrPairNotFound)
+	}
+	return image1, image2, nil
+}
+
 func (s *Service) genPairs(ctx context.Context, album uint64) error {
-	images, err := s.pers.GetImagesIds(ctx, album)
+	sel, err := s.selector()
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	s.rand.shuffle(len(images), func(i, j int) { images[i], images[j] = images[j], images[i] })
-	images = append(images, images[0])
-	pairs := make([][2]uint64, 0, len(images)-1)
-	for i := 0; i < len(images)-1; i++ {
-		image1 := images[i]
-		image2 := images[i+1]
-		pairs = append(pairs, [2]uint64{image1, image2})
+	pairs, err := sel.Select(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
 	}
-	s.rand.shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
 	err = s.pair.Push(ctx, album, pairs)
 	if err != nil {
 		return errors.Wrap(err)
@@ -278,20 +486,50 @@ func (s *Service) genPairs(ctx context.Context, album uint64) error {
 	return nil
 }
 
-func (s *Service) Vote(ctx context.Context, album uint64, tokenFrom uint64, tokenTo uint64) error {
+func (s *Service) Vote(ctx context.Context, album uint64, voter uint64, tokenFrom uint64, tokenTo uint64, outcome string, cred model.Credentials) error {
+	switch outcome {
+	case "", OutcomeWin, OutcomeTie, OutcomeSkip:
+	default:
+		return errors.Wrap(domain.ErrOutcomeInvalid)
+	}
+	err := s.authorize(ctx, album, cred)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	frozen, err := s.pers.GetFrozen(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if frozen {
+		return errors.Wrap(domain.ErrVotingFrozen)
+	}
+	// an album kept for its results after the expiry takes no more votes
+	expires, err := s.pers.GetExpires(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if expired(expires) {
+		return errors.Wrap(domain.ErrVotingFrozen)
+	}
 	imageFrom := tokenFrom
 	imageTo := tokenTo
-	err := error(nil)
 	if s.conf.TempLinks {
-		_, imageFrom, err = s.token.Get(ctx, tokenFrom)
+		// both tokens are looked up before either is spent, a pair with
+		// a stale token is not half consumed
+		albumFrom, image, err := s.token.Get(ctx, tokenFrom)
 		if err != nil {
 			return errors.Wrap(err)
 		}
-		err = s.token.Del(ctx, tokenFrom)
+		imageFrom = image
+		albumTo, image, err := s.token.Get(ctx, tokenTo)
 		if err != nil {
 			return errors.Wrap(err)
 		}
-		_, imageTo, err = s.token.Get(ctx, tokenTo)
+		imageTo = image
+		if albumFrom != album || albumTo != album {
+			return errors.Wrap(domain.ErrTokenNotFound)
+		}
+		err = s.token.Del(ctx, tokenFrom)
 		if err != nil {
 			return errors.Wrap(err)
 		}
@@ -300,10 +538,42 @@ func (s *Service) Vote(ctx context.Context, album uint64, tokenFrom uint64, toke
 			return errors.Wrap(err)
 		}
 	}
-	err = s.pers.SaveVote(ctx, album, imageFrom, imageTo)
+	// the pair is only seen once it is judged, a skip included, a pair
+	// that is served and abandoned is served again
+	if voter != 0x0 {
+		err = s.hist.AddSeen(ctx, voter, album, imageFrom, imageTo)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	err = s.play(ctx, album, imageFrom, imageTo, outcome)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if outcome == OutcomeSkip {
+		err = s.pers.SaveSkip(ctx, album, imageFrom, imageTo)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	}
+	tie := outcome == OutcomeTie
+	err = s.pers.SaveVote(ctx, album, imageFrom, imageTo, tie)
 	if err != nil {
 		return errors.Wrap(err)
 	}
+	if s.conf.Incremental {
+		votes := []model.Vote{{From: imageFrom, To: imageTo, Weight: 1}}
+		if tie {
+			votes = []model.Vote{{From: imageFrom, To: imageTo, Weight: 0.5}, {From: imageTo, To: imageFrom, Weight: 0.5}}
+		}
+		for _, vote := range votes {
+			err = s.tally.AddVote(ctx, album, vote)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+		}
+	}
 	err = s.queue.calc.add(ctx, album)
 	if err != nil {
 		return errors.Wrap(err)
@@ -311,12 +581,50 @@ func (s *Service) Vote(ctx context.Context, album uint64, tokenFrom uint64, toke
 	return nil
 }
 
-func (s *Service) Top(ctx context.Context, album uint64) ([]model.Image, error) {
-	imgs, err := s.pers.GetImagesOrdered(ctx, album)
+func (s *Service) selector() (domain.Selector, error) {
+	sel, ok := s.sel[s.conf.PairSelection]
+	if !ok {
+		return nil, errors.Wrapf(domain.ErrUnknown, "pair selection %q", s.conf.PairSelection)
+	}
+	return sel, nil
+}
+
+// ranker resolves the ranking of an album, albums created before the
+// rankings became selectable have none and follow the configured one
+func (s *Service) ranker(ranking string) (domain.Ranker, error) {
+	if ranking == "" {
+		ranking = s.conf.Ranking
+	}
+	r, ok := s.rank[ranking]
+	if !ok {
+		return nil, errors.Wrapf(domain.ErrRankingInvalid, "ranking %q", ranking)
+	}
+	return r, nil
+}
+
+// Top returns a page of the leaderboard together with the version of the
+// album it was read at
+func (s *Service) Top(ctx context.Context, album uint64, cred model.Credentials, page model.Page) ([]model.Image, uint64, error) {
+	err := s.authorize(ctx, album, cred)
 	if err != nil {
-		return nil, errors.Wrap(err)
+		return nil, 0x0, errors.Wrap(err)
 	}
-	return imgs, nil
+	res, err := s.Results(ctx, album, cred.Owner)
+	if err != nil {
+		return nil, 0x0, errors.Wrap(err)
+	}
+	if !res.Available {
+		return nil, 0x0, errors.Wrap(domain.ErrResultsHidden)
+	}
+	ver, err := s.pers.GetVersion(ctx, album)
+	if err != nil {
+		return nil, 0x0, errors.Wrap(err)
+	}
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, page)
+	if err != nil {
+		return nil, 0x0, errors.Wrap(err)
+	}
+	return imgs, ver, nil
 }
 
 func (s *Service) Health(ctx context.Context) (bool, error) {
@@ -46,11 +46,13 @@ func (suite *ServiceIntegrationTestSuite) SetupSuite() {
 	qDel := NewQueueDel(cach)
 	qDel.Monitor(ctx)
 	fnShuffle := func(n int, swap func(i int, j int)) {}
+	fnIntn := func(n int) int { return 0 }
 	heartbeatComp := make(chan any)
 	heartbeatCalc := make(chan any)
 	heartbeatDel := make(chan any)
 	serv := New(DefaultServiceConfig, comp, stor, data, cach, qCalc, qComp, qDel,
 		WithRandShuffle(fnShuffle),
+		WithRandIntn(fnIntn),
 		WithHeartbeatComp(heartbeatComp),
 		WithHeartbeatCalc(heartbeatCalc),
 		WithHeartbeatDel(heartbeatDel),
@@ -4,7 +4,10 @@ import (
 	"context"
 	"flag"
 	"io"
+	"math"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -23,6 +26,7 @@ import (
 	. "github.com/zitryss/aye-and-nay/internal/generator"
 	. "github.com/zitryss/aye-and-nay/internal/testing"
 	"github.com/zitryss/aye-and-nay/pkg/log"
+	"github.com/zitryss/aye-and-nay/pkg/ranking"
 )
 
 var (
@@ -96,11 +100,13 @@ func (suite *ServiceTestSuite) SetupSuite() {
 	qDel := NewQueueDel(cach)
 	qDel.Monitor(ctx)
 	fnShuffle := func(n int, swap func(i int, j int)) {}
+	fnIntn := func(n int) int { return 0 }
 	heartbeatComp := make(chan any)
 	heartbeatCalc := make(chan any)
 	heartbeatDel := make(chan any)
 	serv := New(DefaultServiceConfig, comp, stor, data, cach, qCalc, qComp, qDel,
 		WithRandShuffle(fnShuffle),
+		WithRandIntn(fnIntn),
 		WithHeartbeatComp(heartbeatComp),
 		WithHeartbeatCalc(heartbeatCalc),
 		WithHeartbeatDel(heartbeatDel),
@@ -161,7 +167,7 @@ func (suite *ServiceTestSuite) TestServiceAlbum() {
 	suite.T().Run("Positive", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		_, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		_, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
 		v := AssertChannel(t, suite.heartbeatComp)
 		p, ok := v.(float64)
@@ -172,15 +178,57 @@ func (suite *ServiceTestSuite) TestServiceAlbum() {
 		assert.True(t, ok)
 		assert.InDelta(t, 1, p, TOLERANCE)
 	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		_, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Ranking: "unknown"})
+		assert.ErrorIs(t, err, domain.ErrRankingInvalid)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		r, err := suite.serv.ranker("")
+		assert.NoError(t, err)
+		assert.Same(t, suite.serv.rank[DefaultServiceConfig.Ranking], r)
+		_, err = suite.serv.ranker("unknown")
+		assert.ErrorIs(t, err, domain.ErrRankingInvalid)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceMetadata() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		meta := model.Metadata{Title: "Cats", Description: "Which one is the cutest?", Captions: []string{"Tom", "Felix"}}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Metadata: meta})
+		assert.NoError(t, err)
+		got, err := suite.serv.Metadata(suite.ctx, album)
+		assert.NoError(t, err)
+		want := model.Metadata{Title: "Cats", Description: "Which one is the cutest?"}
+		assert.Equal(t, want, got)
+		img3, img4, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		img1 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(3), Src: "/api/images/" + suite.ids.Base64(3) + "/", Caption: "Tom"}
+		img2 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(4), Src: "/api/images/" + suite.ids.Base64(4) + "/", Caption: "Felix"}
+		imgs := []model.Image{img1, img2}
+		assert.Contains(t, imgs, img3)
+		assert.Contains(t, imgs, img4)
+		top, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Tom", "Felix"}, []string{top[0].Caption, top[1].Caption})
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		_, err := suite.serv.Metadata(suite.ctx, 0xB0C4)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
 }
 
 func (suite *ServiceTestSuite) TestServicePair() {
 	suite.T().Run("Positive1", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img7, img8, err := suite.serv.Pair(suite.ctx, album)
+		img7, img8, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img1 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(3), Src: "/api/images/" + suite.ids.Base64(3) + "/"}
 		img2 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(4), Src: "/api/images/" + suite.ids.Base64(4) + "/"}
@@ -188,7 +236,7 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		assert.NotEqual(t, img7, img8)
 		assert.Contains(t, imgs1, img7)
 		assert.Contains(t, imgs1, img8)
-		img9, img10, err := suite.serv.Pair(suite.ctx, album)
+		img9, img10, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img3 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(5), Src: "/api/images/" + suite.ids.Base64(5) + "/"}
 		img4 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(6), Src: "/api/images/" + suite.ids.Base64(6) + "/"}
@@ -196,7 +244,7 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		assert.NotEqual(t, img9, img10)
 		assert.Contains(t, imgs2, img9)
 		assert.Contains(t, imgs2, img10)
-		img11, img12, err := suite.serv.Pair(suite.ctx, album)
+		img11, img12, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img5 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(7), Src: "/api/images/" + suite.ids.Base64(7) + "/"}
 		img6 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(8), Src: "/api/images/" + suite.ids.Base64(8) + "/"}
@@ -211,9 +259,9 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		suite.serv.conf.TempLinks = false
 		defer func() { suite.serv.conf.TempLinks = oldTempLinks }()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img7, img8, err := suite.serv.Pair(suite.ctx, album)
+		img7, img8, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img1 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(1), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(1)}
 		img2 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(2), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(2)}
@@ -221,7 +269,7 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		assert.NotEqual(t, img7, img8)
 		assert.Contains(t, imgs1, img7)
 		assert.Contains(t, imgs1, img8)
-		img9, img10, err := suite.serv.Pair(suite.ctx, album)
+		img9, img10, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img3 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(2), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(2)}
 		img4 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(1), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(1)}
@@ -229,7 +277,7 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		assert.NotEqual(t, img9, img10)
 		assert.Contains(t, imgs2, img9)
 		assert.Contains(t, imgs2, img10)
-		img11, img12, err := suite.serv.Pair(suite.ctx, album)
+		img11, img12, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
 		img5 := model.Image{Id: suite.ids.Uint64(1), Token: suite.ids.Uint64(1), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(1)}
 		img6 := model.Image{Id: suite.ids.Uint64(2), Token: suite.ids.Uint64(2), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(2)}
@@ -238,31 +286,89 @@ func (suite *ServiceTestSuite) TestServicePair() {
 		assert.Contains(t, imgs3, img11)
 		assert.Contains(t, imgs3, img12)
 	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		voter1 := suite.id()
+		voter2 := suite.id()
+		seen := map[[2]uint64]int{}
+		for i := 0; i < 3; i++ {
+			img1, img2, err := suite.serv.Pair(suite.ctx, album, voter1, model.Credentials{})
+			assert.NoError(t, err)
+			assert.NotEqual(t, img1.Id, img2.Id)
+			seen[key(img1.Id, img2.Id)]++
+			err = suite.serv.Vote(suite.ctx, album, voter1, img1.Token, img2.Token, OutcomeSkip, model.Credentials{})
+			assert.NoError(t, err)
+		}
+		assert.Len(t, seen, 3)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, voter2, model.Credentials{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, img1.Id, img2.Id)
+		img1, img2, err = suite.serv.Pair(suite.ctx, album, voter1, model.Credentials{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, img1.Id, img2.Id)
+		err = suite.serv.Vote(suite.ctx, album, voter1, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		pairs, err := suite.serv.hist.GetSeen(suite.ctx, voter1, album)
+		assert.NoError(t, err)
+		assert.Len(t, pairs, 1)
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		voter := suite.id()
+		_, _, err = suite.serv.Pair(suite.ctx, album, voter, model.Credentials{})
+		assert.NoError(t, err)
+		pairs, err := suite.serv.hist.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.Empty(t, pairs)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, voter, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, voter, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		pairs, err = suite.serv.hist.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]uint64{key(img1.Id, img2.Id)}, pairs)
+	})
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
-		_, _, err := suite.serv.Pair(suite.ctx, suite.id())
+		_, _, err := suite.serv.Pair(suite.ctx, suite.id(), 0x0, model.Credentials{})
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		suite.serv.conf.PairSelection = "randon"
+		defer func() { suite.serv.conf.PairSelection = DefaultServiceConfig.PairSelection }()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrUnknown)
+	})
 }
 
 func (suite *ServiceTestSuite) TestServiceImage() {
 	suite.T().Run("Positive", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img1, img2, err := suite.serv.Pair(suite.ctx, album)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		f, err := suite.serv.Image(suite.ctx, img1.Token)
+		f, err := suite.serv.Image(suite.ctx, img1.Token, model.Credentials{})
 		assert.NoError(t, err)
 		assert.NotNil(t, f.Reader)
-		f, err = suite.serv.Image(suite.ctx, img2.Token)
+		f, err = suite.serv.Image(suite.ctx, img2.Token, model.Credentials{})
 		assert.NoError(t, err)
 		assert.NotNil(t, f.Reader)
 	})
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
-		_, err := suite.serv.Image(suite.ctx, suite.id())
+		_, err := suite.serv.Image(suite.ctx, suite.id(), model.Credentials{})
 		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
 	})
 }
@@ -271,11 +377,11 @@ func (suite *ServiceTestSuite) TestServiceVote() {
 	suite.T().Run("Positive1", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img1, img2, err := suite.serv.Pair(suite.ctx, album)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, album, img1.Token, img2.Token)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
 		assert.NoError(t, err)
 	})
 	suite.T().Run("Positive2", func(t *testing.T) {
@@ -284,65 +390,371 @@ func (suite *ServiceTestSuite) TestServiceVote() {
 		suite.serv.conf.TempLinks = false
 		defer func() { suite.serv.conf.TempLinks = oldTempLinks }()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img1, img2, err := suite.serv.Pair(suite.ctx, album)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, album, img1.Token, img2.Token)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
 		assert.NoError(t, err)
 	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeTie, model.Credentials{})
+		assert.NoError(t, err)
+		edgs, err := suite.serv.pers.GetEdges(suite.ctx, album)
+		assert.NoError(t, err)
+		for from := range edgs {
+			for to := range edgs {
+				if from == to {
+					continue
+				}
+				assert.Equal(t, 0.5, edgs[from][to])
+			}
+		}
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeSkip, model.Credentials{})
+		assert.NoError(t, err)
+		_, _, err = suite.serv.token.Get(suite.ctx, img1.Token)
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
+		edgs, err := suite.serv.pers.GetEdges(suite.ctx, album)
+		assert.NoError(t, err)
+		for from := range edgs {
+			for to := range edgs {
+				assert.Zero(t, edgs[from][to])
+			}
+		}
+		imgs, err := suite.serv.pers.GetImagesOrdered(suite.ctx, album, model.Page{})
+		assert.NoError(t, err)
+		for _, img := range imgs {
+			assert.Equal(t, 1, img.Skips)
+		}
+	})
 	suite.T().Run("Negative1", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img1, img2, err := suite.serv.Pair(suite.ctx, album)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, suite.id(), img1.Token, img2.Token)
+		err = suite.serv.Vote(suite.ctx, suite.id(), 0x0, img1.Token, img2.Token, "", model.Credentials{})
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
 	suite.T().Run("Negative2", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		_, _, err = suite.serv.Pair(suite.ctx, album)
+		_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, album, suite.id(), suite.id())
+		err = suite.serv.Vote(suite.ctx, album, 0x0, suite.id(), suite.id(), "", model.Credentials{})
 		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
 	})
+	suite.T().Run("Negative3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "draw", model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrOutcomeInvalid)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceTournament() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSingleElimination})
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+			assert.NoError(t, err)
+			err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+			assert.NoError(t, err)
+			AssertChannel(t, suite.heartbeatCalc)
+		}
+		_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrTournamentFinished)
+		tour, imgs, err := suite.serv.Bracket(suite.ctx, album, model.Credentials{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs, 3)
+		match1 := model.Match{Round: 1, Image1: suite.ids.Uint64(1), Image2: suite.ids.Uint64(2), Winner: suite.ids.Uint64(2), Done: true}
+		match2 := model.Match{Round: 1, Image1: suite.ids.Uint64(3), Winner: suite.ids.Uint64(3), Done: true}
+		match3 := model.Match{Round: 2, Image1: suite.ids.Uint64(2), Image2: suite.ids.Uint64(3), Winner: suite.ids.Uint64(3), Done: true}
+		seeds := []uint64{suite.ids.Uint64(1), suite.ids.Uint64(2), suite.ids.Uint64(3)}
+		want := model.Tournament{Mode: ModeSingleElimination, Round: 2, Rounds: 2, Seeds: seeds, Matches: []model.Match{match1, match2, match3}, Champion: suite.ids.Uint64(3), Version: 2}
+		assert.Equal(t, want, tour)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSwiss})
+		assert.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+			assert.NoError(t, err)
+			err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+			assert.NoError(t, err)
+			AssertChannel(t, suite.heartbeatCalc)
+		}
+		_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrTournamentFinished)
+		tour, imgs, err := suite.serv.Bracket(suite.ctx, album, model.Credentials{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, tour.Round)
+		assert.Equal(t, 2, tour.Rounds)
+		assert.Len(t, tour.Matches, 4)
+		assert.Equal(t, suite.ids.Uint64(4), tour.Champion)
+		scores := map[uint64]float64{suite.ids.Uint64(1): 0, suite.ids.Uint64(2): 1, suite.ids.Uint64(3): 1, suite.ids.Uint64(4): 2}
+		assert.Equal(t, scores, tour.Scores)
+		order := make([]uint64, 0, len(imgs))
+		for _, img := range imgs {
+			order = append(order, img.Id)
+		}
+		assert.Equal(t, []uint64{suite.ids.Uint64(4), suite.ids.Uint64(2), suite.ids.Uint64(3), suite.ids.Uint64(1)}, order)
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSingleElimination})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeTie, model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		img3, img4, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		assert.Equal(t, img1.Id, img3.Id)
+		assert.Equal(t, img2.Id, img4.Id)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img4.Token, img3.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		tour, _, err := suite.serv.Bracket(suite.ctx, album, model.Credentials{})
+		assert.NoError(t, err)
+		assert.Equal(t, img3.Id, tour.Champion)
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png(), Png(), Png(), Png(), Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSingleElimination})
+		assert.NoError(t, err)
+		tour, err := suite.serv.pers.GetTournament(suite.ctx, album)
+		assert.NoError(t, err)
+		require.Len(t, tour.Matches, 4)
+		// every vote reads the tournament before any of them saves it
+		pers := suite.serv.pers
+		barrier := &sync.WaitGroup{}
+		barrier.Add(len(tour.Matches))
+		suite.serv.pers = &barrierDatabase{Databaser: pers, barrier: barrier, n: int32(len(tour.Matches))}
+		defer func() { suite.serv.pers = pers }()
+		wg := sync.WaitGroup{}
+		for _, m := range tour.Matches {
+			wg.Add(1)
+			go func(m model.Match) {
+				defer wg.Done()
+				err := suite.serv.play(suite.ctx, album, m.Image1, m.Image2, "")
+				assert.NoError(t, err)
+			}(m)
+		}
+		wg.Wait()
+		tour, err = suite.serv.pers.GetTournament(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, tour.Round)
+		require.Len(t, tour.Matches, 6)
+		for _, m := range tour.Matches[:4] {
+			assert.True(t, m.Done)
+			assert.Equal(t, m.Image2, m.Winner)
+		}
+	})
+	suite.T().Run("Positive5", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSingleElimination})
+		assert.NoError(t, err)
+		voter := suite.id()
+		_, _, err = suite.serv.Pair(suite.ctx, album, voter, model.Credentials{})
+		assert.NoError(t, err)
+		pairs, err := suite.serv.hist.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.Empty(t, pairs)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, voter, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, voter, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		pairs, err = suite.serv.hist.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]uint64{key(img1.Id, img2.Id)}, pairs)
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		_, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: "unknown"})
+		assert.ErrorIs(t, err, domain.ErrModeInvalid)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		_, _, err = suite.serv.Bracket(suite.ctx, album, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrTournamentNotFound)
+	})
+	suite.T().Run("Negative3", func(t *testing.T) {
+		suite.setupTestFn()
+		_, _, err := suite.serv.Bracket(suite.ctx, suite.id(), model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
 }
 
 func (suite *ServiceTestSuite) TestServiceTop() {
-	suite.T().Run("Positive", func(t *testing.T) {
+	suite.T().Run("Positive1", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
-		album, err := suite.serv.Album(suite.ctx, files, 0*time.Millisecond)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
 		assert.NoError(t, err)
-		img1, img2, err := suite.serv.Pair(suite.ctx, album)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, album, img1.Token, img2.Token)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
 		assert.NoError(t, err)
 		AssertChannel(t, suite.heartbeatCalc)
-		img3, img4, err := suite.serv.Pair(suite.ctx, album)
+		img3, img4, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
 		assert.NoError(t, err)
-		err = suite.serv.Vote(suite.ctx, album, img3.Token, img4.Token)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img3.Token, img4.Token, "", model.Credentials{})
 		assert.NoError(t, err)
 		AssertChannel(t, suite.heartbeatCalc)
-		imgs1, err := suite.serv.Top(suite.ctx, album)
+		imgs1, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
 		assert.NoError(t, err)
-		img5 := model.Image{Id: suite.ids.Uint64(1), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(1), Rating: 0.5, Compressed: false}
-		img6 := model.Image{Id: suite.ids.Uint64(2), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(2), Rating: 0.5, Compressed: false}
+		img5 := model.Image{Id: suite.ids.Uint64(1), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(1), Rating: 0.5, RatingLow: 0.35087712117725867, RatingHigh: 0.5, Comparisons: 2, Compressed: false}
+		img6 := model.Image{Id: suite.ids.Uint64(2), Src: "/aye-and-nay/albums/" + suite.ids.Base64(0) + "/images/" + suite.ids.Base64(2), Rating: 0.5, RatingLow: 0.5, RatingHigh: 0.6491228788227413, Comparisons: 2, Compressed: false}
 		imgs2 := []model.Image{img5, img6}
 		assert.Equal(t, imgs2, imgs1)
 	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Ranking: "elo"})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		imgs, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs, 2)
+		assert.InDelta(t, 1516, imgs[0].Rating, TOLERANCE)
+		assert.InDelta(t, 1516, imgs[0].RatingLow, TOLERANCE)
+		assert.InDelta(t, 1516, imgs[0].RatingHigh, TOLERANCE)
+		assert.Equal(t, 1, imgs[0].Comparisons)
+		assert.InDelta(t, 1484, imgs[1].Rating, TOLERANCE)
+		assert.InDelta(t, 1484, imgs[1].RatingLow, TOLERANCE)
+		assert.InDelta(t, 1484, imgs[1].RatingHigh, TOLERANCE)
+		assert.Equal(t, 1, imgs[1].Comparisons)
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		suite.serv.conf.BootstrapInterval = time.Hour
+		defer func() { suite.serv.conf.BootstrapInterval = DefaultServiceConfig.BootstrapInterval }()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		imgs1, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		img3, img4, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img3.Token, img4.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		imgs2, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs2, 2)
+		prev := map[uint64]model.Image{imgs1[0].Id: imgs1[0], imgs1[1].Id: imgs1[1]}
+		for _, img := range imgs2 {
+			assert.InDelta(t, math.Min(prev[img.Id].RatingLow, img.Rating), img.RatingLow, TOLERANCE)
+			assert.InDelta(t, math.Max(prev[img.Id].RatingHigh, img.Rating), img.RatingHigh, TOLERANCE)
+			assert.Equal(t, 2, img.Comparisons)
+		}
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Ranking: "glicko2"})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		imgs, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs, 2)
+		edgs := map[uint64]map[uint64]float64{imgs[1].Id: {imgs[0].Id: 1}, imgs[0].Id: {}}
+		ratings, deviations := ranking.Glicko2(edgs, DefaultServiceConfig.Glicko2Tau)
+		for _, img := range imgs {
+			assert.InDelta(t, ratings[img.Id], img.Rating, 0.000001)
+			assert.InDelta(t, ratings[img.Id]-1.96*deviations[img.Id], img.RatingLow, 0.000001)
+			assert.InDelta(t, ratings[img.Id]+1.96*deviations[img.Id], img.RatingHigh, 0.000001)
+		}
+	})
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
-		_, err := suite.serv.Top(suite.ctx, suite.id())
+		_, _, err := suite.serv.Top(suite.ctx, suite.id(), model.Credentials{}, model.Page{})
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
 }
 
+func (suite *ServiceTestSuite) TestServiceIncremental() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		suite.serv.conf.Incremental = true
+		defer func() { suite.serv.conf.Incremental = DefaultServiceConfig.Incremental }()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Ranking: "elo"})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		img3, img4, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img3.Token, img4.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		imgs, _, err := suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs, 2)
+		assert.InDelta(t, 1501.4695015289756, imgs[0].Rating, TOLERANCE)
+		assert.InDelta(t, 1484, imgs[0].RatingLow, TOLERANCE)
+		assert.InDelta(t, 1501.4695015289756, imgs[0].RatingHigh, TOLERANCE)
+		assert.Equal(t, 2, imgs[0].Comparisons)
+		assert.InDelta(t, 1498.5304984710244, imgs[1].Rating, TOLERANCE)
+		assert.InDelta(t, 1498.5304984710244, imgs[1].RatingLow, TOLERANCE)
+		assert.InDelta(t, 1516, imgs[1].RatingHigh, TOLERANCE)
+		assert.Equal(t, 2, imgs[1].Comparisons)
+	})
+}
+
 func (suite *ServiceTestSuite) TestServiceDelete() {
 	suite.T().Run("Positive1", func(t *testing.T) {
 		suite.setupTestFn()
@@ -367,25 +779,478 @@ func (suite *ServiceTestSuite) TestServiceDelete() {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
 		dur := 100 * time.Millisecond
-		album, err := suite.serv.Album(suite.ctx, files, dur)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Duration: dur})
 		assert.NoError(t, err)
 		AssertChannel(t, suite.heartbeatDel)
-		_, err = suite.serv.Top(suite.ctx, album)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
 		files := []model.File{Png(), Png()}
 		dur := 0 * time.Second
-		album, err := suite.serv.Album(suite.ctx, files, dur)
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Duration: dur})
 		assert.NoError(t, err)
 		AssertNotChannel(t, suite.heartbeatDel)
-		_, err = suite.serv.Top(suite.ctx, album)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
 		assert.NoError(t, err)
 	})
 }
 
+func (suite *ServiceTestSuite) TestServiceOwner() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, owner)
+		err = suite.serv.Freeze(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrVotingFrozen)
+		err = suite.serv.Reopen(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		err = suite.serv.Delete(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		v := AssertChannel(t, suite.heartbeatDel)
+		assert.Equal(t, album, v)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Duration: 100 * time.Millisecond})
+		assert.NoError(t, err)
+		err = suite.serv.Extend(suite.ctx, album, owner, 0*time.Millisecond)
+		assert.NoError(t, err)
+		AssertNotChannel(t, suite.heartbeatDel)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		err = suite.serv.Extend(suite.ctx, album, owner, 100*time.Millisecond)
+		assert.NoError(t, err)
+		v := AssertChannel(t, suite.heartbeatDel)
+		assert.Equal(t, album, v)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		err = suite.serv.Delete(suite.ctx, album, "")
+		assert.ErrorIs(t, err, domain.ErrDeleteForbidden)
+		err = suite.serv.Freeze(suite.ctx, album, "wrong")
+		assert.ErrorIs(t, err, domain.ErrFreezeForbidden)
+		err = suite.serv.Reopen(suite.ctx, album, "wrong")
+		assert.ErrorIs(t, err, domain.ErrReopenForbidden)
+		err = suite.serv.Extend(suite.ctx, album, "wrong", time.Hour)
+		assert.ErrorIs(t, err, domain.ErrExtendForbidden)
+		err = suite.serv.Delete(suite.ctx, 0xB0C4, "wrong")
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceImages() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		images, err := suite.serv.AddImages(suite.ctx, album, owner, []model.File{Png()}, []string{"Tom"})
+		assert.NoError(t, err)
+		assert.Len(t, images, 1)
+		n, err := suite.serv.pers.CountImages(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		caption, err := suite.serv.pers.GetImageCaption(suite.ctx, album, images[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Tom", caption)
+		err = suite.serv.RemoveImage(suite.ctx, album, owner, images[0])
+		assert.NoError(t, err)
+		n, err = suite.serv.pers.CountImages(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		_, err = suite.serv.AddImages(suite.ctx, album, "wrong", []model.File{Png()}, nil)
+		assert.ErrorIs(t, err, domain.ErrAddForbidden)
+		err = suite.serv.RemoveImage(suite.ctx, album, "wrong", suite.ids.Uint64(1))
+		assert.ErrorIs(t, err, domain.ErrRemoveForbidden)
+		err = suite.serv.RemoveImage(suite.ctx, album, owner, suite.ids.Uint64(1))
+		assert.ErrorIs(t, err, domain.ErrNotEnoughImages)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Mode: ModeSwiss})
+		assert.NoError(t, err)
+		_, err = suite.serv.AddImages(suite.ctx, album, owner, []model.File{Png()}, nil)
+		assert.ErrorIs(t, err, domain.ErrTournamentImmutable)
+		err = suite.serv.RemoveImage(suite.ctx, album, owner, suite.ids.Uint64(1))
+		assert.ErrorIs(t, err, domain.ErrTournamentImmutable)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceExport() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", model.Credentials{})
+		assert.NoError(t, err)
+		exp, err := suite.serv.Export(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		assert.Len(t, exp.Images, 2)
+		assert.Equal(t, 1.0, exp.Edges[img1.Id][img2.Id])
+		f, err := exp.Open(suite.ctx, img1.Id)
+		require.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, Png().Size, f.Size)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		_, err = suite.serv.Export(suite.ctx, album, "wrong")
+		assert.ErrorIs(t, err, domain.ErrExportForbidden)
+		_, err = suite.serv.Export(suite.ctx, 0xB0C4, "wrong")
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceImport() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		f1, f2, f3 := Png(), Png(), Png()
+		f1.Name, f2.Name, f3.Name = "tom.png", "felix.png", "garfield.png"
+		files := []model.File{f1, f2, f3}
+		meta := model.Metadata{Captions: []string{"Tom", "Felix"}}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Metadata: meta})
+		assert.NoError(t, err)
+		edgs := []model.Edge{{Winner: "Tom", Loser: "Felix", Count: 2}, {Winner: "garfield.png", Loser: "tom.png", Count: 1}}
+		n, err := suite.serv.Import(suite.ctx, album, owner, edgs)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		AssertChannel(t, suite.heartbeatCalc)
+		exp, err := suite.serv.Export(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		ids := map[string]uint64{}
+		for _, img := range exp.Images {
+			ids[img.Filename] = img.Id
+		}
+		assert.Equal(t, 2.0, exp.Edges[ids["felix.png"]][ids["tom.png"]])
+		assert.Equal(t, 1.0, exp.Edges[ids["tom.png"]][ids["garfield.png"]])
+		assert.Equal(t, ids["garfield.png"], exp.Images[0].Id)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png(), Png()}
+		meta := model.Metadata{Captions: []string{"Tom", "Tom", "Felix"}}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Metadata: meta})
+		assert.NoError(t, err)
+		_, err = suite.serv.Import(suite.ctx, album, "wrong", []model.Edge{{Winner: "Felix", Loser: "Tom", Count: 1}})
+		assert.ErrorIs(t, err, domain.ErrImportForbidden)
+		_, err = suite.serv.Import(suite.ctx, album, owner, []model.Edge{{Winner: "Felix", Loser: "Tom", Count: 1}})
+		assert.ErrorIs(t, err, domain.ErrReferenceInvalid)
+		_, err = suite.serv.Import(suite.ctx, album, owner, []model.Edge{{Winner: "Felix", Loser: "Garfield", Count: 1}})
+		assert.ErrorIs(t, err, domain.ErrReferenceInvalid)
+		_, err = suite.serv.Import(suite.ctx, album, owner, []model.Edge{{Winner: "Felix", Loser: "Felix", Count: 1}})
+		assert.ErrorIs(t, err, domain.ErrImportInvalid)
+		_, err = suite.serv.Import(suite.ctx, album, owner, []model.Edge{{Winner: "Felix", Loser: "Tom", Count: 0}})
+		assert.ErrorIs(t, err, domain.ErrImportInvalid)
+		edgs, err := suite.serv.pers.GetEdges(suite.ctx, album)
+		assert.NoError(t, err)
+		for _, tos := range edgs {
+			assert.Empty(t, tos)
+		}
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceCode() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "hunter2"})
+		assert.NoError(t, err)
+		cred := model.Credentials{Code: "hunter2"}
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, cred)
+		assert.NoError(t, err)
+		_, err = suite.serv.Image(suite.ctx, img1.Token, cred)
+		assert.NoError(t, err)
+		pass, err := suite.serv.Access(suite.ctx, album, "hunter2", model.Credentials{})
+		assert.NoError(t, err)
+		assert.NotZero(t, pass)
+		cred = model.Credentials{Pass: pass}
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, "", cred)
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		_, _, err = suite.serv.Top(suite.ctx, album, cred, model.Page{})
+		assert.NoError(t, err)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album1, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "hunter2"})
+		assert.NoError(t, err)
+		files = []model.File{Png(), Png()}
+		album2, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "swordfish"})
+		assert.NoError(t, err)
+		pass1, err := suite.serv.Access(suite.ctx, album1, "hunter2", model.Credentials{})
+		assert.NoError(t, err)
+		pass2, err := suite.serv.Access(suite.ctx, album2, "swordfish", model.Credentials{Pass: pass1})
+		assert.NoError(t, err)
+		assert.Equal(t, pass1, pass2)
+		_, _, err = suite.serv.Top(suite.ctx, album1, model.Credentials{Pass: pass1}, model.Page{})
+		assert.NoError(t, err)
+		_, _, err = suite.serv.Top(suite.ctx, album2, model.Credentials{Pass: pass1}, model.Page{})
+		assert.NoError(t, err)
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		for _, access := range []string{"", AccessPublic, AccessUnlisted} {
+			files := []model.File{Png(), Png()}
+			album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: access})
+			assert.NoError(t, err)
+			_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+			assert.NoError(t, err)
+			pass, err := suite.serv.Access(suite.ctx, album, "hunter2", model.Credentials{})
+			assert.NoError(t, err)
+			assert.Zero(t, pass)
+		}
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "hunter2"})
+		assert.NoError(t, err)
+		for i := 0; i < cache.DefaultMemConfig.CodeLimiterBurst+1; i++ {
+			_, err = suite.serv.Access(suite.ctx, album, "hunter2", model.Credentials{})
+			assert.NoError(t, err)
+		}
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		_, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: "private"})
+		assert.ErrorIs(t, err, domain.ErrAccessInvalid)
+		_, _, err = suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode})
+		assert.ErrorIs(t, err, domain.ErrCodeInvalid)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "hunter2"})
+		assert.NoError(t, err)
+		_, _, err = suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{Pass: suite.id()}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = suite.serv.Access(suite.ctx, album, "", model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		for i := 0; i < cache.DefaultMemConfig.CodeLimiterBurst; i++ {
+			_, err = suite.serv.Access(suite.ctx, album, "password", model.Credentials{})
+			assert.ErrorIs(t, err, domain.ErrForbidden)
+		}
+		_, err = suite.serv.Access(suite.ctx, album, "hunter2", model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrTooManyRequests)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{Code: "hunter2"}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrTooManyRequests)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceResults() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Results: ResultsOwner})
+		assert.NoError(t, err)
+		res, err := suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.Equal(t, model.Results{Policy: ResultsOwner}, res)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrResultsHidden)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{Owner: owner}, model.Page{})
+		assert.NoError(t, err)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Results: ResultsClosed})
+		assert.NoError(t, err)
+		res, err := suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.Equal(t, model.Results{Policy: ResultsClosed}, res)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrResultsHidden)
+		err = suite.serv.Freeze(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		res, err = suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.True(t, res.Available)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Duration: 1 * time.Hour, Results: ResultsClosed})
+		assert.NoError(t, err)
+		res, err := suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.False(t, res.Available)
+		assert.WithinDuration(t, time.Now().Add(1*time.Hour), res.AvailableAt, 1*time.Minute)
+		res, err = suite.serv.Results(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		assert.Equal(t, model.Results{Policy: ResultsClosed, Available: true}, res)
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		res, err := suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.Equal(t, model.Results{Policy: ResultsAlways, Available: true}, res)
+	})
+	suite.T().Run("Positive5", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		dur := 100 * time.Millisecond
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Duration: dur, Results: ResultsClosed})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		AssertNotChannel(t, suite.heartbeatDel)
+		res, err := suite.serv.Results(suite.ctx, album, "")
+		assert.NoError(t, err)
+		assert.Equal(t, model.Results{Policy: ResultsClosed, Available: true}, res)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeWin, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrVotingFrozen)
+		err = suite.serv.Delete(suite.ctx, album, owner)
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatDel)
+		_, _, err = suite.serv.Top(suite.ctx, album, model.Credentials{}, model.Page{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		_, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Results: "never"})
+		assert.ErrorIs(t, err, domain.ErrResultsInvalid)
+		_, err = suite.serv.Results(suite.ctx, suite.id(), "")
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func (suite *ServiceTestSuite) TestServiceEvents() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{})
+		assert.NoError(t, err)
+		ctx, cancel := context.WithCancel(suite.ctx)
+		defer cancel()
+		ch, err := suite.serv.Events(ctx, album, model.Credentials{})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeWin, model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		event := awaitEvent(t, ch, EventRatings)
+		assert.Equal(t, 1, event.Votes)
+		assert.Len(t, event.Images, 2)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, owner, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Results: ResultsOwner})
+		assert.NoError(t, err)
+		ctx, cancel := context.WithCancel(suite.ctx)
+		defer cancel()
+		ch1, err := suite.serv.Events(ctx, album, model.Credentials{})
+		assert.NoError(t, err)
+		ch2, err := suite.serv.Events(ctx, album, model.Credentials{Owner: owner})
+		assert.NoError(t, err)
+		img1, img2, err := suite.serv.Pair(suite.ctx, album, 0x0, model.Credentials{})
+		assert.NoError(t, err)
+		err = suite.serv.Vote(suite.ctx, album, 0x0, img1.Token, img2.Token, OutcomeWin, model.Credentials{})
+		assert.NoError(t, err)
+		AssertChannel(t, suite.heartbeatCalc)
+		event := awaitEvent(t, ch1, EventRatings)
+		assert.Equal(t, 1, event.Votes)
+		assert.Empty(t, event.Images)
+		event = awaitEvent(t, ch2, EventRatings)
+		assert.Equal(t, 1, event.Votes)
+		assert.Len(t, event.Images, 2)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		files := []model.File{Png(), Png()}
+		album, _, err := suite.serv.Album(suite.ctx, files, model.AlbumOptions{Access: AccessCode, Code: "hunter2"})
+		assert.NoError(t, err)
+		_, err = suite.serv.Events(suite.ctx, album, model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = suite.serv.Events(suite.ctx, suite.id(), model.Credentials{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func awaitEvent(t *testing.T, ch <-chan model.Event, typ string) model.Event {
+	t.Helper()
+	timeout := time.After(1 * time.Second)
+	for {
+		select {
+		case event := <-ch:
+			if event.Type == typ {
+				return event
+			}
+		case <-timeout:
+			t.Error("<-time.After(1 * time.Second)")
+			return model.Event{}
+		}
+	}
+}
+
 func (suite *ServiceTestSuite) TestServiceHealth() {
 	_, err := suite.serv.Health(suite.ctx)
 	assert.NoError(suite.T(), err)
 }
+
+// barrierDatabase holds back the first n reads of a tournament until all
+// of them have been made
+type barrierDatabase struct {
+	domain.Databaser
+	barrier *sync.WaitGroup
+	n       int32
+}
+
+func (b *barrierDatabase) GetTournament(ctx context.Context, album uint64) (model.Tournament, error) {
+	tour, err := b.Databaser.GetTournament(ctx, album)
+	if atomic.AddInt32(&b.n, -1) >= 0 {
+		b.barrier.Done()
+		b.barrier.Wait()
+	}
+	return tour, err
+}
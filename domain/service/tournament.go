@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+)
+
+const (
+	ModePairwise          = "pairwise"
+	ModeSingleElimination = "single-elimination"
+	ModeSwiss             = "swiss"
+
+	playAttempts = 16
+)
+
+func tournament(mode string) bool {
+	return mode == ModeSingleElimination || mode == ModeSwiss
+}
+
+func (s *Service) newTournament(mode string, images []uint64) model.Tournament {
+	seeds := slices.Clone(images)
+	s.rand.shuffle(len(seeds), func(i, j int) { seeds[i], seeds[j] = seeds[j], seeds[i] })
+	tour := model.Tournament{}
+	tour.Mode = mode
+	tour.Rounds = rounds(len(seeds))
+	tour.Seeds = seeds
+	if mode == ModeSwiss {
+		tour.Scores = make(map[uint64]float64, len(seeds))
+		for _, id := range seeds {
+			tour.Scores[id] = 0
+		}
+	}
+	nextRound(&tour)
+	return tour
+}
+
+func (s *Service) Bracket(ctx context.Context, album uint64, cred model.Credentials) (model.Tournament, []model.Image, error) {
+	err := s.authorize(ctx, album, cred)
+	if err != nil {
+		return model.Tournament{}, nil, errors.Wrap(err)
+	}
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return model.Tournament{}, nil, errors.Wrap(err)
+	}
+	if !tournament(tour.Mode) {
+		return model.Tournament{}, nil, errors.Wrap(domain.ErrTournamentNotFound)
+	}
+	imgs, err := s.pers.GetImagesOrdered(ctx, album, model.Page{})
+	if err != nil {
+		return model.Tournament{}, nil, errors.Wrap(err)
+	}
+	if tour.Mode == ModeSwiss {
+		pos := map[uint64]int{}
+		for i, id := range standings(tour) {
+			pos[id] = i
+		}
+		slices.SortStableFunc(imgs, func(a, b model.Image) bool { return pos[a.Id] < pos[b.Id] })
+	}
+	return tour, imgs, nil
+}
+
+func (s *Service) match(ctx context.Context, album uint64, voter uint64, tour model.Tournament) (uint64, uint64, error) {
+	if tour.Champion != 0x0 {
+		return 0x0, 0x0, errors.Wrap(domain.ErrTournamentFinished)
+	}
+	open := [][2]uint64(nil)
+	for _, m := range tour.Matches {
+		if m.Round == tour.Round && !m.Done {
+			open = append(open, [2]uint64{m.Image1, m.Image2})
+		}
+	}
+	if len(open) == 0 {
+		return 0x0, 0x0, errors.Wrap(domain.ErrPairNotFound)
+	}
+	if voter != 0x0 {
+		pairs, err := s.hist.GetSeen(ctx, voter, album)
+		if err != nil {
+			return 0x0, 0x0, errors.Wrap(err)
+		}
+		seen := make(map[[2]uint64]struct{}, len(pairs))
+		for _, pair := range pairs {
+			seen[key(pair[0], pair[1])] = struct{}{}
+		}
+		unseen := [][2]uint64(nil)
+		for _, pair := range open {
+			_, ok := seen[key(pair[0], pair[1])]
+			if !ok {
+				unseen = append(unseen, pair)
+			}
+		}
+		// a voter who has seen every match of the round may vote again
+		if len(unseen) > 0 {
+			open = unseen
+		}
+	}
+	// the match is marked as seen by the vote, like any other pair
+	pair := open[s.rand.intn(len(open))]
+	s.rand.shuffle(2, func(i, j int) { pair[i], pair[j] = pair[j], pair[i] })
+	return pair[0], pair[1], nil
+}
+
+// play decides the match, the tournament is read again and the vote is
+// replayed if another vote has saved the tournament in the meantime
+func (s *Service) play(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, outcome string) error {
+	err := error(nil)
+	for i := 0; i < playAttempts; i++ {
+		err = s.playOnce(ctx, album, imageFrom, imageTo, outcome)
+		if !errors.Is(err, domain.ErrTournamentConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *Service) playOnce(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, outcome string) error {
+	tour, err := s.pers.GetTournament(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if !tournament(tour.Mode) || tour.Champion != 0x0 {
+		return nil
+	}
+	index := -1
+	for i, m := range tour.Matches {
+		if m.Round == tour.Round && !m.Done && key(m.Image1, m.Image2) == key(imageFrom, imageTo) {
+			index = i
+			break
+		}
+	}
+	// the match has already been decided by another voter
+	if index == -1 {
+		return nil
+	}
+	m := &tour.Matches[index]
+	switch {
+	case outcome == OutcomeSkip:
+		return nil
+	case outcome == OutcomeTie && tour.Mode == ModeSingleElimination:
+		// there are no draws in a knockout, the match is played again
+		return nil
+	case outcome == OutcomeTie:
+		m.Done = true
+		tour.Scores[imageFrom] += 0.5
+		tour.Scores[imageTo] += 0.5
+	default:
+		m.Done = true
+		m.Winner = imageTo
+		if tour.Mode == ModeSwiss {
+			tour.Scores[imageTo]++
+		}
+	}
+	nextRound(&tour)
+	err = s.pers.SaveTournament(ctx, album, tour)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// nextRound starts a new round once every match of the current one is
+// decided, or crowns the champion after the last round
+func nextRound(tour *model.Tournament) {
+	for _, m := range tour.Matches {
+		if m.Round == tour.Round && !m.Done {
+			return
+		}
+	}
+	switch tour.Mode {
+	case ModeSingleElimination:
+		players := tour.Seeds
+		if tour.Round > 0 {
+			players = nil
+			for _, m := range tour.Matches {
+				if m.Round == tour.Round {
+					players = append(players, m.Winner)
+				}
+			}
+		}
+		if len(players) <= 1 {
+			if len(players) == 1 {
+				tour.Champion = players[0]
+			}
+			return
+		}
+		tour.Round++
+		for i := 0; i+1 < len(players); i += 2 {
+			tour.Matches = append(tour.Matches, model.Match{Round: tour.Round, Image1: players[i], Image2: players[i+1]})
+		}
+		if len(players)%2 == 1 {
+			bye := players[len(players)-1]
+			tour.Matches = append(tour.Matches, model.Match{Round: tour.Round, Image1: bye, Winner: bye, Done: true})
+		}
+	case ModeSwiss:
+		order := standings(*tour)
+		if tour.Round >= tour.Rounds {
+			if len(order) > 0 {
+				tour.Champion = order[0]
+			}
+			return
+		}
+		tour.Round++
+		played := map[[2]uint64]struct{}{}
+		byes := map[uint64]struct{}{}
+		for _, m := range tour.Matches {
+			if m.Image2 == 0x0 {
+				byes[m.Image1] = struct{}{}
+				continue
+			}
+			played[key(m.Image1, m.Image2)] = struct{}{}
+		}
+		bye := uint64(0x0)
+		if len(order)%2 == 1 {
+			// the lowest placed image without a bye sits the round out
+			bye = order[len(order)-1]
+			for i := len(order) - 1; i >= 0; i-- {
+				_, ok := byes[order[i]]
+				if !ok {
+					bye = order[i]
+					break
+				}
+			}
+		}
+		paired := map[uint64]struct{}{bye: {}}
+		for i, image1 := range order {
+			_, ok := paired[image1]
+			if ok {
+				continue
+			}
+			image2 := uint64(0x0)
+			for _, id := range order[i+1:] {
+				_, ok := paired[id]
+				if ok {
+					continue
+				}
+				if image2 == 0x0 {
+					image2 = id
+				}
+				// a rematch is only allowed when there is no one else left
+				_, ok = played[key(image1, id)]
+				if !ok {
+					image2 = id
+					break
+				}
+			}
+			paired[image1] = struct{}{}
+			paired[image2] = struct{}{}
+			tour.Matches = append(tour.Matches, model.Match{Round: tour.Round, Image1: image1, Image2: image2})
+		}
+		if bye != 0x0 {
+			tour.Matches = append(tour.Matches, model.Match{Round: tour.Round, Image1: bye, Winner: bye, Done: true})
+			tour.Scores[bye]++
+		}
+	}
+}
+
+// standings returns the images of a swiss tournament from the highest score
+// to the lowest, images with the same score keep the order of the seeds
+func standings(tour model.Tournament) []uint64 {
+	order := slices.Clone(tour.Seeds)
+	slices.SortStableFunc(order, func(a, b uint64) bool { return tour.Scores[a] > tour.Scores[b] })
+	return order
+}
+
+// rounds - amount of rounds needed to single out a champion
+func rounds(n int) int {
+	r := 0
+	for 1<<r < n {
+		r++
+	}
+	return r
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zitryss/aye-and-nay/domain/model"
+)
+
+func TestRounds(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	tests := []struct {
+		give int
+		want int
+	}{
+		{give: 1, want: 0},
+		{give: 2, want: 1},
+		{give: 3, want: 2},
+		{give: 4, want: 2},
+		{give: 5, want: 3},
+		{give: 16, want: 4},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rounds(tt.give))
+	}
+}
+
+func TestNextRoundSingleElimination(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	tour := model.Tournament{Mode: ModeSingleElimination, Rounds: 3, Seeds: []uint64{0x1, 0x2, 0x3, 0x4, 0x5}}
+	nextRound(&tour)
+	assert.Equal(t, 1, tour.Round)
+	want := []model.Match{{Round: 1, Image1: 0x1, Image2: 0x2}, {Round: 1, Image1: 0x3, Image2: 0x4}, {Round: 1, Image1: 0x5, Winner: 0x5, Done: true}}
+	assert.Equal(t, want, tour.Matches)
+	tour.Matches[0].Winner, tour.Matches[0].Done = 0x2, true
+	nextRound(&tour)
+	assert.Equal(t, 1, tour.Round)
+	tour.Matches[1].Winner, tour.Matches[1].Done = 0x3, true
+	nextRound(&tour)
+	assert.Equal(t, 2, tour.Round)
+	want = append(append([]model.Match(nil), tour.Matches[:3]...), model.Match{Round: 2, Image1: 0x2, Image2: 0x3}, model.Match{Round: 2, Image1: 0x5, Winner: 0x5, Done: true})
+	assert.Equal(t, want, tour.Matches)
+	tour.Matches[3].Winner, tour.Matches[3].Done = 0x3, true
+	nextRound(&tour)
+	assert.Equal(t, 3, tour.Round)
+	assert.Equal(t, model.Match{Round: 3, Image1: 0x3, Image2: 0x5}, tour.Matches[5])
+	tour.Matches[5].Winner, tour.Matches[5].Done = 0x5, true
+	nextRound(&tour)
+	assert.Equal(t, uint64(0x5), tour.Champion)
+}
+
+func TestNextRoundSwiss(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	seeds := []uint64{0x1, 0x2, 0x3}
+	scores := map[uint64]float64{0x1: 0, 0x2: 0, 0x3: 0}
+	tour := model.Tournament{Mode: ModeSwiss, Rounds: 2, Seeds: seeds, Scores: scores}
+	nextRound(&tour)
+	want := []model.Match{{Round: 1, Image1: 0x1, Image2: 0x2}, {Round: 1, Image1: 0x3, Winner: 0x3, Done: true}}
+	assert.Equal(t, want, tour.Matches)
+	tour.Matches[0].Winner, tour.Matches[0].Done = 0x1, true
+	tour.Scores[0x1]++
+	nextRound(&tour)
+	assert.Equal(t, 2, tour.Round)
+	// 0x2 is the lowest placed image without a bye, 0x1 and 0x3 have not met yet
+	want = append(append([]model.Match(nil), tour.Matches[:2]...), model.Match{Round: 2, Image1: 0x1, Image2: 0x3}, model.Match{Round: 2, Image1: 0x2, Winner: 0x2, Done: true})
+	assert.Equal(t, want, tour.Matches)
+	tour.Matches[2].Done = true
+	tour.Scores[0x1] += 0.5
+	tour.Scores[0x3] += 0.5
+	nextRound(&tour)
+	assert.Equal(t, uint64(0x1), tour.Champion)
+	assert.Equal(t, map[uint64]float64{0x1: 1.5, 0x2: 1, 0x3: 1.5}, tour.Scores)
+}
@@ -2,12 +2,12 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/pkg/errors"
-	"github.com/zitryss/aye-and-nay/pkg/linalg"
 )
 
 func (s *Service) StartWorkingPoolCalc(ctx context.Context, g *errgroup.Group) {
@@ -51,21 +51,33 @@ func (s *Service) StartWorkingPoolCalc(ctx context.Context, g *errgroup.Group) {
 						return
 					default:
 					}
-					edgs, err := s.pers.GetEdges(ctx, album)
+					name, err := s.pers.GetRanking(ctx, album)
 					if err != nil {
 						err = errors.Wrap(err)
 						handleError(err)
 						e = err
 						continue
 					}
-					vect := linalg.PageRank(edgs, s.conf.Accuracy)
-					err = s.pers.UpdateRatings(ctx, album, vect)
+					r, err := s.ranker(name)
 					if err != nil {
 						err = errors.Wrap(err)
 						handleError(err)
 						e = err
 						continue
 					}
+					err = s.calc(ctx, album, r)
+					if err != nil {
+						err = errors.Wrap(err)
+						handleError(err)
+						e = err
+						continue
+					}
+					err = s.publishRatings(ctx, album)
+					if err != nil {
+						err = errors.Wrap(err)
+						handleError(err)
+						e = err
+					}
 					if s.heartbeat.calc != nil {
 						select {
 						case <-ctx.Done():
@@ -120,7 +132,7 @@ func (s *Service) StartWorkingPoolComp(ctx context.Context, g *errgroup.Group) {
 						return
 					default:
 					}
-					images, err := s.pers.GetImagesIds(ctx, album)
+					images, err := s.pers.GetImagesIdsUncompressed(ctx, album)
 					if err != nil {
 						err = errors.Wrap(err)
 						handleError(err)
@@ -165,6 +177,12 @@ func (s *Service) StartWorkingPoolComp(ctx context.Context, g *errgroup.Group) {
 							e = err
 							continue
 						}
+						err = s.publishProgress(ctx, album)
+						if err != nil {
+							err = errors.Wrap(err)
+							handleError(err)
+							e = err
+						}
 						if s.heartbeat.comp != nil {
 							p, _ := s.Progress(ctx, album)
 							select {
@@ -212,6 +230,49 @@ func (s *Service) StartWorkingPoolDel(ctx context.Context, g *errgroup.Group) {
 				return
 			default:
 			}
+			expires, err := s.pers.GetExpires(ctx, album)
+			if errors.Is(err, domain.ErrAlbumNotFound) {
+				// the album has been deleted by an earlier entry
+				continue
+			}
+			if err != nil {
+				err = errors.Wrap(err)
+				handleError(err)
+				e = err
+				continue
+			}
+			if expires.IsZero() {
+				continue
+			}
+			if time.Now().Before(expires) {
+				// the expiry has been extended after the entry was added
+				err = s.queue.del.add(ctx, album, expires)
+				if err != nil {
+					err = errors.Wrap(err)
+					handleError(err)
+					e = err
+				}
+				continue
+			}
+			policy, err := s.pers.GetResults(ctx, album)
+			if err != nil {
+				err = errors.Wrap(err)
+				handleError(err)
+				e = err
+				continue
+			}
+			// the results hidden until the album closes are shown for the
+			// grace period before the album is gone
+			grace := expires.Add(s.conf.ResultsGrace)
+			if policy == ResultsClosed && time.Now().Before(grace) {
+				err = s.queue.del.add(ctx, album, grace)
+				if err != nil {
+					err = errors.Wrap(err)
+					handleError(err)
+					e = err
+				}
+				continue
+			}
 			images, err := s.pers.GetImagesIds(ctx, album)
 			if err != nil {
 				err = errors.Wrap(err)
@@ -226,6 +287,13 @@ func (s *Service) StartWorkingPoolDel(ctx context.Context, g *errgroup.Group) {
 				e = err
 				continue
 			}
+			err = s.tally.DelTally(ctx, album)
+			if err != nil {
+				err = errors.Wrap(err)
+				handleError(err)
+				e = err
+				continue
+			}
 			for _, image := range images {
 				err = s.stor.Remove(ctx, album, image)
 				if err != nil {
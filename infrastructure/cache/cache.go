@@ -7,6 +7,10 @@ import (
 	"github.com/zitryss/aye-and-nay/internal/log"
 )
 
+const (
+	eventsBufferSize = 16
+)
+
 func New(ctx context.Context, conf CacheConfig) (domain.Cacher, error) {
 	switch conf.Cache {
 	case "redis":
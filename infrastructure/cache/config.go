@@ -14,7 +14,13 @@ type MemConfig struct {
 	CleanupInterval          time.Duration `mapstructure:"CACHE_MEM_CLEANUP_INTERVAL"             validate:"required"`
 	LimiterRequestsPerSecond float64       `mapstructure:"MIDDLEWARE_LIMITER_REQUESTS_PER_SECOND" validate:"required"`
 	LimiterBurst             int           `mapstructure:"MIDDLEWARE_LIMITER_BURST"               validate:"required"`
+	CodeLimiterPerMinute     int           `mapstructure:"MIDDLEWARE_CODE_LIMITER_PER_MINUTE"     validate:"required"`
+	CodeLimiterBurst         int           `mapstructure:"MIDDLEWARE_CODE_LIMITER_BURST"          validate:"required"`
+	CreateLimiterPerMinute   int           `mapstructure:"MIDDLEWARE_CREATE_LIMITER_PER_MINUTE"   validate:"required"`
+	CreateLimiterBurst       int           `mapstructure:"MIDDLEWARE_CREATE_LIMITER_BURST"        validate:"required"`
 	TimeToLive               time.Duration `mapstructure:"CACHE_REDIS_TIME_TO_LIVE"               validate:"required"`
+	SessionTimeToLive        time.Duration `mapstructure:"CACHE_SESSION_TIME_TO_LIVE"             validate:"required"`
+	PassTimeToLive           time.Duration `mapstructure:"CACHE_PASS_TIME_TO_LIVE"                validate:"required"`
 }
 
 type RedisConfig struct {
@@ -25,8 +31,14 @@ type RedisConfig struct {
 	Timeout                  time.Duration `mapstructure:"CACHE_REDIS_TIMEOUT"                    validate:"required"`
 	LimiterRequestsPerSecond int           `mapstructure:"MIDDLEWARE_LIMITER_REQUESTS_PER_SECOND" validate:"required"`
 	LimiterBurst             int64         `mapstructure:"MIDDLEWARE_LIMITER_BURST"               validate:"required"`
+	CodeLimiterPerMinute     int           `mapstructure:"MIDDLEWARE_CODE_LIMITER_PER_MINUTE"     validate:"required"`
+	CodeLimiterBurst         int           `mapstructure:"MIDDLEWARE_CODE_LIMITER_BURST"          validate:"required"`
+	CreateLimiterPerMinute   int           `mapstructure:"MIDDLEWARE_CREATE_LIMITER_PER_MINUTE"   validate:"required"`
+	CreateLimiterBurst       int           `mapstructure:"MIDDLEWARE_CREATE_LIMITER_BURST"        validate:"required"`
 	TimeToLive               time.Duration `mapstructure:"CACHE_REDIS_TIME_TO_LIVE"               validate:"required"`
 	TxRetries                int           `mapstructure:"CACHE_REDIS_TX_RETRIES"                 validate:"required"`
+	SessionTimeToLive        time.Duration `mapstructure:"CACHE_SESSION_TIME_TO_LIVE"             validate:"required"`
+	PassTimeToLive           time.Duration `mapstructure:"CACHE_PASS_TIME_TO_LIVE"                validate:"required"`
 }
 
 var (
@@ -34,7 +46,13 @@ var (
 		CleanupInterval:          0,
 		LimiterRequestsPerSecond: 30000,
 		LimiterBurst:             300,
+		CodeLimiterPerMinute:     1,
+		CodeLimiterBurst:         3,
+		CreateLimiterPerMinute:   1,
+		CreateLimiterBurst:       3,
 		TimeToLive:               0,
+		SessionTimeToLive:        0,
+		PassTimeToLive:           3 * time.Second,
 	}
 	DefaultRedisConfig = RedisConfig{
 		Host:                     "localhost",
@@ -44,7 +62,13 @@ var (
 		Timeout:                  30 * time.Second,
 		LimiterRequestsPerSecond: 1,
 		LimiterBurst:             1,
+		CodeLimiterPerMinute:     1,
+		CodeLimiterBurst:         3,
+		CreateLimiterPerMinute:   1,
+		CreateLimiterBurst:       3,
 		TimeToLive:               3 * time.Second,
 		TxRetries:                1,
+		SessionTimeToLive:        3 * time.Second,
+		PassTimeToLive:           3 * time.Second,
 	}
 )
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
 	"github.com/zitryss/aye-and-nay/pkg/errors"
 )
 
@@ -20,11 +21,15 @@ var (
 func NewMem(conf MemConfig, opts ...options) *Mem {
 	m := &Mem{
 		conf:         conf,
-		syncVisitors: syncVisitors{visitors: map[uint64]*visitorTime{}},
+		syncVisitors: syncVisitors{visitors: map[uint64]*visitorTime{}, codes: map[uint64]*visitorTime{}, creators: map[uint64]*visitorTime{}},
 		syncQueues:   syncQueues{queues: map[uint64]*linkedhashset.Set{}},
 		syncPQueues:  syncPQueues{pqueues: map[uint64]*binaryheap.Heap{}},
 		syncPairs:    syncPairs{pairs: map[uint64]*pairsTime{}},
 		syncTokens:   syncTokens{tokens: map[uint64]*tokenTime{}},
+		syncSeen:     syncSeen{seen: map[[2]uint64]*seenTime{}},
+		syncPasses:   syncPasses{passes: map[[2]uint64]time.Time{}},
+		syncSubs:     syncSubs{subs: map[uint64]map[chan model.Event]struct{}{}},
+		syncTallies:  syncTallies{tallies: map[uint64]*tallyTime{}},
 	}
 	for _, opt := range opts {
 		opt(m)
@@ -52,6 +57,12 @@ func WithHeartbeatToken(ch chan<- any) options {
 	}
 }
 
+func WithHeartbeatSeen(ch chan<- any) options {
+	return func(m *Mem) {
+		m.heartbeat.seen = ch
+	}
+}
+
 type Mem struct {
 	conf MemConfig
 	syncVisitors
@@ -59,16 +70,23 @@ type Mem struct {
 	syncPQueues
 	syncPairs
 	syncTokens
+	syncSeen
+	syncPasses
+	syncSubs
+	syncTallies
 	heartbeat struct {
 		cleanup chan<- any
 		pair    chan<- any
 		token   chan<- any
+		seen    chan<- any
 	}
 }
 
 type syncVisitors struct {
 	sync.Mutex
 	visitors map[uint64]*visitorTime
+	codes    map[uint64]*visitorTime
+	creators map[uint64]*visitorTime
 }
 
 type visitorTime struct {
@@ -107,6 +125,38 @@ type tokenTime struct {
 	seen  time.Time
 }
 
+type syncSeen struct {
+	sync.Mutex
+	seen map[[2]uint64]*seenTime
+}
+
+type seenTime struct {
+	pairs map[[2]uint64]struct{}
+	seen  time.Time
+}
+
+type syncPasses struct {
+	sync.Mutex
+	// passes - expiry of a pass for an album
+	passes map[[2]uint64]time.Time
+}
+
+type syncSubs struct {
+	sync.Mutex
+	subs map[uint64]map[chan model.Event]struct{}
+}
+
+type syncTallies struct {
+	sync.Mutex
+	tallies map[uint64]*tallyTime
+}
+
+type tallyTime struct {
+	votes []model.Vote
+	tally model.Tally
+	seen  time.Time
+}
+
 type elem struct {
 	album   uint64
 	expires time.Time
@@ -147,7 +197,24 @@ func (m *Mem) Monitor(ctx context.Context) {
 					delete(m.visitors, k)
 				}
 			}
+			for k, v := range m.codes {
+				if now.Sub(v.seen) >= m.conf.TimeToLive {
+					delete(m.codes, k)
+				}
+			}
+			for k, v := range m.creators {
+				if now.Sub(v.seen) >= m.conf.TimeToLive {
+					delete(m.creators, k)
+				}
+			}
 			m.syncVisitors.Unlock()
+			m.syncPasses.Lock()
+			for k, v := range m.passes {
+				if !now.Before(v) {
+					delete(m.passes, k)
+				}
+			}
+			m.syncPasses.Unlock()
 			time.Sleep(m.conf.CleanupInterval)
 			if m.heartbeat.cleanup != nil {
 				select {
@@ -180,6 +247,13 @@ func (m *Mem) Monitor(ctx context.Context) {
 				}
 			}
 			m.syncPairs.Unlock()
+			m.syncTallies.Lock()
+			for k, v := range m.tallies {
+				if now.Sub(v.seen) >= m.conf.TimeToLive {
+					delete(m.tallies, k)
+				}
+			}
+			m.syncTallies.Unlock()
 			time.Sleep(m.conf.CleanupInterval)
 			if m.heartbeat.pair != nil {
 				select {
@@ -222,6 +296,38 @@ func (m *Mem) Monitor(ctx context.Context) {
 			}
 		}
 	}()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if m.heartbeat.seen != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case m.heartbeat.seen <- struct{}{}:
+				}
+			}
+			now := time.Now()
+			m.syncSeen.Lock()
+			for k, v := range m.seen {
+				if now.Sub(v.seen) >= m.conf.SessionTimeToLive {
+					delete(m.seen, k)
+				}
+			}
+			m.syncSeen.Unlock()
+			time.Sleep(m.conf.CleanupInterval)
+			if m.heartbeat.seen != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case m.heartbeat.seen <- struct{}{}:
+				}
+			}
+		}
+	}()
 }
 
 func (m *Mem) Allow(_ context.Context, ip uint64) (bool, error) {
@@ -237,6 +343,44 @@ func (m *Mem) Allow(_ context.Context, ip uint64) (bool, error) {
 	return v.limiter.Allow(), nil
 }
 
+func (m *Mem) AllowCode(_ context.Context, album uint64) (bool, error) {
+	m.syncVisitors.Lock()
+	defer m.syncVisitors.Unlock()
+	v, ok := m.codes[album]
+	if !ok {
+		return true, nil
+	}
+	v.seen = time.Now()
+	return v.limiter.Tokens() >= 1, nil
+}
+
+func (m *Mem) FailCode(_ context.Context, album uint64) error {
+	m.syncVisitors.Lock()
+	defer m.syncVisitors.Unlock()
+	v, ok := m.codes[album]
+	if !ok {
+		l := rate.NewLimiter(rate.Every(time.Minute/time.Duration(m.conf.CodeLimiterPerMinute)), m.conf.CodeLimiterBurst)
+		v = &visitorTime{limiter: l}
+		m.codes[album] = v
+	}
+	v.seen = time.Now()
+	_ = v.limiter.Allow()
+	return nil
+}
+
+func (m *Mem) AllowCreate(_ context.Context, ip uint64) (bool, error) {
+	m.syncVisitors.Lock()
+	defer m.syncVisitors.Unlock()
+	v, ok := m.creators[ip]
+	if !ok {
+		l := rate.NewLimiter(rate.Every(time.Minute/time.Duration(m.conf.CreateLimiterPerMinute)), m.conf.CreateLimiterBurst)
+		v = &visitorTime{limiter: l}
+		m.creators[ip] = v
+	}
+	v.seen = time.Now()
+	return v.limiter.Allow(), nil
+}
+
 func (m *Mem) Add(_ context.Context, queue uint64, album uint64) error {
 	m.syncQueues.Lock()
 	defer m.syncQueues.Unlock()
@@ -345,6 +489,23 @@ func (m *Mem) Pop(_ context.Context, album uint64) (uint64, uint64, error) {
 	return images[0], images[1], nil
 }
 
+func (m *Mem) Drop(_ context.Context, album uint64, image uint64) error {
+	m.syncPairs.Lock()
+	defer m.syncPairs.Unlock()
+	p, ok := m.pairs[album]
+	if !ok {
+		return nil
+	}
+	pairs := make([][2]uint64, 0, len(p.pairs))
+	for _, images := range p.pairs {
+		if images[0] != image && images[1] != image {
+			pairs = append(pairs, images)
+		}
+	}
+	p.pairs = pairs
+	return nil
+}
+
 func (m *Mem) Set(_ context.Context, token uint64, album uint64, image uint64) error {
 	m.syncTokens.Lock()
 	defer m.syncTokens.Unlock()
@@ -377,6 +538,156 @@ func (m *Mem) Del(_ context.Context, token uint64) error {
 	return nil
 }
 
+func (m *Mem) DelImage(_ context.Context, album uint64, image uint64) error {
+	m.syncTokens.Lock()
+	defer m.syncTokens.Unlock()
+	for token, t := range m.tokens {
+		if t.album == album && t.image == image {
+			delete(m.tokens, token)
+		}
+	}
+	return nil
+}
+
+func (m *Mem) AddSeen(_ context.Context, voter uint64, album uint64, image1 uint64, image2 uint64) error {
+	m.syncSeen.Lock()
+	defer m.syncSeen.Unlock()
+	key := [2]uint64{voter, album}
+	s, ok := m.seen[key]
+	if !ok {
+		s = &seenTime{pairs: map[[2]uint64]struct{}{}}
+		m.seen[key] = s
+	}
+	if image1 > image2 {
+		image1, image2 = image2, image1
+	}
+	s.pairs[[2]uint64{image1, image2}] = struct{}{}
+	s.seen = time.Now()
+	return nil
+}
+
+func (m *Mem) GetSeen(_ context.Context, voter uint64, album uint64) ([][2]uint64, error) {
+	m.syncSeen.Lock()
+	defer m.syncSeen.Unlock()
+	s, ok := m.seen[[2]uint64{voter, album}]
+	if !ok {
+		return nil, nil
+	}
+	pairs := make([][2]uint64, 0, len(s.pairs))
+	for pair := range s.pairs {
+		pairs = append(pairs, pair)
+	}
+	s.seen = time.Now()
+	return pairs, nil
+}
+
+func (m *Mem) DelSeen(_ context.Context, voter uint64, album uint64) error {
+	m.syncSeen.Lock()
+	defer m.syncSeen.Unlock()
+	delete(m.seen, [2]uint64{voter, album})
+	return nil
+}
+
+func (m *Mem) AddPass(_ context.Context, pass uint64, album uint64) error {
+	m.syncPasses.Lock()
+	defer m.syncPasses.Unlock()
+	m.passes[[2]uint64{pass, album}] = time.Now().Add(m.conf.PassTimeToLive)
+	return nil
+}
+
+func (m *Mem) HasPass(_ context.Context, pass uint64, album uint64) (bool, error) {
+	m.syncPasses.Lock()
+	defer m.syncPasses.Unlock()
+	expires, ok := m.passes[[2]uint64{pass, album}]
+	if !ok {
+		return false, nil
+	}
+	return time.Now().Before(expires), nil
+}
+
+func (m *Mem) Publish(_ context.Context, album uint64, event model.Event) error {
+	m.syncSubs.Lock()
+	defer m.syncSubs.Unlock()
+	for ch := range m.subs[album] {
+		// a subscriber that falls behind misses the event rather than
+		// blocking the publisher
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *Mem) Subscribe(ctx context.Context, album uint64) (<-chan model.Event, error) {
+	ch := make(chan model.Event, eventsBufferSize)
+	m.syncSubs.Lock()
+	subs, ok := m.subs[album]
+	if !ok {
+		subs = map[chan model.Event]struct{}{}
+		m.subs[album] = subs
+	}
+	subs[ch] = struct{}{}
+	m.syncSubs.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.syncSubs.Lock()
+		defer m.syncSubs.Unlock()
+		delete(m.subs[album], ch)
+		if len(m.subs[album]) == 0 {
+			delete(m.subs, album)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func (m *Mem) AddVote(_ context.Context, album uint64, vote model.Vote) error {
+	m.syncTallies.Lock()
+	defer m.syncTallies.Unlock()
+	t, ok := m.tallies[album]
+	if !ok {
+		t = &tallyTime{}
+		m.tallies[album] = t
+	}
+	t.votes = append(t.votes, vote)
+	t.seen = time.Now()
+	return nil
+}
+
+func (m *Mem) TakeVotes(_ context.Context, album uint64) ([]model.Vote, model.Tally, error) {
+	m.syncTallies.Lock()
+	defer m.syncTallies.Unlock()
+	t, ok := m.tallies[album]
+	if !ok {
+		return nil, model.Tally{}, nil
+	}
+	votes := t.votes
+	t.votes = nil
+	t.seen = time.Now()
+	return votes, t.tally, nil
+}
+
+func (m *Mem) SaveTally(_ context.Context, album uint64, tally model.Tally) error {
+	m.syncTallies.Lock()
+	defer m.syncTallies.Unlock()
+	t, ok := m.tallies[album]
+	if !ok {
+		t = &tallyTime{}
+		m.tallies[album] = t
+	}
+	t.tally = tally
+	t.seen = time.Now()
+	return nil
+}
+
+func (m *Mem) DelTally(_ context.Context, album uint64) error {
+	m.syncTallies.Lock()
+	defer m.syncTallies.Unlock()
+	delete(m.tallies, album)
+	return nil
+}
+
 func (m *Mem) Health(_ context.Context) (bool, error) {
 	return true, nil
 }
@@ -385,6 +696,8 @@ func (m *Mem) Reset() error {
 	m.syncVisitors.Lock()
 	defer m.syncVisitors.Unlock()
 	m.visitors = map[uint64]*visitorTime{}
+	m.codes = map[uint64]*visitorTime{}
+	m.creators = map[uint64]*visitorTime{}
 	m.syncQueues.Lock()
 	defer m.syncQueues.Unlock()
 	m.queues = map[uint64]*linkedhashset.Set{}
@@ -397,5 +710,17 @@ func (m *Mem) Reset() error {
 	m.syncTokens.Lock()
 	defer m.syncTokens.Unlock()
 	m.tokens = map[uint64]*tokenTime{}
+	m.syncSeen.Lock()
+	defer m.syncSeen.Unlock()
+	m.seen = map[[2]uint64]*seenTime{}
+	m.syncPasses.Lock()
+	defer m.syncPasses.Unlock()
+	m.passes = map[[2]uint64]time.Time{}
+	m.syncSubs.Lock()
+	defer m.syncSubs.Unlock()
+	m.subs = map[uint64]map[chan model.Event]struct{}{}
+	m.syncTallies.Lock()
+	defer m.syncTallies.Unlock()
+	m.tallies = map[uint64]*tallyTime{}
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
 	. "github.com/zitryss/aye-and-nay/internal/generator"
 	. "github.com/zitryss/aye-and-nay/internal/testing"
 )
@@ -26,6 +27,7 @@ type MemTestSuite struct {
 	heartbeatCleanup chan any
 	heartbeatPair    chan any
 	heartbeatToken   chan any
+	heartbeatSeen    chan any
 	cache            domain.Cacher
 	setupTestFn      func()
 }
@@ -36,10 +38,12 @@ func (suite *MemTestSuite) SetupSuite() {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	conf := DefaultMemConfig
+	conf.PassTimeToLive = 100 * time.Millisecond
 	hc := make(chan any)
 	hp := make(chan any)
 	ht := make(chan any)
-	mem := NewMem(conf, WithHeartbeatCleanup(hc), WithHeartbeatPair(hp), WithHeartbeatToken(ht))
+	hs := make(chan any)
+	mem := NewMem(conf, WithHeartbeatCleanup(hc), WithHeartbeatPair(hp), WithHeartbeatToken(ht), WithHeartbeatSeen(hs))
 	mem.Monitor(ctx)
 	suite.ctx = ctx
 	suite.cancel = cancel
@@ -47,6 +51,7 @@ func (suite *MemTestSuite) SetupSuite() {
 	suite.heartbeatCleanup = hc
 	suite.heartbeatPair = hp
 	suite.heartbeatToken = ht
+	suite.heartbeatSeen = hs
 	suite.cache = mem
 	suite.setupTestFn = suite.SetupTest
 }
@@ -66,6 +71,83 @@ func (suite *MemTestSuite) TearDownSuite() {
 	suite.cancel()
 }
 
+func (suite *MemTestSuite) TestAllowCode() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album1 := id()
+		album2 := id()
+		for i := 0; i < suite.conf.CodeLimiterBurst; i++ {
+			allowed, err := suite.cache.AllowCode(suite.ctx, album1)
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+			err = suite.cache.FailCode(suite.ctx, album1)
+			assert.NoError(t, err)
+		}
+		allowed, err := suite.cache.AllowCode(suite.ctx, album2)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		for i := 0; i < suite.conf.CodeLimiterBurst+1; i++ {
+			allowed, err := suite.cache.AllowCode(suite.ctx, album)
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+		}
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		for i := 0; i < suite.conf.CodeLimiterBurst; i++ {
+			allowed, err := suite.cache.AllowCode(suite.ctx, album)
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+			err = suite.cache.FailCode(suite.ctx, album)
+			assert.NoError(t, err)
+		}
+		allowed, err := suite.cache.AllowCode(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
+
+func (suite *MemTestSuite) TestAllowCreate() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		ip1 := id()
+		ip2 := id()
+		for i := 0; i < suite.conf.CreateLimiterBurst; i++ {
+			allowed, err := suite.cache.AllowCreate(suite.ctx, ip1)
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+		}
+		allowed, err := suite.cache.AllowCreate(suite.ctx, ip2)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+		allowed, err = suite.cache.Allow(suite.ctx, ip1)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		ip := id()
+		for i := 0; i < suite.conf.CreateLimiterBurst; i++ {
+			allowed, err := suite.cache.AllowCreate(suite.ctx, ip)
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+		}
+		allowed, err := suite.cache.AllowCreate(suite.ctx, ip)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
+
 func (suite *MemTestSuite) TestPair() {
 	suite.T().Run("Positive", func(t *testing.T) {
 		suite.setupTestFn()
@@ -79,6 +161,22 @@ func (suite *MemTestSuite) TestPair() {
 		assert.Equal(t, ids.Uint64(1), image1)
 		assert.Equal(t, ids.Uint64(2), image2)
 	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		album := id()
+		pairs := [][2]uint64{{id(), id()}, {ids.Uint64(2), id()}, {ids.Uint64(1), ids.Uint64(3)}}
+		err := suite.cache.Push(suite.ctx, album, pairs)
+		assert.NoError(t, err)
+		err = suite.cache.Drop(suite.ctx, album, ids.Uint64(1))
+		assert.NoError(t, err)
+		image1, image2, err := suite.cache.Pop(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Equal(t, ids.Uint64(2), image1)
+		assert.Equal(t, ids.Uint64(3), image2)
+		_, _, err = suite.cache.Pop(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrPairNotFound)
+	})
 	suite.T().Run("Negative1", func(t *testing.T) {
 		suite.setupTestFn()
 		id, _ := GenId()
@@ -131,6 +229,31 @@ func (suite *MemTestSuite) TestToken() {
 		assert.Equal(t, album1, album2)
 		assert.Equal(t, image1, image2)
 	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		token1 := id()
+		token2 := id()
+		token3 := id()
+		album := id()
+		image1 := id()
+		image2 := id()
+		err := suite.cache.Set(suite.ctx, token1, album, image1)
+		assert.NoError(t, err)
+		err = suite.cache.Set(suite.ctx, token2, album, image1)
+		assert.NoError(t, err)
+		err = suite.cache.Set(suite.ctx, token3, album, image2)
+		assert.NoError(t, err)
+		err = suite.cache.DelImage(suite.ctx, album, image1)
+		assert.NoError(t, err)
+		_, _, err = suite.cache.Get(suite.ctx, token1)
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
+		_, _, err = suite.cache.Get(suite.ctx, token2)
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
+		_, image, err := suite.cache.Get(suite.ctx, token3)
+		assert.NoError(t, err)
+		assert.Equal(t, image2, image)
+	})
 	suite.T().Run("Negative1", func(t *testing.T) {
 		suite.setupTestFn()
 		id, _ := GenId()
@@ -192,3 +315,184 @@ func (suite *MemTestSuite) TestToken() {
 		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
 	})
 }
+
+func (suite *MemTestSuite) TestPass() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		pass := id()
+		album1 := id()
+		album2 := id()
+		err := suite.cache.AddPass(suite.ctx, pass, album1)
+		assert.NoError(t, err)
+		ok, err := suite.cache.HasPass(suite.ctx, pass, album1)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = suite.cache.HasPass(suite.ctx, pass, album2)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = suite.cache.HasPass(suite.ctx, id(), album1)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		_, ok := suite.cache.(*Redis)
+		if testing.Short() && ok {
+			t.Skip("short flag is set")
+		}
+		id, _ := GenId()
+		pass := id()
+		album := id()
+		err := suite.cache.AddPass(suite.ctx, pass, album)
+		assert.NoError(t, err)
+		time.Sleep(suite.conf.PassTimeToLive * 2)
+		ok, err = suite.cache.HasPass(suite.ctx, pass, album)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func (suite *MemTestSuite) TestSeen() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		voter := id()
+		album := id()
+		image1 := id()
+		image2 := id()
+		image3 := id()
+		err := suite.cache.AddSeen(suite.ctx, voter, album, image1, image2)
+		assert.NoError(t, err)
+		err = suite.cache.AddSeen(suite.ctx, voter, album, image2, image1)
+		assert.NoError(t, err)
+		err = suite.cache.AddSeen(suite.ctx, voter, album, image3, image1)
+		assert.NoError(t, err)
+		pairs, err := suite.cache.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, [][2]uint64{{image1, image2}, {image1, image3}}, pairs)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		voter1 := id()
+		voter2 := id()
+		album := id()
+		err := suite.cache.AddSeen(suite.ctx, voter1, album, id(), id())
+		assert.NoError(t, err)
+		pairs, err := suite.cache.GetSeen(suite.ctx, voter2, album)
+		assert.NoError(t, err)
+		assert.Empty(t, pairs)
+		err = suite.cache.DelSeen(suite.ctx, voter1, album)
+		assert.NoError(t, err)
+		pairs, err = suite.cache.GetSeen(suite.ctx, voter1, album)
+		assert.NoError(t, err)
+		assert.Empty(t, pairs)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		_, ok := suite.cache.(*Redis)
+		if testing.Short() && ok {
+			t.Skip("short flag is set")
+		}
+		id, _ := GenId()
+		voter := id()
+		album := id()
+		err := suite.cache.AddSeen(suite.ctx, voter, album, id(), id())
+		assert.NoError(t, err)
+		time.Sleep(suite.conf.SessionTimeToLive * 2)
+		AssertChannel(t, suite.heartbeatSeen)
+		AssertChannel(t, suite.heartbeatSeen)
+		pairs, err := suite.cache.GetSeen(suite.ctx, voter, album)
+		assert.NoError(t, err)
+		assert.Empty(t, pairs)
+	})
+}
+
+func (suite *MemTestSuite) TestTally() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		image1 := id()
+		image2 := id()
+		votes, tally, err := suite.cache.TakeVotes(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Empty(t, votes)
+		assert.Equal(t, model.Tally{}, tally)
+		err = suite.cache.AddVote(suite.ctx, album, model.Vote{From: image1, To: image2, Weight: 1})
+		assert.NoError(t, err)
+		err = suite.cache.AddVote(suite.ctx, album, model.Vote{From: image2, To: image1, Weight: 0.5})
+		assert.NoError(t, err)
+		full := time.Unix(0, time.Now().UnixNano())
+		err = suite.cache.SaveTally(suite.ctx, album, model.Tally{Since: 2, Full: full, Bootstrap: full})
+		assert.NoError(t, err)
+		votes, tally, err = suite.cache.TakeVotes(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Vote{{From: image1, To: image2, Weight: 1}, {From: image2, To: image1, Weight: 0.5}}, votes)
+		assert.Equal(t, 2, tally.Since)
+		assert.True(t, full.Equal(tally.Full))
+		assert.True(t, full.Equal(tally.Bootstrap))
+		votes, _, err = suite.cache.TakeVotes(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Empty(t, votes)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		err := suite.cache.AddVote(suite.ctx, album, model.Vote{From: id(), To: id(), Weight: 1})
+		assert.NoError(t, err)
+		err = suite.cache.SaveTally(suite.ctx, album, model.Tally{Since: 1, Full: time.Now()})
+		assert.NoError(t, err)
+		err = suite.cache.DelTally(suite.ctx, album)
+		assert.NoError(t, err)
+		votes, tally, err := suite.cache.TakeVotes(suite.ctx, album)
+		assert.NoError(t, err)
+		assert.Empty(t, votes)
+		assert.Equal(t, model.Tally{}, tally)
+	})
+}
+
+func (suite *MemTestSuite) TestEvents() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album1 := id()
+		album2 := id()
+		ctx, cancel := context.WithCancel(suite.ctx)
+		ch1, err := suite.cache.Subscribe(ctx, album1)
+		assert.NoError(t, err)
+		ch2, err := suite.cache.Subscribe(ctx, album1)
+		assert.NoError(t, err)
+		ch3, err := suite.cache.Subscribe(ctx, album2)
+		assert.NoError(t, err)
+		event := model.Event{Type: "ratings", Votes: 1, Images: []model.Image{{Id: id(), Rating: 0.5, Comparisons: 1}}}
+		err = suite.cache.Publish(suite.ctx, album1, event)
+		assert.NoError(t, err)
+		for _, ch := range []<-chan model.Event{ch1, ch2} {
+			select {
+			case v := <-ch:
+				assert.Equal(t, event, v)
+			case <-time.After(1 * time.Second):
+				t.Error("<-time.After(1 * time.Second)")
+			}
+		}
+		select {
+		case <-ch3:
+			t.Error("<-ch3")
+		case <-time.After(100 * time.Millisecond):
+		}
+		cancel()
+		for _, ch := range []<-chan model.Event{ch1, ch2, ch3} {
+			_, ok := <-ch
+			assert.False(t, ok)
+		}
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		err := suite.cache.Publish(suite.ctx, id(), model.Event{Type: "progress", Progress: 1})
+		assert.NoError(t, err)
+	})
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/go-redis/redis_rate/v9"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
 	"github.com/zitryss/aye-and-nay/pkg/base64"
 	"github.com/zitryss/aye-and-nay/pkg/errors"
 	"github.com/zitryss/aye-and-nay/pkg/retry"
@@ -37,14 +40,18 @@ func NewRedis(ctx context.Context, conf RedisConfig) (*Redis, error) {
 	}
 	r.limiter = redis_rate.NewLimiter(client)
 	r.limit = redis_rate.PerSecond(conf.LimiterRequestsPerSecond)
+	r.codeLimit = redis_rate.Limit{Rate: conf.CodeLimiterPerMinute, Burst: conf.CodeLimiterBurst, Period: time.Minute}
+	r.createLimit = redis_rate.Limit{Rate: conf.CreateLimiterPerMinute, Burst: conf.CreateLimiterBurst, Period: time.Minute}
 	return r, nil
 }
 
 type Redis struct {
-	conf    RedisConfig
-	client  *redisdb.Client
-	limiter *redis_rate.Limiter
-	limit   redis_rate.Limit
+	conf        RedisConfig
+	client      *redisdb.Client
+	limiter     *redis_rate.Limiter
+	limit       redis_rate.Limit
+	codeLimit   redis_rate.Limit
+	createLimit redis_rate.Limit
 }
 
 func (r *Redis) Allow(ctx context.Context, ip uint64) (bool, error) {
@@ -57,6 +64,38 @@ func (r *Redis) Allow(ctx context.Context, ip uint64) (bool, error) {
 	return res.Allowed > 0, nil
 }
 
+// AllowCode only looks at the limit, an attempt of no cost takes nothing
+// from it
+func (r *Redis) AllowCode(ctx context.Context, album uint64) (bool, error) {
+	albumB64 := base64.FromUint64(album)
+	key := "album:" + albumB64 + ":code"
+	res, err := r.limiter.AllowN(ctx, key, r.codeLimit, 0)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return res.Remaining > 0, nil
+}
+
+func (r *Redis) FailCode(ctx context.Context, album uint64) error {
+	albumB64 := base64.FromUint64(album)
+	key := "album:" + albumB64 + ":code"
+	_, err := r.limiter.Allow(ctx, key, r.codeLimit)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) AllowCreate(ctx context.Context, ip uint64) (bool, error) {
+	ipB64 := base64.FromUint64(ip)
+	key := "ip:" + ipB64 + ":create"
+	res, err := r.limiter.Allow(ctx, key, r.createLimit)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return res.Allowed > 0, nil
+}
+
 func (r *Redis) Add(ctx context.Context, queue uint64, album uint64) error {
 	queueB64 := base64.FromUint64(queue)
 	key1 := "queue:" + queueB64 + ":set"
@@ -244,6 +283,28 @@ func (r *Redis) Pop(ctx context.Context, album uint64) (uint64, uint64, error) {
 	return image0, image1, nil
 }
 
+func (r *Redis) Drop(ctx context.Context, album uint64, image uint64) error {
+	albumB64 := base64.FromUint64(album)
+	imageB64 := base64.FromUint64(image)
+	key := "album:" + albumB64 + ":pairs"
+	vals, err := r.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	pipe := r.client.Pipeline()
+	for _, val := range vals {
+		image1B64, image2B64, _ := strings.Cut(val, ":")
+		if image1B64 == imageB64 || image2B64 == imageB64 {
+			pipe.LRem(ctx, key, 0, val)
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 func (r *Redis) Set(ctx context.Context, token uint64, album uint64, image uint64) error {
 	tokenB64 := base64.FromUint64(token)
 	albumB64 := base64.FromUint64(album)
@@ -256,7 +317,13 @@ func (r *Redis) Set(ctx context.Context, token uint64, album uint64, image uint6
 	if n == 1 {
 		return errors.Wrap(domain.ErrTokenAlreadyExists)
 	}
-	err = r.client.Set(ctx, key, albumB64+":"+imageB64, r.conf.TimeToLive).Err()
+	// tokens of an image are indexed so they can be revoked together
+	index := "album:" + albumB64 + ":image:" + imageB64 + ":tokens"
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, key, albumB64+":"+imageB64, r.conf.TimeToLive)
+	pipe.SAdd(ctx, index, tokenB64)
+	pipe.Expire(ctx, index, r.conf.TimeToLive)
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		return errors.Wrap(err)
 	}
@@ -298,6 +365,265 @@ func (r *Redis) Del(ctx context.Context, token uint64) error {
 	return nil
 }
 
+func (r *Redis) DelImage(ctx context.Context, album uint64, image uint64) error {
+	albumB64 := base64.FromUint64(album)
+	imageB64 := base64.FromUint64(image)
+	index := "album:" + albumB64 + ":image:" + imageB64 + ":tokens"
+	tokens, err := r.client.SMembers(ctx, index).Result()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	pipe := r.client.Pipeline()
+	for _, tokenB64 := range tokens {
+		pipe.Del(ctx, "token:"+tokenB64)
+	}
+	pipe.Del(ctx, index)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) AddSeen(ctx context.Context, voter uint64, album uint64, image1 uint64, image2 uint64) error {
+	voterB64 := base64.FromUint64(voter)
+	albumB64 := base64.FromUint64(album)
+	if image1 > image2 {
+		image1, image2 = image2, image1
+	}
+	image1B64 := base64.FromUint64(image1)
+	image2B64 := base64.FromUint64(image2)
+	key := "voter:" + voterB64 + ":album:" + albumB64 + ":seen"
+	pipe := r.client.Pipeline()
+	pipe.SAdd(ctx, key, image1B64+":"+image2B64)
+	pipe.Expire(ctx, key, r.conf.SessionTimeToLive)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) GetSeen(ctx context.Context, voter uint64, album uint64) ([][2]uint64, error) {
+	voterB64 := base64.FromUint64(voter)
+	albumB64 := base64.FromUint64(album)
+	key := "voter:" + voterB64 + ":album:" + albumB64 + ":seen"
+	vals, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	_ = r.client.Expire(ctx, key, r.conf.SessionTimeToLive)
+	pairs := make([][2]uint64, 0, len(vals))
+	for _, val := range vals {
+		image1B64, image2B64, found := strings.Cut(val, ":")
+		if !found {
+			return nil, errors.Wrap(domain.ErrUnknown)
+		}
+		image1, err := base64.ToUint64(image1B64)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		image2, err := base64.ToUint64(image2B64)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		pairs = append(pairs, [2]uint64{image1, image2})
+	}
+	return pairs, nil
+}
+
+func (r *Redis) DelSeen(ctx context.Context, voter uint64, album uint64) error {
+	voterB64 := base64.FromUint64(voter)
+	albumB64 := base64.FromUint64(album)
+	key := "voter:" + voterB64 + ":album:" + albumB64 + ":seen"
+	err := r.client.Del(ctx, key).Err()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) AddPass(ctx context.Context, pass uint64, album uint64) error {
+	passB64 := base64.FromUint64(pass)
+	albumB64 := base64.FromUint64(album)
+	key := "pass:" + passB64 + ":album:" + albumB64
+	err := r.client.Set(ctx, key, "", r.conf.PassTimeToLive).Err()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) HasPass(ctx context.Context, pass uint64, album uint64) (bool, error) {
+	passB64 := base64.FromUint64(pass)
+	albumB64 := base64.FromUint64(album)
+	key := "pass:" + passB64 + ":album:" + albumB64
+	n, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return n == 1, nil
+}
+
+func (r *Redis) Publish(ctx context.Context, album uint64, event model.Event) error {
+	albumB64 := base64.FromUint64(album)
+	key := "album:" + albumB64 + ":events"
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = r.client.Publish(ctx, key, b).Err()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) Subscribe(ctx context.Context, album uint64) (<-chan model.Event, error) {
+	albumB64 := base64.FromUint64(album)
+	key := "album:" + albumB64 + ":events"
+	ps := r.client.Subscribe(ctx, key)
+	// the subscription is set up lazily, the confirmation guarantees that
+	// the events published from now on are received
+	_, err := ps.Receive(ctx)
+	if err != nil {
+		_ = ps.Close()
+		return nil, errors.Wrap(err)
+	}
+	ch := make(chan model.Event, eventsBufferSize)
+	go func() {
+		defer close(ch)
+		defer func() { _ = ps.Close() }()
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				event := model.Event{}
+				err := json.Unmarshal([]byte(msg.Payload), &event)
+				if err != nil {
+					continue
+				}
+				select {
+				case ch <- event:
+				default:
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (r *Redis) AddVote(ctx context.Context, album uint64, vote model.Vote) error {
+	albumB64 := base64.FromUint64(album)
+	fromB64 := base64.FromUint64(vote.From)
+	toB64 := base64.FromUint64(vote.To)
+	weight := strconv.FormatFloat(vote.Weight, 'g', -1, 64)
+	key := "album:" + albumB64 + ":tally:votes"
+	pipe := r.client.Pipeline()
+	pipe.RPush(ctx, key, fromB64+":"+toB64+":"+weight)
+	pipe.Expire(ctx, key, r.conf.TimeToLive)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) TakeVotes(ctx context.Context, album uint64) ([]model.Vote, model.Tally, error) {
+	albumB64 := base64.FromUint64(album)
+	key1 := "album:" + albumB64 + ":tally:votes"
+	key2 := "album:" + albumB64 + ":tally"
+	pipe := r.client.TxPipeline()
+	vals := pipe.LRange(ctx, key1, 0, -1)
+	pipe.Del(ctx, key1)
+	fields := pipe.HGetAll(ctx, key2)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, model.Tally{}, errors.Wrap(err)
+	}
+	votes := make([]model.Vote, 0, len(vals.Val()))
+	for _, val := range vals.Val() {
+		parts := strings.Split(val, ":")
+		if len(parts) != 3 {
+			return nil, model.Tally{}, errors.Wrap(domain.ErrUnknown)
+		}
+		from, err := base64.ToUint64(parts[0])
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+		to, err := base64.ToUint64(parts[1])
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+		weight, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+		votes = append(votes, model.Vote{From: from, To: to, Weight: weight})
+	}
+	tally := model.Tally{}
+	since, ok := fields.Val()["since"]
+	if ok {
+		tally.Since, err = strconv.Atoi(since)
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+	}
+	full, ok := fields.Val()["full"]
+	if ok {
+		nsec, err := strconv.ParseInt(full, 10, 64)
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+		tally.Full = time.Unix(0, nsec)
+	}
+	bootstrap, ok := fields.Val()["bootstrap"]
+	if ok {
+		nsec, err := strconv.ParseInt(bootstrap, 10, 64)
+		if err != nil {
+			return nil, model.Tally{}, errors.Wrap(err)
+		}
+		tally.Bootstrap = time.Unix(0, nsec)
+	}
+	return votes, tally, nil
+}
+
+func (r *Redis) SaveTally(ctx context.Context, album uint64, tally model.Tally) error {
+	albumB64 := base64.FromUint64(album)
+	key := "album:" + albumB64 + ":tally"
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "since", tally.Since)
+	if !tally.Full.IsZero() {
+		pipe.HSet(ctx, key, "full", tally.Full.UnixNano())
+	}
+	if !tally.Bootstrap.IsZero() {
+		pipe.HSet(ctx, key, "bootstrap", tally.Bootstrap.UnixNano())
+	}
+	pipe.Expire(ctx, key, r.conf.TimeToLive)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Redis) DelTally(ctx context.Context, album uint64) error {
+	albumB64 := base64.FromUint64(album)
+	key1 := "album:" + albumB64 + ":tally:votes"
+	key2 := "album:" + albumB64 + ":tally"
+	err := r.client.Del(ctx, key1, key2).Err()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 func (r *Redis) Health(ctx context.Context) (bool, error) {
 	err := r.client.Ping(ctx).Err()
 	if err != nil {
@@ -37,6 +37,10 @@ func (suite *RedisTestSuite) SetupSuite() {
 	suite.base.cancel = cancel
 	suite.base.conf.LimiterRequestsPerSecond = float64(conf.LimiterRequestsPerSecond)
 	suite.base.conf.TimeToLive = conf.TimeToLive
+	suite.base.conf.SessionTimeToLive = conf.SessionTimeToLive
+	suite.base.conf.CodeLimiterBurst = conf.CodeLimiterBurst
+	suite.base.conf.CreateLimiterBurst = conf.CreateLimiterBurst
+	suite.base.conf.PassTimeToLive = conf.PassTimeToLive
 	suite.base.cache = redis
 	suite.base.setupTestFn = suite.SetupTest
 	suite.setupTestFn = suite.SetupTest
@@ -187,3 +191,27 @@ func (suite *RedisTestSuite) TestRedisPair() {
 func (suite *RedisTestSuite) TestRedisToken() {
 	suite.base.TestToken()
 }
+
+func (suite *RedisTestSuite) TestRedisAllowCode() {
+	suite.base.TestAllowCode()
+}
+
+func (suite *RedisTestSuite) TestRedisAllowCreate() {
+	suite.base.TestAllowCreate()
+}
+
+func (suite *RedisTestSuite) TestRedisPass() {
+	suite.base.TestPass()
+}
+
+func (suite *RedisTestSuite) TestRedisSeen() {
+	suite.base.TestSeen()
+}
+
+func (suite *RedisTestSuite) TestRedisTally() {
+	suite.base.TestTally()
+}
+
+func (suite *RedisTestSuite) TestRedisEvents() {
+	suite.base.TestEvents()
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/gob"
@@ -65,12 +66,12 @@ func (b *Badger) SaveAlbum(_ context.Context, alb model.Album) error {
 	if err == nil {
 		return errors.Wrap(domain.ErrAlbumAlreadyExists)
 	}
-	edgs := make(map[uint64]map[uint64]int, len(alb.Images))
+	edgs := make(map[uint64]map[uint64]float64, len(alb.Images))
 	albLru := make(albumLru, len(alb.Images))
 	for i := range alb.Images {
 		img := &alb.Images[i]
 		img.Compressed = b.conf.Compressed
-		edgs[img.Id] = make(map[uint64]int, len(alb.Images))
+		edgs[img.Id] = make(map[uint64]float64, len(alb.Images))
 		albLru[img.Id] = img.Src
 	}
 	alb.Edges = edgs
@@ -95,6 +96,9 @@ func (b *Badger) CountImagesCompressed(_ context.Context, album uint64) (int, er
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return 0, errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
 	n := 0
 	for _, img := range alb.Images {
 		if img.Compressed {
@@ -109,6 +113,9 @@ func (b *Badger) UpdateCompressionStatus(_ context.Context, album uint64, image
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	found := false
 	for i := range alb.Images {
 		img := &alb.Images[i]
@@ -140,6 +147,22 @@ func (b *Badger) GetImageSrc(_ context.Context, album uint64, image uint64) (str
 	return src, nil
 }
 
+func (b *Badger) GetImageCaption(_ context.Context, album uint64, image uint64) (string, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	for _, img := range alb.Images {
+		if img.Id == image {
+			return img.Caption, nil
+		}
+	}
+	return "", errors.Wrap(domain.ErrImageNotFound)
+}
+
 func (b *Badger) GetImagesIds(_ context.Context, album uint64) ([]uint64, error) {
 	albLru, err := b.lruGetOrAddAndGet(album)
 	if err != nil {
@@ -152,12 +175,173 @@ func (b *Badger) GetImagesIds(_ context.Context, album uint64) ([]uint64, error)
 	return images, nil
 }
 
-func (b *Badger) SaveVote(_ context.Context, album uint64, imageFrom uint64, imageTo uint64) error {
+func (b *Badger) GetImagesIdsUncompressed(_ context.Context, album uint64) ([]uint64, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	images := []uint64(nil)
+	for _, img := range alb.Images {
+		if !img.Compressed {
+			images = append(images, img.Id)
+		}
+	}
+	return images, nil
+}
+
+func (b *Badger) SaveImages(_ context.Context, album uint64, imgs []model.Image) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for _, img := range imgs {
+		img.Compressed = b.conf.Compressed
+		alb.Images = append(alb.Images, img)
+		alb.Edges[img.Id] = map[uint64]float64{}
+	}
+	alb.Version++
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	b.cache.Remove(album)
+	return nil
+}
+
+func (b *Badger) DeleteImage(_ context.Context, album uint64, image uint64) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	index := slices.IndexFunc(alb.Images, func(img model.Image) bool { return img.Id == image })
+	if index == -1 {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	alb.Images = slices.Delete(alb.Images, index, index+1)
+	delete(alb.Edges, image)
+	for from := range alb.Edges {
+		delete(alb.Edges[from], image)
+	}
+	alb.Version++
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	b.cache.Remove(album)
+	return nil
+}
+
+func (b *Badger) GetRanking(_ context.Context, album uint64) (string, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return alb.Ranking, nil
+}
+
+func (b *Badger) GetMetadata(_ context.Context, album uint64) (model.Metadata, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return model.Metadata{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return model.Metadata{}, errors.Wrap(err)
+	}
+	return model.Metadata{Title: alb.Title, Description: alb.Description}, nil
+}
+
+func (b *Badger) GetOwner(_ context.Context, album uint64) ([]byte, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return alb.Owner, nil
+}
+
+func (b *Badger) GetAccess(_ context.Context, album uint64) (string, []byte, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return "", nil, errors.Wrap(err)
+	}
+	return alb.Access, alb.Code, nil
+}
+
+func (b *Badger) GetResults(_ context.Context, album uint64) (string, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return alb.Results, nil
+}
+
+func (b *Badger) GetFrozen(_ context.Context, album uint64) (bool, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return alb.Frozen, nil
+}
+
+func (b *Badger) SaveFrozen(_ context.Context, album uint64, frozen bool) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	alb.Frozen = frozen
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *Badger) GetExpires(_ context.Context, album uint64) (time.Time, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return time.Time{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrap(err)
+	}
+	return alb.Expires, nil
+}
+
+func (b *Badger) SaveExpires(_ context.Context, album uint64, expires time.Time) error {
 	alb, err := b.get(album)
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
-	alb.Edges[imageFrom][imageTo]++
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	alb.Expires = expires
 	err = b.set(alb)
 	if err != nil {
 		return errors.Wrap(err)
@@ -165,11 +349,128 @@ func (b *Badger) SaveVote(_ context.Context, album uint64, imageFrom uint64, ima
 	return nil
 }
 
-func (b *Badger) GetEdges(_ context.Context, album uint64) (map[uint64]map[uint64]int, error) {
+func (b *Badger) GetTournament(_ context.Context, album uint64) (model.Tournament, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return model.Tournament{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return model.Tournament{}, errors.Wrap(err)
+	}
+	return alb.Tournament, nil
+}
+
+// SaveTournament reads and writes the album in one transaction, badger
+// refuses to commit it if the album has been written in the meantime
+func (b *Badger) SaveTournament(_ context.Context, album uint64, tour model.Tournament) error {
+	key := make([]byte, 8)
+	binary.LittleEndian.PutUint64(key, album)
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		alb, err := decodeAlbum(val)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if alb.Tournament.Version != tour.Version {
+			return errors.Wrap(domain.ErrTournamentConflict)
+		}
+		alb.Tournament = tour
+		alb.Tournament.Version++
+		buf := pool.GetBuffer()
+		defer pool.PutBuffer(buf)
+		err = gob.NewEncoder(buf).Encode(alb)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		err = txn.Set(key, buf.Bytes())
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		return nil
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if errors.Is(err, badger.ErrConflict) {
+		return errors.Wrap(domain.ErrTournamentConflict)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *Badger) SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, tie bool) error {
+	return b.SaveVotes(ctx, album, votes(imageFrom, imageTo, tie))
+}
+
+func (b *Badger) SaveVotes(_ context.Context, album uint64, votes []model.Vote) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// the image may have been removed since the pair was served
+	for _, v := range votes {
+		_, ok1 := alb.Edges[v.From]
+		_, ok2 := alb.Edges[v.To]
+		if !ok1 || !ok2 {
+			return errors.Wrap(domain.ErrImageNotFound)
+		}
+	}
+	for _, v := range votes {
+		alb.Edges[v.From][v.To] += v.Weight
+	}
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *Badger) SaveSkip(_ context.Context, album uint64, image1 uint64, image2 uint64) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, ok1 := alb.Edges[image1]
+	_, ok2 := alb.Edges[image2]
+	if !ok1 || !ok2 {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	for i := range alb.Images {
+		img := &alb.Images[i]
+		if img.Id == image1 || img.Id == image2 {
+			img.Skips++
+		}
+	}
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *Badger) GetEdges(_ context.Context, album uint64) (map[uint64]map[uint64]float64, error) {
 	alb, err := b.get(album)
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return nil, errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
 	return alb.Edges, nil
 }
 
@@ -178,6 +479,9 @@ func (b *Badger) UpdateRatings(_ context.Context, album uint64, vector map[uint6
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	for id, rating := range vector {
 		for i := range alb.Images {
 			img := &alb.Images[i]
@@ -187,6 +491,29 @@ func (b *Badger) UpdateRatings(_ context.Context, album uint64, vector map[uint6
 			}
 		}
 	}
+	alb.Version++
+	err = b.set(alb)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *Badger) UpdateConfidence(_ context.Context, album uint64, vectorLow map[uint64]float64, vectorHigh map[uint64]float64, comparisons map[uint64]int) error {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for i := range alb.Images {
+		img := &alb.Images[i]
+		img.RatingLow = vectorLow[img.Id]
+		img.RatingHigh = vectorHigh[img.Id]
+		img.Comparisons = comparisons[img.Id]
+	}
+	alb.Version++
 	err = b.set(alb)
 	if err != nil {
 		return errors.Wrap(err)
@@ -194,13 +521,26 @@ func (b *Badger) UpdateRatings(_ context.Context, album uint64, vector map[uint6
 	return nil
 }
 
-func (b *Badger) GetImagesOrdered(_ context.Context, album uint64) ([]model.Image, error) {
+func (b *Badger) GetImagesOrdered(_ context.Context, album uint64, page model.Page) ([]model.Image, error) {
 	alb, err := b.get(album)
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return nil, errors.Wrap(domain.ErrAlbumNotFound)
 	}
-	slices.SortFunc(alb.Images, func(a, b model.Image) bool { return a.Rating > b.Rating })
-	return alb.Images, nil
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return paginate(alb.Images, page), nil
+}
+
+func (b *Badger) GetVersion(_ context.Context, album uint64) (uint64, error) {
+	alb, err := b.get(album)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0x0, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	return alb.Version, nil
 }
 
 func (b *Badger) DeleteAlbum(_ context.Context, album uint64) error {
@@ -208,6 +548,9 @@ func (b *Badger) DeleteAlbum(_ context.Context, album uint64) error {
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	key := make([]byte, 8)
 	binary.LittleEndian.PutUint64(key, album)
 	err = b.db.Update(func(txn *badger.Txn) error {
@@ -267,14 +610,71 @@ func (b *Badger) get(album uint64) (model.Album, error) {
 	if err != nil {
 		return model.Album{}, errors.Wrap(err)
 	}
-	alb := model.Album{}
-	err = gob.NewDecoder(buf).Decode(&alb)
+	alb, err := decodeAlbum(buf.Bytes())
 	if err != nil {
 		return model.Album{}, errors.Wrap(err)
 	}
 	return alb, nil
 }
 
+// albumV0 - an album saved while the edges counted whole votes, it differs
+// from model.Album only in the type of the edges
+type albumV0 struct {
+	Id          uint64
+	Images      []model.Image
+	Edges       map[uint64]map[uint64]int
+	Expires     time.Time
+	Ranking     string
+	Tournament  model.Tournament
+	Title       string
+	Description string
+	Owner       []byte
+	Frozen      bool
+	Access      string
+	Code        []byte
+	Results     string
+	Version     uint64
+}
+
+// decodeAlbum reads the current layout of an album and falls back to
+// albumV0, gob refuses to turn the whole numbers into fractional ones
+func decodeAlbum(b []byte) (model.Album, error) {
+	alb := model.Album{}
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&alb)
+	if err == nil {
+		return alb, nil
+	}
+	albV0 := albumV0{}
+	errV0 := gob.NewDecoder(bytes.NewReader(b)).Decode(&albV0)
+	if errV0 != nil {
+		return model.Album{}, errors.Wrap(err)
+	}
+	edgs := make(map[uint64]map[uint64]float64, len(albV0.Edges))
+	for from, tos := range albV0.Edges {
+		edgs[from] = make(map[uint64]float64, len(tos))
+		for to, count := range tos {
+			edgs[from][to] = float64(count)
+		}
+	}
+	alb = model.Album{
+		Id:          albV0.Id,
+		Images:      albV0.Images,
+		Edges:       edgs,
+		Expires:     albV0.Expires,
+		Ranking:     albV0.Ranking,
+		Tournament:  albV0.Tournament,
+		Title:       albV0.Title,
+		Description: albV0.Description,
+		Owner:       albV0.Owner,
+		Frozen:      albV0.Frozen,
+		Access:      albV0.Access,
+		Code:        albV0.Code,
+		Results:     albV0.Results,
+		Version:     albV0.Version,
+	}
+	return alb, nil
+}
+
 func (b *Badger) set(alb model.Album) error {
 	key := make([]byte, 8)
 	binary.LittleEndian.PutUint64(key, alb.Id)
@@ -338,6 +738,9 @@ func (b *Badger) lruAdd(album uint64) error {
 	if errors.Is(err, badger.ErrKeyNotFound) {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	albLru := make(albumLru, len(alb.Images))
 	for _, img := range alb.Images {
 		albLru[img.Id] = img.Src
@@ -64,18 +64,50 @@ func (suite *BadgerTestSuite) TestBadgerImage() {
 	suite.base.TestImage()
 }
 
+func (suite *BadgerTestSuite) TestBadgerImages() {
+	suite.base.TestImages()
+}
+
 func (suite *BadgerTestSuite) TestBadgerVote() {
 	suite.base.TestVote()
 }
 
+func (suite *BadgerTestSuite) TestBadgerRanking() {
+	suite.base.TestRanking()
+}
+
+func (suite *BadgerTestSuite) TestBadgerMetadata() {
+	suite.base.TestMetadata()
+}
+
+func (suite *BadgerTestSuite) TestBadgerOwner() {
+	suite.base.TestOwner()
+}
+
+func (suite *BadgerTestSuite) TestBadgerTournament() {
+	suite.base.TestTournament()
+}
+
 func (suite *BadgerTestSuite) TestBadgerSort() {
 	suite.base.TestSort()
 }
 
+func (suite *BadgerTestSuite) TestBadgerPage() {
+	suite.base.TestPage()
+}
+
+func (suite *BadgerTestSuite) TestBadgerVersion() {
+	suite.base.TestVersion()
+}
+
 func (suite *BadgerTestSuite) TestBadgerRatings() {
 	suite.base.TestRatings()
 }
 
+func (suite *BadgerTestSuite) TestBadgerConfidence() {
+	suite.base.TestConfidence()
+}
+
 func (suite *BadgerTestSuite) TestBadgerDelete() {
 	suite.base.TestDelete()
 }
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zitryss/aye-and-nay/domain/model"
+)
+
+func TestDecodeAlbum(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		alb := model.Album{Id: 0x3A8F, Edges: map[uint64]map[uint64]float64{0x5C1D: {0x9E07: 1.5}, 0x9E07: {}}}
+		buf := bytes.Buffer{}
+		err := gob.NewEncoder(&buf).Encode(alb)
+		require.NoError(t, err)
+		got, err := decodeAlbum(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, alb, got)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		// the layout of an album before the edges became fractional
+		type image struct {
+			Id         uint64
+			Src        string
+			Token      uint64
+			Rating     float64
+			Compressed bool
+		}
+		type album struct {
+			Id      uint64
+			Images  []image
+			Edges   map[uint64]map[uint64]int
+			Expires time.Time
+		}
+		expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		imgs := []image{{0x5C1D, "/aye-and-nay/albums/jzoAAAAAAAA/images/HVwAAAAAAAA", 0x0, 0.75, true}, {0x9E07, "/aye-and-nay/albums/jzoAAAAAAAA/images/B54AAAAAAAA", 0x0, 0.25, true}}
+		edgs := map[uint64]map[uint64]int{0x5C1D: {}, 0x9E07: {0x5C1D: 2}}
+		buf := bytes.Buffer{}
+		err := gob.NewEncoder(&buf).Encode(album{0x3A8F, imgs, edgs, expires})
+		require.NoError(t, err)
+		err = gob.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(&model.Album{})
+		require.Error(t, err)
+		got, err := decodeAlbum(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0x3A8F), got.Id)
+		assert.Equal(t, expires, got.Expires)
+		require.Len(t, got.Images, 2)
+		assert.Equal(t, model.Image{Id: 0x5C1D, Src: "/aye-and-nay/albums/jzoAAAAAAAA/images/HVwAAAAAAAA", Rating: 0.75, Compressed: true}, got.Images[0])
+		assert.Equal(t, map[uint64]map[uint64]float64{0x5C1D: {}, 0x9E07: {0x5C1D: 2}}, got.Edges)
+	})
+	t.Run("Negative", func(t *testing.T) {
+		_, err := decodeAlbum([]byte{0x1, 0x2, 0x3})
+		assert.Error(t, err)
+	})
+}
@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
 	"github.com/zitryss/aye-and-nay/internal/log"
 )
 
@@ -26,3 +29,37 @@ func New(ctx context.Context, conf DatabaseConfig) (domain.Databaser, error) {
 		return NewMem(conf.Mem), nil
 	}
 }
+
+// paginate orders the images the way the leaderboard does and cuts out a
+// page, Mem and Badger keep an album in one piece and page it in memory
+func paginate(imgs []model.Image, page model.Page) []model.Image {
+	slices.SortFunc(imgs, func(a, b model.Image) bool {
+		if a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		return a.Id < b.Id
+	})
+	res := make([]model.Image, 0, len(imgs))
+	for _, img := range imgs {
+		if img.Comparisons < page.MinComparisons {
+			continue
+		}
+		after := page.After
+		if after != nil && (img.Rating > after.Rating || img.Rating == after.Rating && img.Id <= after.Id) {
+			continue
+		}
+		res = append(res, img)
+		if page.Limit > 0 && len(res) == page.Limit {
+			break
+		}
+	}
+	return res
+}
+
+// votes splits a tie into two votes of half the weight
+func votes(imageFrom uint64, imageTo uint64, tie bool) []model.Vote {
+	if tie {
+		return []model.Vote{{From: imageFrom, To: imageTo, Weight: 0.5}, {From: imageTo, To: imageFrom, Weight: 0.5}}
+	}
+	return []model.Vote{{From: imageFrom, To: imageTo, Weight: 1}}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"sync"
+	"time"
 
 	"golang.org/x/exp/slices"
 
@@ -39,13 +40,14 @@ func (m *Mem) SaveAlbum(_ context.Context, alb model.Album) error {
 	if ok {
 		return errors.Wrap(domain.ErrAlbumAlreadyExists)
 	}
-	edgs := make(map[uint64]map[uint64]int, len(alb.Images))
+	edgs := make(map[uint64]map[uint64]float64, len(alb.Images))
 	for i := range alb.Images {
 		img := &alb.Images[i]
 		img.Compressed = m.conf.Compressed
-		edgs[img.Id] = make(map[uint64]int, len(alb.Images))
+		edgs[img.Id] = make(map[uint64]float64, len(alb.Images))
 	}
 	alb.Edges = edgs
+	alb.Tournament = copyTournament(alb.Tournament)
 	m.albums[alb.Id] = alb
 	return nil
 }
@@ -121,6 +123,21 @@ func (m *Mem) GetImageSrc(_ context.Context, album uint64, image uint64) (string
 	return alb.Images[index].Src, nil
 }
 
+func (m *Mem) GetImageCaption(_ context.Context, album uint64, image uint64) (string, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	for _, img := range alb.Images {
+		if img.Id == image {
+			return img.Caption, nil
+		}
+	}
+	return "", errors.Wrap(domain.ErrImageNotFound)
+}
+
 func (m *Mem) GetImagesIds(_ context.Context, album uint64) ([]uint64, error) {
 	m.syncAlbums.Lock()
 	defer m.syncAlbums.Unlock()
@@ -135,27 +152,237 @@ func (m *Mem) GetImagesIds(_ context.Context, album uint64) ([]uint64, error) {
 	return images, nil
 }
 
-func (m *Mem) SaveVote(_ context.Context, album uint64, imageFrom uint64, imageTo uint64) error {
+func (m *Mem) GetImagesIdsUncompressed(_ context.Context, album uint64) ([]uint64, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	images := []uint64(nil)
+	for _, img := range alb.Images {
+		if !img.Compressed {
+			images = append(images, img.Id)
+		}
+	}
+	return images, nil
+}
+
+func (m *Mem) SaveImages(_ context.Context, album uint64, imgs []model.Image) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	alb.Images = slices.Clone(alb.Images)
+	for _, img := range imgs {
+		img.Compressed = m.conf.Compressed
+		alb.Images = append(alb.Images, img)
+		alb.Edges[img.Id] = map[uint64]float64{}
+	}
+	alb.Version++
+	m.albums[album] = alb
+	return nil
+}
+
+func (m *Mem) DeleteImage(_ context.Context, album uint64, image uint64) error {
 	m.syncAlbums.Lock()
 	defer m.syncAlbums.Unlock()
 	alb, ok := m.albums[album]
 	if !ok {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
-	alb.Edges[imageFrom][imageTo]++
+	index := slices.IndexFunc(alb.Images, func(img model.Image) bool { return img.Id == image })
+	if index == -1 {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	alb.Images = slices.Delete(slices.Clone(alb.Images), index, index+1)
+	delete(alb.Edges, image)
+	for from := range alb.Edges {
+		delete(alb.Edges[from], image)
+	}
+	alb.Version++
+	m.albums[album] = alb
 	return nil
 }
 
-func (m *Mem) GetEdges(_ context.Context, album uint64) (map[uint64]map[uint64]int, error) {
+func (m *Mem) GetRanking(_ context.Context, album uint64) (string, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Ranking, nil
+}
+
+func (m *Mem) GetMetadata(_ context.Context, album uint64) (model.Metadata, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return model.Metadata{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return model.Metadata{Title: alb.Title, Description: alb.Description}, nil
+}
+
+func (m *Mem) GetOwner(_ context.Context, album uint64) ([]byte, error) {
 	m.syncAlbums.Lock()
 	defer m.syncAlbums.Unlock()
 	alb, ok := m.albums[album]
 	if !ok {
 		return nil, errors.Wrap(domain.ErrAlbumNotFound)
 	}
-	edgs := make(map[uint64]map[uint64]int, len(alb.Edges))
+	return slices.Clone(alb.Owner), nil
+}
+
+func (m *Mem) GetAccess(_ context.Context, album uint64) (string, []byte, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return "", nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Access, slices.Clone(alb.Code), nil
+}
+
+func (m *Mem) GetResults(_ context.Context, album uint64) (string, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return "", errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Results, nil
+}
+
+func (m *Mem) GetFrozen(_ context.Context, album uint64) (bool, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return false, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Frozen, nil
+}
+
+func (m *Mem) SaveFrozen(_ context.Context, album uint64, frozen bool) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	alb.Frozen = frozen
+	m.albums[album] = alb
+	return nil
+}
+
+func (m *Mem) GetExpires(_ context.Context, album uint64) (time.Time, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return time.Time{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Expires, nil
+}
+
+func (m *Mem) SaveExpires(_ context.Context, album uint64, expires time.Time) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	alb.Expires = expires
+	m.albums[album] = alb
+	return nil
+}
+
+func (m *Mem) GetTournament(_ context.Context, album uint64) (model.Tournament, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return model.Tournament{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return copyTournament(alb.Tournament), nil
+}
+
+func (m *Mem) SaveTournament(_ context.Context, album uint64, tour model.Tournament) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if alb.Tournament.Version != tour.Version {
+		return errors.Wrap(domain.ErrTournamentConflict)
+	}
+	alb.Tournament = copyTournament(tour)
+	alb.Tournament.Version++
+	m.albums[album] = alb
+	return nil
+}
+
+func (m *Mem) SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, tie bool) error {
+	return m.SaveVotes(ctx, album, votes(imageFrom, imageTo, tie))
+}
+
+func (m *Mem) SaveVotes(_ context.Context, album uint64, votes []model.Vote) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	// the image may have been removed since the pair was served
+	for _, v := range votes {
+		_, ok1 := alb.Edges[v.From]
+		_, ok2 := alb.Edges[v.To]
+		if !ok1 || !ok2 {
+			return errors.Wrap(domain.ErrImageNotFound)
+		}
+	}
+	for _, v := range votes {
+		alb.Edges[v.From][v.To] += v.Weight
+	}
+	return nil
+}
+
+func (m *Mem) SaveSkip(_ context.Context, album uint64, image1 uint64, image2 uint64) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	_, ok1 := alb.Edges[image1]
+	_, ok2 := alb.Edges[image2]
+	if !ok1 || !ok2 {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	for i := range alb.Images {
+		img := &alb.Images[i]
+		if img.Id == image1 || img.Id == image2 {
+			img.Skips++
+		}
+	}
+	return nil
+}
+
+func (m *Mem) GetEdges(_ context.Context, album uint64) (map[uint64]map[uint64]float64, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return nil, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	edgs := make(map[uint64]map[uint64]float64, len(alb.Edges))
 	for k := range alb.Edges {
-		edgs[k] = make(map[uint64]int, len(alb.Edges[k]))
+		edgs[k] = make(map[uint64]float64, len(alb.Edges[k]))
 	}
 	for k1 := range alb.Edges {
 		for k2 := range alb.Edges[k1] {
@@ -181,10 +408,30 @@ func (m *Mem) UpdateRatings(_ context.Context, album uint64, vector map[uint64]f
 			}
 		}
 	}
+	alb.Version++
+	m.albums[album] = alb
+	return nil
+}
+
+func (m *Mem) UpdateConfidence(_ context.Context, album uint64, vectorLow map[uint64]float64, vectorHigh map[uint64]float64, comparisons map[uint64]int) error {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	for i := range alb.Images {
+		img := &alb.Images[i]
+		img.RatingLow = vectorLow[img.Id]
+		img.RatingHigh = vectorHigh[img.Id]
+		img.Comparisons = comparisons[img.Id]
+	}
+	alb.Version++
+	m.albums[album] = alb
 	return nil
 }
 
-func (m *Mem) GetImagesOrdered(_ context.Context, album uint64) ([]model.Image, error) {
+func (m *Mem) GetImagesOrdered(_ context.Context, album uint64, page model.Page) ([]model.Image, error) {
 	m.syncAlbums.Lock()
 	defer m.syncAlbums.Unlock()
 	alb, ok := m.albums[album]
@@ -193,8 +440,17 @@ func (m *Mem) GetImagesOrdered(_ context.Context, album uint64) ([]model.Image,
 	}
 	imgs := make([]model.Image, len(alb.Images))
 	copy(imgs, alb.Images)
-	slices.SortFunc(imgs, func(a, b model.Image) bool { return a.Rating > b.Rating })
-	return imgs, nil
+	return paginate(imgs, page), nil
+}
+
+func (m *Mem) GetVersion(_ context.Context, album uint64) (uint64, error) {
+	m.syncAlbums.Lock()
+	defer m.syncAlbums.Unlock()
+	alb, ok := m.albums[album]
+	if !ok {
+		return 0x0, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	return alb.Version, nil
 }
 
 func (m *Mem) DeleteAlbum(_ context.Context, album uint64) error {
@@ -220,6 +476,20 @@ func (m *Mem) AlbumsToBeDeleted(_ context.Context) ([]model.Album, error) {
 	return albs, nil
 }
 
+func copyTournament(tour model.Tournament) model.Tournament {
+	tour.Seeds = slices.Clone(tour.Seeds)
+	tour.Matches = slices.Clone(tour.Matches)
+	scores := tour.Scores
+	tour.Scores = nil
+	if scores != nil {
+		tour.Scores = make(map[uint64]float64, len(scores))
+		for id, score := range scores {
+			tour.Scores[id] = score
+		}
+	}
+	return tour
+}
+
 func (m *Mem) Health(_ context.Context) (bool, error) {
 	return true, nil
 }
@@ -181,33 +181,367 @@ func (suite *MemTestSuite) TestImage() {
 	})
 }
 
-func (suite *MemTestSuite) TestVote() {
+func (suite *MemTestSuite) TestImages() {
 	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		err = suite.db.UpdateCompressionStatus(suite.ctx, ids.Uint64(0), ids.Uint64(1))
+		assert.NoError(t, err)
+		img6 := model.Image{Id: id(), Src: "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(6), Caption: "Tom"}
+		err = suite.db.SaveImages(suite.ctx, ids.Uint64(0), []model.Image{img6})
+		assert.NoError(t, err)
+		n, err := suite.db.CountImages(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 6, n)
+		caption, err := suite.db.GetImageCaption(suite.ctx, ids.Uint64(0), ids.Uint64(6))
+		assert.NoError(t, err)
+		assert.Equal(t, "Tom", caption)
+		images, err := suite.db.GetImagesIdsUncompressed(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{ids.Uint64(2), ids.Uint64(3), ids.Uint64(4), ids.Uint64(5), ids.Uint64(6)}, images)
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(1), ids.Uint64(2), false)
+		assert.NoError(t, err)
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(2), ids.Uint64(6), false)
+		assert.NoError(t, err)
+		err = suite.db.DeleteImage(suite.ctx, ids.Uint64(0), ids.Uint64(2))
+		assert.NoError(t, err)
+		images, err = suite.db.GetImagesIds(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{ids.Uint64(1), ids.Uint64(3), ids.Uint64(4), ids.Uint64(5), ids.Uint64(6)}, images)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Len(t, edgs, 5)
+		for from := range edgs {
+			assert.Empty(t, edgs[from])
+		}
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		err := suite.db.SaveImages(suite.ctx, album, []model.Image{{Id: id()}})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		err = suite.db.DeleteImage(suite.ctx, album, id())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		_, err = suite.db.GetImagesIdsUncompressed(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		err := suite.db.DeleteImage(suite.ctx, ids.Uint64(0), id())
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
+	})
+}
+
+func (suite *MemTestSuite) TestVote() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		err := suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5), false)
+		assert.NoError(t, err)
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5), false)
+		assert.NoError(t, err)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 2.0, edgs[ids.Uint64(3)][ids.Uint64(5)])
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		err := suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5), true)
+		assert.NoError(t, err)
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5), false)
+		assert.NoError(t, err)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 1.5, edgs[ids.Uint64(3)][ids.Uint64(5)])
+		assert.Equal(t, 0.5, edgs[ids.Uint64(5)][ids.Uint64(3)])
+	})
+	suite.T().Run("Positive3", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		err := suite.db.SaveSkip(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5))
+		assert.NoError(t, err)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, edgs[ids.Uint64(3)][ids.Uint64(5)])
+		imgs, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{})
+		assert.NoError(t, err)
+		skips := map[uint64]int{}
+		for _, img := range imgs {
+			skips[img.Id] = img.Skips
+		}
+		assert.Equal(t, map[uint64]int{ids.Uint64(1): 0, ids.Uint64(2): 0, ids.Uint64(3): 1, ids.Uint64(4): 0, ids.Uint64(5): 1}, skips)
+	})
+	suite.T().Run("Positive4", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		votes := []model.Vote{{From: ids.Uint64(3), To: ids.Uint64(5), Weight: 1000}, {From: ids.Uint64(5), To: ids.Uint64(1), Weight: 2}}
+		err := suite.db.SaveVotes(suite.ctx, ids.Uint64(0), votes)
+		assert.NoError(t, err)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 1000.0, edgs[ids.Uint64(3)][ids.Uint64(5)])
+		assert.Equal(t, 2.0, edgs[ids.Uint64(5)][ids.Uint64(1)])
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		err := suite.db.SaveVote(suite.ctx, id(), id(), id(), false)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		err := suite.db.SaveSkip(suite.ctx, id(), id(), id())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative3", func(t *testing.T) {
 		suite.setupTestFn()
 		id, ids := GenId()
 		_ = suite.saveAlbum(id, ids)
-		err := suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5))
+		err := suite.db.DeleteImage(suite.ctx, ids.Uint64(0), ids.Uint64(5))
 		assert.NoError(t, err)
-		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5))
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5), false)
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
+		err = suite.db.SaveVote(suite.ctx, ids.Uint64(0), ids.Uint64(5), ids.Uint64(3), true)
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
+		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.NotContains(t, edgs, ids.Uint64(5))
+	})
+	suite.T().Run("Negative4", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		err := suite.db.DeleteImage(suite.ctx, ids.Uint64(0), ids.Uint64(5))
 		assert.NoError(t, err)
+		err = suite.db.SaveSkip(suite.ctx, ids.Uint64(0), ids.Uint64(3), ids.Uint64(5))
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
+	})
+	suite.T().Run("Negative5", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		votes := []model.Vote{{From: ids.Uint64(3), To: ids.Uint64(5), Weight: 1}, {From: ids.Uint64(5), To: id(), Weight: 1}}
+		err := suite.db.SaveVotes(suite.ctx, ids.Uint64(0), votes)
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
 		edgs, err := suite.db.GetEdges(suite.ctx, ids.Uint64(0))
 		assert.NoError(t, err)
-		assert.Equal(t, 2, edgs[ids.Uint64(3)][ids.Uint64(5)])
+		assert.Zero(t, edgs[ids.Uint64(3)][ids.Uint64(5)])
+	})
+}
+
+func (suite *MemTestSuite) TestRanking() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		ranking, err := suite.db.GetRanking(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, "", ranking)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		alb.Ranking = "elo"
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		ranking, err := suite.db.GetRanking(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, "elo", ranking)
 	})
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
 		id, _ := GenId()
-		err := suite.db.SaveVote(suite.ctx, id(), id(), id())
+		_, err := suite.db.GetRanking(suite.ctx, id())
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
 }
 
+func (suite *MemTestSuite) TestMetadata() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		alb.Title = "Cats"
+		alb.Description = "Which one is the cutest?"
+		alb.Images[0].Caption = "Tom"
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		meta, err := suite.db.GetMetadata(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, model.Metadata{Title: "Cats", Description: "Which one is the cutest?"}, meta)
+		caption, err := suite.db.GetImageCaption(suite.ctx, ids.Uint64(0), ids.Uint64(1))
+		assert.NoError(t, err)
+		assert.Equal(t, "Tom", caption)
+		caption, err = suite.db.GetImageCaption(suite.ctx, ids.Uint64(0), ids.Uint64(2))
+		assert.NoError(t, err)
+		assert.Equal(t, "", caption)
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		_, err := suite.db.GetMetadata(suite.ctx, id())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		_, err := suite.db.GetImageCaption(suite.ctx, ids.Uint64(0), id())
+		assert.ErrorIs(t, err, domain.ErrImageNotFound)
+	})
+}
+
+func (suite *MemTestSuite) TestOwner() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		alb.Owner = []byte{0xA, 0xB, 0xC}
+		alb.Access = "code"
+		alb.Code = []byte{0xD, 0xE, 0xF}
+		alb.Results = "owner"
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		owner, err := suite.db.GetOwner(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xA, 0xB, 0xC}, owner)
+		access, code, err := suite.db.GetAccess(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, "code", access)
+		assert.Equal(t, []byte{0xD, 0xE, 0xF}, code)
+		results, err := suite.db.GetResults(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, "owner", results)
+		frozen, err := suite.db.GetFrozen(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.False(t, frozen)
+		err = suite.db.SaveFrozen(suite.ctx, ids.Uint64(0), true)
+		assert.NoError(t, err)
+		frozen, err = suite.db.GetFrozen(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.True(t, frozen)
+		expires, err := suite.db.GetExpires(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.True(t, expires.IsZero())
+		want := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		err = suite.db.SaveExpires(suite.ctx, ids.Uint64(0), want)
+		assert.NoError(t, err)
+		expires, err = suite.db.GetExpires(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.True(t, want.Equal(expires))
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		album := id()
+		_, err := suite.db.GetOwner(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		_, _, err = suite.db.GetAccess(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		_, err = suite.db.GetResults(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		_, err = suite.db.GetFrozen(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		err = suite.db.SaveFrozen(suite.ctx, album, true)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		_, err = suite.db.GetExpires(suite.ctx, album)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+		err = suite.db.SaveExpires(suite.ctx, album, time.Now())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func (suite *MemTestSuite) TestTournament() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		tour, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, "", tour.Mode)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		seeds := []uint64{ids.Uint64(1), ids.Uint64(2), ids.Uint64(3), ids.Uint64(4), ids.Uint64(5)}
+		match1 := model.Match{Round: 1, Image1: ids.Uint64(1), Image2: ids.Uint64(2)}
+		match2 := model.Match{Round: 1, Image1: ids.Uint64(3), Image2: ids.Uint64(4)}
+		match3 := model.Match{Round: 1, Image1: ids.Uint64(5), Winner: ids.Uint64(5), Done: true}
+		scores := map[uint64]float64{ids.Uint64(1): 0, ids.Uint64(2): 0, ids.Uint64(3): 0, ids.Uint64(4): 0, ids.Uint64(5): 1}
+		alb.Tournament = model.Tournament{Mode: "swiss", Round: 1, Rounds: 3, Seeds: seeds, Matches: []model.Match{match1, match2, match3}, Scores: scores}
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		tour, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, alb.Tournament, tour)
+		tour.Matches[0].Winner = ids.Uint64(2)
+		tour.Matches[0].Done = true
+		tour.Scores[ids.Uint64(2)]++
+		err = suite.db.SaveTournament(suite.ctx, ids.Uint64(0), tour)
+		assert.NoError(t, err)
+		tour2, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		tour.Version++
+		assert.Equal(t, tour, tour2)
+	})
+	suite.T().Run("Negative1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		_, err := suite.db.GetTournament(suite.ctx, id())
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		err := suite.db.SaveTournament(suite.ctx, id(), model.Tournament{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+	suite.T().Run("Negative3", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		alb := AlbumFactory(id, ids)
+		match := model.Match{Round: 1, Image1: ids.Uint64(1), Image2: ids.Uint64(2)}
+		alb.Tournament = model.Tournament{Mode: "single-elimination", Round: 1, Rounds: 1, Seeds: []uint64{ids.Uint64(1), ids.Uint64(2)}, Matches: []model.Match{match}}
+		err := suite.db.SaveAlbum(suite.ctx, alb)
+		assert.NoError(t, err)
+		tour1, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		tour2, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		tour1.Matches[0].Winner = ids.Uint64(1)
+		tour1.Matches[0].Done = true
+		err = suite.db.SaveTournament(suite.ctx, ids.Uint64(0), tour1)
+		assert.NoError(t, err)
+		tour2.Matches[0].Winner = ids.Uint64(2)
+		tour2.Matches[0].Done = true
+		err = suite.db.SaveTournament(suite.ctx, ids.Uint64(0), tour2)
+		assert.ErrorIs(t, err, domain.ErrTournamentConflict)
+		tour3, err := suite.db.GetTournament(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, ids.Uint64(1), tour3.Matches[0].Winner)
+	})
+}
+
 func (suite *MemTestSuite) TestSort() {
 	suite.T().Run("Positive", func(t *testing.T) {
 		suite.setupTestFn()
 		id, ids := GenId()
 		_ = suite.saveAlbum(id, ids)
-		imgs1, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0))
+		imgs1, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{})
 		assert.NoError(t, err)
 		img1 := model.Image{Id: ids.Uint64(4), Src: "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(4), Rating: 0.77920413}
 		img2 := model.Image{Id: ids.Uint64(1), Src: "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(1), Rating: 0.48954984}
@@ -220,7 +554,81 @@ func (suite *MemTestSuite) TestSort() {
 	suite.T().Run("Negative", func(t *testing.T) {
 		suite.setupTestFn()
 		id, _ := GenId()
-		_, err := suite.db.GetImagesOrdered(suite.ctx, id())
+		_, err := suite.db.GetImagesOrdered(suite.ctx, id(), model.Page{})
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
+func (suite *MemTestSuite) TestPage() {
+	suite.T().Run("Positive1", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		imgs, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{Limit: 2})
+		assert.NoError(t, err)
+		require.Len(t, imgs, 2)
+		assert.Equal(t, ids.Uint64(4), imgs[0].Id)
+		assert.Equal(t, ids.Uint64(1), imgs[1].Id)
+		after := &model.Cursor{Rating: imgs[1].Rating, Id: imgs[1].Id}
+		imgs, err = suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{Limit: 2, After: after})
+		assert.NoError(t, err)
+		require.Len(t, imgs, 2)
+		assert.Equal(t, ids.Uint64(3), imgs[0].Id)
+		assert.Equal(t, ids.Uint64(2), imgs[1].Id)
+		after = &model.Cursor{Rating: imgs[1].Rating, Id: imgs[1].Id}
+		imgs, err = suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{Limit: 2, After: after})
+		assert.NoError(t, err)
+		require.Len(t, imgs, 1)
+		assert.Equal(t, ids.Uint64(5), imgs[0].Id)
+	})
+	suite.T().Run("Positive2", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		vector := map[uint64]float64{}
+		comparisons := map[uint64]int{}
+		for i := 1; i <= 5; i++ {
+			vector[ids.Uint64(i)] = 0.5
+			comparisons[ids.Uint64(i)] = i
+		}
+		err := suite.db.UpdateRatings(suite.ctx, ids.Uint64(0), vector)
+		assert.NoError(t, err)
+		err = suite.db.UpdateConfidence(suite.ctx, ids.Uint64(0), vector, vector, comparisons)
+		assert.NoError(t, err)
+		imgs, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{MinComparisons: 3})
+		assert.NoError(t, err)
+		require.Len(t, imgs, 3)
+		assert.Equal(t, ids.Uint64(3), imgs[0].Id)
+		assert.Equal(t, ids.Uint64(4), imgs[1].Id)
+		assert.Equal(t, ids.Uint64(5), imgs[2].Id)
+		after := &model.Cursor{Rating: 0.5, Id: ids.Uint64(3)}
+		imgs, err = suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{Limit: 1, After: after, MinComparisons: 3})
+		assert.NoError(t, err)
+		require.Len(t, imgs, 1)
+		assert.Equal(t, ids.Uint64(4), imgs[0].Id)
+	})
+}
+
+func (suite *MemTestSuite) TestVersion() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		v1, err := suite.db.GetVersion(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		err = suite.db.UpdateRatings(suite.ctx, ids.Uint64(0), map[uint64]float64{ids.Uint64(1): 0.5})
+		assert.NoError(t, err)
+		v2, err := suite.db.GetVersion(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.NotEqual(t, v1, v2)
+		v3, err := suite.db.GetVersion(suite.ctx, ids.Uint64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, v2, v3)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		_, err := suite.db.GetVersion(suite.ctx, id())
 		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
 	})
 }
@@ -244,7 +652,7 @@ func (suite *MemTestSuite) TestRatings() {
 		vector[img5.Id] = img5.Rating
 		err := suite.db.UpdateRatings(suite.ctx, ids.Uint64(0), vector)
 		assert.NoError(t, err)
-		imgs2, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0))
+		imgs2, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{})
 		assert.NoError(t, err)
 		sort.Slice(imgs1, func(i, j int) bool { return imgs1[i].Rating > imgs1[j].Rating })
 		assert.Equal(t, imgs1, imgs2)
@@ -269,6 +677,43 @@ func (suite *MemTestSuite) TestRatings() {
 	})
 }
 
+func (suite *MemTestSuite) TestConfidence() {
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		_ = suite.saveAlbum(id, ids)
+		vector := map[uint64]float64{}
+		vectorLow := map[uint64]float64{}
+		vectorHigh := map[uint64]float64{}
+		comparisons := map[uint64]int{}
+		for i := 1; i <= 5; i++ {
+			vector[ids.Uint64(i)] = float64(i) / 10
+			vectorLow[ids.Uint64(i)] = float64(i)/10 - 0.05
+			vectorHigh[ids.Uint64(i)] = float64(i)/10 + 0.05
+			comparisons[ids.Uint64(i)] = i
+		}
+		err := suite.db.UpdateRatings(suite.ctx, ids.Uint64(0), vector)
+		assert.NoError(t, err)
+		err = suite.db.UpdateConfidence(suite.ctx, ids.Uint64(0), vectorLow, vectorHigh, comparisons)
+		assert.NoError(t, err)
+		imgs, err := suite.db.GetImagesOrdered(suite.ctx, ids.Uint64(0), model.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, imgs, 5)
+		for _, img := range imgs {
+			assert.InDelta(t, vector[img.Id], img.Rating, TOLERANCE)
+			assert.InDelta(t, vectorLow[img.Id], img.RatingLow, TOLERANCE)
+			assert.InDelta(t, vectorHigh[img.Id], img.RatingHigh, TOLERANCE)
+			assert.Equal(t, comparisons[img.Id], img.Comparisons)
+		}
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		err := suite.db.UpdateConfidence(suite.ctx, id(), nil, nil, nil)
+		assert.ErrorIs(t, err, domain.ErrAlbumNotFound)
+	})
+}
+
 func (suite *MemTestSuite) TestDelete() {
 	suite.T().Run("Positive1", func(t *testing.T) {
 		suite.setupTestFn()
@@ -6,7 +6,6 @@ import (
 
 	lru "github.com/hashicorp/golang-lru"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongodb "go.mongodb.org/mongo-driver/mongo"
 	optionsdb "go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -23,20 +22,64 @@ var (
 
 type albumLru map[uint64]string
 
+type albumDao struct {
+	Album       int64
+	Expires     time.Time
+	Ranking     string
+	Title       string
+	Description string
+	Owner       []byte
+	Frozen      bool
+	Access      string
+	Code        []byte
+	Results     string
+	Version     int64
+}
+
 type imageDao struct {
-	Album      int64
-	Id         int64
-	Src        string
-	Rating     float64
-	Compressed bool
-	Expires    time.Time
+	Album       int64
+	Id          int64
+	Src         string
+	Caption     string
+	Filename    string
+	Rating      float64
+	RatingLow   float64
+	RatingHigh  float64
+	Comparisons int
+	Skips       int
+	Compressed  bool
 }
 
 type edgeDao struct {
 	Album  int64
 	From   int64
 	To     int64
-	Weight int
+	Weight float64
+}
+
+type tournamentDao struct {
+	Album    int64
+	Mode     string
+	Round    int
+	Rounds   int
+	Seeds    []int64
+	Matches  []matchDao
+	Scores   []scoreDao
+	Champion int64
+	Version  int64
+}
+
+type matchDao struct {
+	Round  int
+	Image1 int64
+	Image2 int64
+	Winner int64
+	Done   bool
+}
+
+type scoreDao struct {
+	Image int64
+	Score float64
 }
 
 func NewMongo(ctx context.Context, conf MongoConfig) (*Mongo, error) {
@@ -48,13 +91,15 @@ func NewMongo(ctx context.Context, conf MongoConfig) (*Mongo, error) {
 		return &Mongo{}, errors.Wrap(err)
 	}
 	db := client.Database("aye-and-nay")
+	albums := db.Collection("albums")
 	images := db.Collection("images")
 	edges := db.Collection("edges")
+	tournaments := db.Collection("tournaments")
 	cache, err := lru.New(conf.LRU)
 	if err != nil {
 		return &Mongo{}, errors.Wrap(err)
 	}
-	m := &Mongo{conf, client, db, images, edges, cache}
+	m := &Mongo{conf, client, db, albums, images, edges, tournaments, cache}
 	err = retry.Do(conf.RetryTimes, conf.RetryPause, func() error {
 		_, err := m.Health(ctx)
 		if err != nil {
@@ -65,32 +110,54 @@ func NewMongo(ctx context.Context, conf MongoConfig) (*Mongo, error) {
 	if err != nil {
 		return &Mongo{}, errors.Wrap(err)
 	}
+	err = m.migrate(ctx)
+	if err != nil {
+		return &Mongo{}, errors.Wrap(err)
+	}
 	return m, nil
 }
 
 type Mongo struct {
-	conf   MongoConfig
-	client *mongodb.Client
-	db     *mongodb.Database
-	images *mongodb.Collection
-	edges  *mongodb.Collection
-	cache  *lru.Cache
+	conf        MongoConfig
+	client      *mongodb.Client
+	db          *mongodb.Database
+	albums      *mongodb.Collection
+	images      *mongodb.Collection
+	edges       *mongodb.Collection
+	tournaments *mongodb.Collection
+	cache       *lru.Cache
 }
 
 func (m *Mongo) SaveAlbum(ctx context.Context, alb model.Album) error {
 	filter := bson.D{{"album", int64(alb.Id)}}
-	n, err := m.images.CountDocuments(ctx, filter)
+	n, err := m.albums.CountDocuments(ctx, filter)
 	if err != nil {
 		return errors.Wrap(err)
 	}
 	if n > 0 {
 		return errors.Wrap(domain.ErrAlbumAlreadyExists)
 	}
+	albDao := albumDao{
+		Album:       int64(alb.Id),
+		Expires:     alb.Expires,
+		Ranking:     alb.Ranking,
+		Title:       alb.Title,
+		Description: alb.Description,
+		Owner:       alb.Owner,
+		Frozen:      alb.Frozen,
+		Access:      alb.Access,
+		Code:        alb.Code,
+		Results:     alb.Results,
+		Version:     int64(alb.Version),
+	}
+	_, err = m.albums.InsertOne(ctx, albDao)
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	imgsDao := make([]any, 0, len(alb.Images))
 	albLru := make(albumLru, len(alb.Images))
 	for _, img := range alb.Images {
-		imgDao := imageDao{int64(alb.Id), int64(img.Id), img.Src, img.Rating, m.conf.Compressed, alb.Expires}
-		imgsDao = append(imgsDao, imgDao)
+		imgsDao = append(imgsDao, m.newImageDao(alb.Id, img))
 		albLru[img.Id] = img.Src
 	}
 	_, err = m.images.InsertMany(ctx, imgsDao)
@@ -106,10 +173,33 @@ func (m *Mongo) SaveAlbum(ctx context.Context, alb model.Album) error {
 			}
 		}
 	}
+	if alb.Tournament.Mode != "" {
+		tourDao := newTournamentDao(alb.Id, alb.Tournament)
+		_, err = m.tournaments.InsertOne(ctx, tourDao)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
 	m.cache.Add(alb.Id, albLru)
 	return nil
 }
 
+func (m *Mongo) newImageDao(album uint64, img model.Image) imageDao {
+	return imageDao{
+		Album:       int64(album),
+		Id:          int64(img.Id),
+		Src:         img.Src,
+		Caption:     img.Caption,
+		Filename:    img.Filename,
+		Rating:      img.Rating,
+		RatingLow:   img.RatingLow,
+		RatingHigh:  img.RatingHigh,
+		Comparisons: img.Comparisons,
+		Skips:       img.Skips,
+		Compressed:  m.conf.Compressed,
+	}
+}
+
 func (m *Mongo) CountImages(ctx context.Context, album uint64) (int, error) {
 	albLru, err := m.lruGetOrAddAndGet(ctx, album)
 	if err != nil {
@@ -161,6 +251,27 @@ func (m *Mongo) GetImageSrc(ctx context.Context, album uint64, image uint64) (st
 	return src, nil
 }
 
+func (m *Mongo) GetImageCaption(ctx context.Context, album uint64, image uint64) (string, error) {
+	albLru, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	_, ok := albLru[image]
+	if !ok {
+		return "", errors.Wrap(domain.ErrImageNotFound)
+	}
+	filter := bson.D{{"album", int64(album)}, {"id", int64(image)}}
+	imgDao := imageDao{}
+	err = m.images.FindOne(ctx, filter).Decode(&imgDao)
+	if errors.Is(err, mongodb.ErrNoDocuments) {
+		return "", errors.Wrap(domain.ErrImageNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return imgDao.Caption, nil
+}
+
 func (m *Mongo) GetImagesIds(ctx context.Context, album uint64) ([]uint64, error) {
 	albLru, err := m.lruGetOrAddAndGet(ctx, album)
 	if err != nil {
@@ -173,22 +284,317 @@ func (m *Mongo) GetImagesIds(ctx context.Context, album uint64) ([]uint64, error
 	return images, nil
 }
 
-func (m *Mongo) SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64) error {
+func (m *Mongo) GetImagesIdsUncompressed(ctx context.Context, album uint64) ([]uint64, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	filter := bson.D{{"album", int64(album)}, {"compressed", false}}
+	cursor, err := m.images.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	imgsDao := []imageDao(nil)
+	err = cursor.All(ctx, &imgsDao)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	images := make([]uint64, 0, len(imgsDao))
+	for _, imgDao := range imgsDao {
+		images = append(images, uint64(imgDao.Id))
+	}
+	return images, nil
+}
+
+func (m *Mongo) SaveImages(ctx context.Context, album uint64, imgs []model.Image) error {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	imgsDao := make([]any, 0, len(imgs))
+	for _, img := range imgs {
+		imgsDao = append(imgsDao, m.newImageDao(album, img))
+	}
+	_, err = m.images.InsertMany(ctx, imgsDao)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = m.bump(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	m.cache.Remove(album)
+	return nil
+}
+
+func (m *Mongo) DeleteImage(ctx context.Context, album uint64, image uint64) error {
+	albLru, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, ok := albLru[image]
+	if !ok {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	filter := bson.D{{"album", int64(album)}, {"id", int64(image)}}
+	_, err = m.images.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	filter = bson.D{{"album", int64(album)}, {"$or", bson.A{bson.D{{"from", int64(image)}}, bson.D{{"to", int64(image)}}}}}
+	_, err = m.edges.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = m.bump(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	m.cache.Remove(album)
+	return nil
+}
+
+func (m *Mongo) GetRanking(ctx context.Context, album uint64) (string, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return albDao.Ranking, nil
+}
+
+func (m *Mongo) GetMetadata(ctx context.Context, album uint64) (model.Metadata, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return model.Metadata{}, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return model.Metadata{}, errors.Wrap(err)
+	}
+	return model.Metadata{Title: albDao.Title, Description: albDao.Description}, nil
+}
+
+func (m *Mongo) GetOwner(ctx context.Context, album uint64) ([]byte, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return albDao.Owner, nil
+}
+
+func (m *Mongo) GetAccess(ctx context.Context, album uint64) (string, []byte, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return "", nil, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return "", nil, errors.Wrap(err)
+	}
+	return albDao.Access, albDao.Code, nil
+}
+
+func (m *Mongo) GetResults(ctx context.Context, album uint64) (string, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return albDao.Results, nil
+}
+
+func (m *Mongo) GetFrozen(ctx context.Context, album uint64) (bool, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return albDao.Frozen, nil
+}
+
+func (m *Mongo) SaveFrozen(ctx context.Context, album uint64, frozen bool) error {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	filter := bson.D{{"album", int64(album)}}
+	update := bson.D{{"$set", bson.D{{"frozen", frozen}}}}
+	_, err = m.albums.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (m *Mongo) GetExpires(ctx context.Context, album uint64) (time.Time, error) {
 	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err)
+	}
+	return albDao.Expires, nil
+}
+
+func (m *Mongo) SaveExpires(ctx context.Context, album uint64, expires time.Time) error {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	filter := bson.D{{"album", int64(album)}}
+	update := bson.D{{"$set", bson.D{{"expires", expires}}}}
+	_, err = m.albums.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (m *Mongo) GetTournament(ctx context.Context, album uint64) (model.Tournament, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return model.Tournament{}, errors.Wrap(err)
+	}
+	filter := bson.D{{"album", int64(album)}}
+	tourDao := tournamentDao{}
+	err = m.tournaments.FindOne(ctx, filter).Decode(&tourDao)
+	if errors.Is(err, mongodb.ErrNoDocuments) {
+		return model.Tournament{}, nil
+	}
+	if err != nil {
+		return model.Tournament{}, errors.Wrap(err)
+	}
+	tour := model.Tournament{}
+	tour.Mode = tourDao.Mode
+	tour.Round = tourDao.Round
+	tour.Rounds = tourDao.Rounds
+	tour.Seeds = make([]uint64, 0, len(tourDao.Seeds))
+	for _, seed := range tourDao.Seeds {
+		tour.Seeds = append(tour.Seeds, uint64(seed))
+	}
+	tour.Matches = make([]model.Match, 0, len(tourDao.Matches))
+	for _, mDao := range tourDao.Matches {
+		match := model.Match{Round: mDao.Round, Image1: uint64(mDao.Image1), Image2: uint64(mDao.Image2), Winner: uint64(mDao.Winner), Done: mDao.Done}
+		tour.Matches = append(tour.Matches, match)
+	}
+	if len(tourDao.Scores) > 0 {
+		tour.Scores = make(map[uint64]float64, len(tourDao.Scores))
+		for _, sDao := range tourDao.Scores {
+			tour.Scores[uint64(sDao.Image)] = sDao.Score
+		}
+	}
+	tour.Champion = uint64(tourDao.Champion)
+	tour.Version = uint64(tourDao.Version)
+	return tour, nil
+}
+
+func (m *Mongo) SaveTournament(ctx context.Context, album uint64, tour model.Tournament) error {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// the tournaments saved before the version was added have none
+	version := bson.D{{"version", int64(tour.Version)}}
+	if tour.Version == 0 {
+		version = bson.D{{"$or", bson.A{version, bson.D{{"version", bson.D{{"$exists", false}}}}}}}
+	}
+	filter := append(bson.D{{"album", int64(album)}}, version...)
+	tourDao := newTournamentDao(album, tour)
+	tourDao.Version++
+	res, err := m.tournaments.ReplaceOne(ctx, filter, tourDao)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.Wrap(domain.ErrTournamentConflict)
+	}
+	return nil
+}
+
+func newTournamentDao(album uint64, tour model.Tournament) tournamentDao {
+	seeds := make([]int64, 0, len(tour.Seeds))
+	for _, seed := range tour.Seeds {
+		seeds = append(seeds, int64(seed))
+	}
+	matchesDao := make([]matchDao, 0, len(tour.Matches))
+	for _, match := range tour.Matches {
+		mDao := matchDao{match.Round, int64(match.Image1), int64(match.Image2), int64(match.Winner), match.Done}
+		matchesDao = append(matchesDao, mDao)
+	}
+	scoresDao := make([]scoreDao, 0, len(tour.Scores))
+	for image, score := range tour.Scores {
+		scoresDao = append(scoresDao, scoreDao{int64(image), score})
+	}
+	return tournamentDao{int64(album), tour.Mode, tour.Round, tour.Rounds, seeds, matchesDao, scoresDao, int64(tour.Champion), int64(tour.Version)}
+}
+
+func (m *Mongo) SaveVote(ctx context.Context, album uint64, imageFrom uint64, imageTo uint64, tie bool) error {
+	return m.SaveVotes(ctx, album, votes(imageFrom, imageTo, tie))
+}
+
+func (m *Mongo) SaveVotes(ctx context.Context, album uint64, votes []model.Vote) error {
+	albLru, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// the image may have been removed since the pair was served
+	models := make([]mongodb.WriteModel, 0, len(votes))
+	for _, v := range votes {
+		_, ok1 := albLru[v.From]
+		_, ok2 := albLru[v.To]
+		if !ok1 || !ok2 {
+			return errors.Wrap(domain.ErrImageNotFound)
+		}
+		filter := bson.D{{"album", int64(album)}, {"from", int64(v.From)}, {"to", int64(v.To)}}
+		update := bson.D{{"$inc", bson.D{{"weight", v.Weight}}}}
+		models = append(models, mongodb.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	_, err = m.edges.BulkWrite(ctx, models)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (m *Mongo) SaveSkip(ctx context.Context, album uint64, image1 uint64, image2 uint64) error {
+	albLru, err := m.lruGetOrAddAndGet(ctx, album)
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	filter := bson.D{{"album", int64(album)}, {"from", int64(imageFrom)}, {"to", int64(imageTo)}}
-	update := bson.D{{"$inc", bson.D{{"weight", 1}}}}
-	opts := optionsdb.Update().SetUpsert(true)
-	_, err = m.edges.UpdateOne(ctx, filter, update, opts)
+	_, ok1 := albLru[image1]
+	_, ok2 := albLru[image2]
+	if !ok1 || !ok2 {
+		return errors.Wrap(domain.ErrImageNotFound)
+	}
+	filter := bson.D{{"album", int64(album)}, {"id", bson.D{{"$in", bson.A{int64(image1), int64(image2)}}}}}
+	update := bson.D{{"$inc", bson.D{{"skips", 1}}}}
+	_, err = m.images.UpdateMany(ctx, filter, update)
 	if err != nil {
 		return errors.Wrap(err)
 	}
 	return nil
 }
 
-func (m *Mongo) GetEdges(ctx context.Context, album uint64) (map[uint64]map[uint64]int, error) {
+func (m *Mongo) GetEdges(ctx context.Context, album uint64) (map[uint64]map[uint64]float64, error) {
 	albLru, err := m.lruGetOrAddAndGet(ctx, album)
 	if err != nil {
 		return nil, errors.Wrap(err)
@@ -203,9 +609,9 @@ func (m *Mongo) GetEdges(ctx context.Context, album uint64) (map[uint64]map[uint
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
-	edgs := make(map[uint64]map[uint64]int, len(albLru))
+	edgs := make(map[uint64]map[uint64]float64, len(albLru))
 	for _, imgDao := range imgsDao {
-		edgs[uint64(imgDao.Id)] = make(map[uint64]int, len(albLru))
+		edgs[uint64(imgDao.Id)] = make(map[uint64]float64, len(albLru))
 		filter := bson.D{{"album", int64(album)}, {"from", imgDao.Id}}
 		cursor, err := m.edges.Find(ctx, filter)
 		if err != nil {
@@ -236,16 +642,53 @@ func (m *Mongo) UpdateRatings(ctx context.Context, album uint64, vector map[uint
 			return errors.Wrap(err)
 		}
 	}
+	err = m.bump(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	return nil
 }
 
-func (m *Mongo) GetImagesOrdered(ctx context.Context, album uint64) ([]model.Image, error) {
+func (m *Mongo) UpdateConfidence(ctx context.Context, album uint64, vectorLow map[uint64]float64, vectorHigh map[uint64]float64, comparisons map[uint64]int) error {
+	albLru, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for id := range albLru {
+		filter := bson.D{{"album", int64(album)}, {"id", int64(id)}}
+		update := bson.D{{"$set", bson.D{{"ratinglow", vectorLow[id]}, {"ratinghigh", vectorHigh[id]}, {"comparisons", comparisons[id]}}}}
+		_, err := m.images.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	err = m.bump(ctx, album)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (m *Mongo) GetImagesOrdered(ctx context.Context, album uint64, page model.Page) ([]model.Image, error) {
 	albLru, err := m.lruGetOrAddAndGet(ctx, album)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
 	filter := bson.D{{"album", int64(album)}}
-	opts := optionsdb.Find().SetSort(bson.D{{"rating", -1}})
+	if page.MinComparisons > 0 {
+		filter = append(filter, bson.E{"comparisons", bson.D{{"$gte", page.MinComparisons}}})
+	}
+	if page.After != nil {
+		after := bson.A{
+			bson.D{{"rating", bson.D{{"$lt", page.After.Rating}}}},
+			bson.D{{"rating", page.After.Rating}, {"id", bson.D{{"$gt", int64(page.After.Id)}}}},
+		}
+		filter = append(filter, bson.E{"$or", after})
+	}
+	opts := optionsdb.Find().SetSort(bson.D{{"rating", -1}, {"id", 1}})
+	if page.Limit > 0 {
+		opts = opts.SetLimit(int64(page.Limit))
+	}
 	cursor, err := m.images.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, errors.Wrap(err)
@@ -257,19 +700,31 @@ func (m *Mongo) GetImagesOrdered(ctx context.Context, album uint64) ([]model.Ima
 	}
 	imgs := make([]model.Image, 0, len(albLru))
 	for _, imgDao := range imgsDao {
-		img := model.Image{Id: uint64(imgDao.Id), Src: imgDao.Src, Rating: imgDao.Rating}
+		img := model.Image{Id: uint64(imgDao.Id), Src: imgDao.Src, Caption: imgDao.Caption, Filename: imgDao.Filename, Rating: imgDao.Rating, RatingLow: imgDao.RatingLow, RatingHigh: imgDao.RatingHigh, Comparisons: imgDao.Comparisons, Skips: imgDao.Skips}
 		imgs = append(imgs, img)
 	}
 	return imgs, nil
 }
 
+func (m *Mongo) GetVersion(ctx context.Context, album uint64) (uint64, error) {
+	_, err := m.lruGetOrAddAndGet(ctx, album)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	albDao, err := m.findAlbum(ctx, album)
+	if err != nil {
+		return 0x0, errors.Wrap(err)
+	}
+	return uint64(albDao.Version), nil
+}
+
 func (m *Mongo) DeleteAlbum(ctx context.Context, album uint64) error {
 	filter := bson.D{{"album", int64(album)}}
-	n, err := m.images.CountDocuments(ctx, filter)
+	res, err := m.albums.DeleteOne(ctx, filter)
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	if n == 0 {
+	if res.DeletedCount == 0 {
 		return errors.Wrap(domain.ErrAlbumNotFound)
 	}
 	_, err = m.images.DeleteMany(ctx, filter)
@@ -280,29 +735,105 @@ func (m *Mongo) DeleteAlbum(ctx context.Context, album uint64) error {
 	if err != nil {
 		return errors.Wrap(err)
 	}
+	_, err = m.tournaments.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err)
+	}
 	m.cache.Remove(album)
 	return nil
 }
 
 func (m *Mongo) AlbumsToBeDeleted(ctx context.Context) ([]model.Album, error) {
-	match := bson.D{{"$match", bson.D{{"expires", bson.D{{"$ne", time.Time{}}}}}}}
-	group := bson.D{{"$group", bson.D{{"_id", "$album"}, {"expires", bson.D{{"$first", "$expires"}}}}}}
-	cursor, err := m.images.Aggregate(ctx, mongodb.Pipeline{match, group})
+	filter := bson.D{{"expires", bson.D{{"$ne", time.Time{}}}}}
+	cursor, err := m.albums.Find(ctx, filter)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
-	results := []bson.M(nil)
-	err = cursor.All(ctx, &results)
+	albsDao := []albumDao(nil)
+	err = cursor.All(ctx, &albsDao)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
-	albs := make([]model.Album, 0, len(results))
-	for _, r := range results {
-		albs = append(albs, model.Album{Id: uint64(r["_id"].(int64)), Expires: r["expires"].(primitive.DateTime).Time()})
+	albs := make([]model.Album, 0, len(albsDao))
+	for _, albDao := range albsDao {
+		albs = append(albs, model.Album{Id: uint64(albDao.Album), Expires: albDao.Expires})
 	}
 	return albs, nil
 }
 
+// findAlbum reads the album wide fields, they are kept apart from the images
+func (m *Mongo) findAlbum(ctx context.Context, album uint64) (albumDao, error) {
+	filter := bson.D{{"album", int64(album)}}
+	albDao := albumDao{}
+	err := m.albums.FindOne(ctx, filter).Decode(&albDao)
+	if errors.Is(err, mongodb.ErrNoDocuments) {
+		return albumDao{}, errors.Wrap(domain.ErrAlbumNotFound)
+	}
+	if err != nil {
+		return albumDao{}, errors.Wrap(err)
+	}
+	return albDao, nil
+}
+
+// migrate backfills the albums collection for the albums that were saved
+// before it existed, their fields are read from the first image document
+func (m *Mongo) migrate(ctx context.Context) error {
+	pipeline := mongodb.Pipeline{
+		{{"$group", bson.D{
+			{"_id", "$album"},
+			{"expires", bson.D{{"$first", "$expires"}}},
+			{"ranking", bson.D{{"$first", "$ranking"}}},
+			{"title", bson.D{{"$first", "$title"}}},
+			{"description", bson.D{{"$first", "$description"}}},
+			{"owner", bson.D{{"$first", "$owner"}}},
+			{"frozen", bson.D{{"$first", "$frozen"}}},
+			{"access", bson.D{{"$first", "$access"}}},
+			{"code", bson.D{{"$first", "$code"}}},
+			{"results", bson.D{{"$first", "$results"}}},
+			{"version", bson.D{{"$max", "$version"}}},
+		}}},
+		{{"$lookup", bson.D{
+			{"from", m.albums.Name()},
+			{"localField", "_id"},
+			{"foreignField", "album"},
+			{"as", "saved"},
+		}}},
+		{{"$match", bson.D{{"saved", bson.D{{"$size", 0}}}}}},
+		{{"$addFields", bson.D{{"album", "$_id"}}}},
+		{{"$project", bson.D{{"_id", 0}, {"saved", 0}}}},
+	}
+	cursor, err := m.images.Aggregate(ctx, pipeline)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	albsDao := []albumDao(nil)
+	err = cursor.All(ctx, &albsDao)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for _, albDao := range albsDao {
+		filter := bson.D{{"album", albDao.Album}}
+		update := bson.D{{"$setOnInsert", albDao}}
+		opts := optionsdb.Update().SetUpsert(true)
+		_, err := m.albums.UpdateOne(ctx, filter, update, opts)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// bump increments the version of an album
+func (m *Mongo) bump(ctx context.Context, album uint64) error {
+	filter := bson.D{{"album", int64(album)}}
+	update := bson.D{{"$inc", bson.D{{"version", 1}}}}
+	_, err := m.albums.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 func (m *Mongo) lruGetOrAddAndGet(ctx context.Context, album uint64) (albumLru, error) {
 	a, ok := m.cache.Get(album)
 	if !ok {
@@ -3,10 +3,14 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
 
 	. "github.com/zitryss/aye-and-nay/internal/generator"
 )
@@ -64,18 +68,50 @@ func (suite *MongoTestSuite) TestMongoImage() {
 	suite.base.TestImage()
 }
 
+func (suite *MongoTestSuite) TestMongoImages() {
+	suite.base.TestImages()
+}
+
 func (suite *MongoTestSuite) TestMongoVote() {
 	suite.base.TestVote()
 }
 
+func (suite *MongoTestSuite) TestMongoRanking() {
+	suite.base.TestRanking()
+}
+
+func (suite *MongoTestSuite) TestMongoMetadata() {
+	suite.base.TestMetadata()
+}
+
+func (suite *MongoTestSuite) TestMongoOwner() {
+	suite.base.TestOwner()
+}
+
+func (suite *MongoTestSuite) TestMongoTournament() {
+	suite.base.TestTournament()
+}
+
 func (suite *MongoTestSuite) TestMongoSort() {
 	suite.base.TestSort()
 }
 
+func (suite *MongoTestSuite) TestMongoPage() {
+	suite.base.TestPage()
+}
+
+func (suite *MongoTestSuite) TestMongoVersion() {
+	suite.base.TestVersion()
+}
+
 func (suite *MongoTestSuite) TestMongoRatings() {
 	suite.base.TestRatings()
 }
 
+func (suite *MongoTestSuite) TestMongoConfidence() {
+	suite.base.TestConfidence()
+}
+
 func (suite *MongoTestSuite) TestMongoDelete() {
 	suite.base.TestDelete()
 }
@@ -88,3 +124,30 @@ func (suite *MongoTestSuite) TestMongoLru() {
 	assert.NoError(suite.base.T(), err)
 	assert.Equal(suite.base.T(), alb1.Edges, edgs)
 }
+
+func (suite *MongoTestSuite) TestMongoMigrate() {
+	suite.SetupTest()
+	id, ids := GenId()
+	album, image1, image2 := int64(id()), int64(id()), int64(id())
+	m := suite.base.db.(*Mongo)
+	expires := time.Now().Add(-1 * time.Hour).UTC().Truncate(time.Millisecond)
+	imgs := []any{
+		bson.D{{"album", album}, {"id", image1}, {"src", "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(1)}, {"expires", expires}, {"access", "code"}, {"code", []byte{0x1}}},
+		bson.D{{"album", album}, {"id", image2}, {"src", "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(2)}, {"expires", expires}, {"access", "code"}, {"code", []byte{0x1}}},
+	}
+	_, err := m.images.InsertMany(suite.base.ctx, imgs)
+	require.NoError(suite.T(), err)
+	_, _, err = m.GetAccess(suite.base.ctx, ids.Uint64(0))
+	assert.ErrorIs(suite.T(), err, domain.ErrAlbumNotFound)
+	err = m.migrate(suite.base.ctx)
+	assert.NoError(suite.T(), err)
+	err = m.migrate(suite.base.ctx)
+	assert.NoError(suite.T(), err)
+	access, code, err := m.GetAccess(suite.base.ctx, ids.Uint64(0))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "code", access)
+	assert.Equal(suite.T(), []byte{0x1}, code)
+	albums, err := m.AlbumsToBeDeleted(suite.base.ctx)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), len(albums) == 1 && albums[0].Id == ids.Uint64(0) && albums[0].Expires.Equal(expires))
+}
@@ -0,0 +1,25 @@
+package fetcher
+
+import (
+	"time"
+)
+
+const (
+	kb = 1 << (10 * 1)
+)
+
+type FetcherConfig struct {
+	Timeout      time.Duration `mapstructure:"FETCHER_TIMEOUT"          validate:"required"`
+	MaxRedirects int           `mapstructure:"FETCHER_MAX_REDIRECTS"`
+	MaxFileSize  int64         `mapstructure:"CONTROLLER_MAX_FILE_SIZE" validate:"required"`
+	AllowPrivate bool          `mapstructure:"FETCHER_ALLOW_PRIVATE"`
+}
+
+var (
+	DefaultFetcherConfig = FetcherConfig{
+		Timeout:      5 * time.Second,
+		MaxRedirects: 3,
+		MaxFileSize:  512 * kb,
+		AllowPrivate: false,
+	}
+)
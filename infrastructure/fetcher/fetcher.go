@@ -0,0 +1,155 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/pool"
+)
+
+var (
+	_ domain.Fetcher = (*Http)(nil)
+)
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+
+	// reserved lists the ranges netip has no predicate for
+	reserved = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"),
+		netip.MustParsePrefix("240.0.0.0/4"),
+		netip.MustParsePrefix("64:ff9b::/96"),
+	}
+)
+
+func NewHttp(conf FetcherConfig) *Http {
+	dialer := &net.Dialer{Timeout: conf.Timeout}
+	if !conf.AllowPrivate {
+		dialer.Control = control
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   conf.Timeout,
+		ResponseHeaderTimeout: conf.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       conf.Timeout,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   conf.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > conf.MaxRedirects {
+				return errTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return domain.ErrUrlInvalid
+			}
+			return nil
+		},
+	}
+	return &Http{conf, client}
+}
+
+type Http struct {
+	conf   FetcherConfig
+	client *http.Client
+}
+
+func (h *Http) Fetch(ctx context.Context, rawUrl string) (model.File, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return model.File{}, errors.Wrap(domain.ErrUrlInvalid)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return model.File{}, errors.Wrap(domain.ErrUrlInvalid)
+	}
+	resp, err := h.client.Do(req)
+	switch {
+	case errors.Is(err, domain.ErrUrlForbidden):
+		return model.File{}, errors.Wrap(domain.ErrUrlForbidden)
+	case errors.Is(err, domain.ErrUrlInvalid):
+		return model.File{}, errors.Wrap(domain.ErrUrlInvalid)
+	case err != nil:
+		return model.File{}, errors.Wrapf(domain.ErrFetchFailed, "%s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, h.conf.MaxFileSize))
+		return model.File{}, errors.Wrapf(domain.ErrFetchFailed, "status code %d", resp.StatusCode)
+	}
+	if resp.ContentLength > h.conf.MaxFileSize {
+		return model.File{}, errors.Wrap(domain.ErrImageTooLarge)
+	}
+	buf := pool.GetBufferN(resp.ContentLength)
+	n, err := io.Copy(buf, io.LimitReader(resp.Body, h.conf.MaxFileSize+1))
+	if err != nil {
+		pool.PutBuffer(buf)
+		return model.File{}, errors.Wrapf(domain.ErrFetchFailed, "%s", err)
+	}
+	if n > h.conf.MaxFileSize {
+		pool.PutBuffer(buf)
+		return model.File{}, errors.Wrap(domain.ErrImageTooLarge)
+	}
+	b := buf.Bytes()
+	if len(b) > 512 {
+		b = b[:512]
+	}
+	typ := http.DetectContentType(b)
+	if !strings.HasPrefix(typ, "image/") {
+		pool.PutBuffer(buf)
+		return model.File{}, errors.Wrap(domain.ErrNotImage)
+	}
+	closeFn := func() error {
+		pool.PutBuffer(buf)
+		return nil
+	}
+	f := model.NewFile(buf, closeFn, n)
+	f.Name = path.Base(resp.Request.URL.Path)
+	return f, nil
+}
+
+// control runs right before every connection, after the name has been
+// resolved, so redirects and rebinding cannot sneak past it
+func control(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if forbidden(addr.Unmap()) {
+		return errors.Wrapf(domain.ErrUrlForbidden, "%s", addr)
+	}
+	return nil
+}
+
+func forbidden(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
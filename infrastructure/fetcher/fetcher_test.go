@@ -0,0 +1,192 @@
+package fetcher
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	. "github.com/zitryss/aye-and-nay/internal/testing"
+)
+
+var (
+	unit = flag.Bool("unit", false, "")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestHttp(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	conf := DefaultFetcherConfig
+	conf.AllowPrivate = true
+	t.Run("Positive", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.Copy(w, Png())
+			assert.NoError(t, err)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		f, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL+"/cats/small.png")
+		assert.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, "small.png", f.Name)
+		AssertEqualFile(t, Png(), f)
+	})
+	t.Run("PositiveRedirect", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/b", http.StatusFound)
+		})
+		mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.Copy(w, Png())
+			assert.NoError(t, err)
+		})
+		mockserver := httptest.NewServer(mux)
+		defer mockserver.Close()
+		f, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL+"/a")
+		assert.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, "b", f.Name)
+	})
+	t.Run("NegativeUrl", func(t *testing.T) {
+		urls := []string{"", "cats.png", "ftp://localhost/cats.png", "file:///etc/passwd", "http://"}
+		for _, url := range urls {
+			_, err := NewHttp(conf).Fetch(context.Background(), url)
+			assert.ErrorIs(t, err, domain.ErrUrlInvalid, url)
+		}
+	})
+	t.Run("NegativeTooLarge", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.Copy(w, Png())
+			assert.NoError(t, err)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		conf := conf
+		conf.MaxFileSize = Png().Size - 1
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrImageTooLarge)
+	})
+	t.Run("NegativeTooLargeChunked", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.Copy(w, Png())
+			assert.NoError(t, err)
+			w.(http.Flusher).Flush()
+			_, err = io.Copy(w, Png())
+			assert.NoError(t, err)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		conf := conf
+		conf.MaxFileSize = Png().Size + 1
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrImageTooLarge)
+	})
+	t.Run("NegativeNotImage", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.WriteString(w, "<html><body>cats</body></html>")
+			assert.NoError(t, err)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrNotImage)
+	})
+	t.Run("NegativeStatusCode", func(t *testing.T) {
+		mockserver := httptest.NewServer(http.NotFoundHandler())
+		defer mockserver.Close()
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrFetchFailed)
+	})
+	t.Run("NegativeTooManyRedirects", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL+"/x")
+		assert.ErrorIs(t, err, domain.ErrFetchFailed)
+	})
+	t.Run("NegativeRedirectScheme", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrUrlInvalid)
+	})
+	t.Run("NegativeTimeout", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		conf := conf
+		conf.Timeout = 10 * time.Millisecond
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrFetchFailed)
+	})
+	t.Run("NegativePrivate", func(t *testing.T) {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.Copy(w, Png())
+			assert.NoError(t, err)
+		}
+		mockserver := httptest.NewServer(http.HandlerFunc(fn))
+		defer mockserver.Close()
+		conf := conf
+		conf.AllowPrivate = false
+		_, err := NewHttp(conf).Fetch(context.Background(), mockserver.URL)
+		assert.ErrorIs(t, err, domain.ErrUrlForbidden)
+		localhost := strings.Replace(mockserver.URL, "127.0.0.1", "localhost", 1)
+		_, err = NewHttp(conf).Fetch(context.Background(), localhost)
+		assert.ErrorIs(t, err, domain.ErrUrlForbidden)
+	})
+}
+
+func TestForbidden(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"::", true},
+		{"64:ff9b::a00:1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			addr := netip.MustParseAddr(tt.addr)
+			assert.Equal(t, tt.want, forbidden(addr))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	. "github.com/zitryss/aye-and-nay/internal/testing"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/pool"
+)
+
+var (
+	_ domain.Fetcher = (*Mock)(nil)
+)
+
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+type Mock struct {
+}
+
+func (m *Mock) Fetch(_ context.Context, rawUrl string) (model.File, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return model.File{}, errors.Wrap(domain.ErrUrlInvalid)
+	}
+	f := Png()
+	buf := pool.GetBufferN(f.Size)
+	n, err := io.Copy(buf, f.Reader)
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
+	closeFn := func() error {
+		pool.PutBuffer(buf)
+		return nil
+	}
+	F := model.NewFile(buf, closeFn, n)
+	F.Name = path.Base(u.Path)
+	return F, nil
+}
@@ -0,0 +1,34 @@
+package stager
+
+import (
+	"time"
+)
+
+type StagerConfig struct {
+	Stager string     `mapstructure:"APP_STAGER" validate:"required"`
+	Mem    MemConfig  `mapstructure:",squash"`
+	Disk   DiskConfig `mapstructure:",squash"`
+}
+
+type MemConfig struct {
+	TimeToLive      time.Duration `mapstructure:"STAGER_TIME_TO_LIVE"      validate:"required"`
+	CleanupInterval time.Duration `mapstructure:"STAGER_CLEANUP_INTERVAL" validate:"required"`
+}
+
+type DiskConfig struct {
+	Dir             string        `mapstructure:"STAGER_DISK_DIR"          validate:"required"`
+	TimeToLive      time.Duration `mapstructure:"STAGER_TIME_TO_LIVE"      validate:"required"`
+	CleanupInterval time.Duration `mapstructure:"STAGER_CLEANUP_INTERVAL" validate:"required"`
+}
+
+var (
+	DefaultMemConfig = MemConfig{
+		TimeToLive:      1 * time.Second,
+		CleanupInterval: 1 * time.Second,
+	}
+	DefaultDiskConfig = DiskConfig{
+		Dir:             "",
+		TimeToLive:      1 * time.Second,
+		CleanupInterval: 1 * time.Second,
+	}
+)
@@ -0,0 +1,251 @@
+package stager
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/base64"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/rand"
+)
+
+var (
+	_ domain.Stager = (*Disk)(nil)
+)
+
+func NewDisk(conf DiskConfig) (*Disk, error) {
+	err := os.MkdirAll(conf.Dir, 0o700)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &Disk{conf: conf, busy: map[uint64]bool{}}, nil
+}
+
+// Disk keeps every upload as two files, the data as it arrives and a
+// description next to it, the offset is the size of the data file
+type Disk struct {
+	conf DiskConfig
+	mu   sync.Mutex
+	busy map[uint64]bool
+}
+
+type diskUpload struct {
+	Size    int64     `json:"size"`
+	Name    string    `json:"name"`
+	Expires time.Time `json:"expires"`
+}
+
+func (d *Disk) Monitor(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			d.cleanup(time.Now())
+			time.Sleep(d.conf.CleanupInterval)
+		}
+	}()
+}
+
+// cleanup removes the uploads that expired before now, the description
+// goes last so an interrupted removal is picked up again
+func (d *Disk) cleanup(now time.Time) {
+	entries, err := os.ReadDir(d.conf.Dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		upload, err := base64.ToUint64(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		d.mu.Lock()
+		if d.busy[upload] {
+			d.mu.Unlock()
+			continue
+		}
+		du, err := d.readInfo(upload)
+		if err == nil && !now.Before(du.Expires) {
+			_ = d.remove(upload)
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (d *Disk) Create(_ context.Context, size int64, name string) (model.Upload, error) {
+	id, err := rand.Id()
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	upl := model.Upload{Id: id, Size: size, Name: name, Expires: time.Now().Add(d.conf.TimeToLive)}
+	f, err := os.OpenFile(d.data(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	b, err := json.Marshal(diskUpload{upl.Size, upl.Name, upl.Expires})
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	err = os.WriteFile(d.info(id), b, 0o600)
+	if err != nil {
+		_ = os.Remove(d.data(id))
+		return model.Upload{}, errors.Wrap(err)
+	}
+	return upl, nil
+}
+
+func (d *Disk) Append(_ context.Context, upload uint64, offset int64, r io.Reader) (int64, error) {
+	d.mu.Lock()
+	if d.busy[upload] {
+		d.mu.Unlock()
+		return 0, errors.Wrap(domain.ErrUploadLocked)
+	}
+	upl, err := d.read(upload)
+	if err != nil {
+		d.mu.Unlock()
+		return 0, errors.Wrap(err)
+	}
+	if offset != upl.Offset {
+		d.mu.Unlock()
+		return 0, errors.Wrap(domain.ErrUploadOffset)
+	}
+	d.busy[upload] = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.busy, upload)
+		d.mu.Unlock()
+	}()
+	f, err := os.OpenFile(d.data(upload), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	n, err := io.Copy(f, io.LimitReader(r, upl.Size-offset))
+	errClose := f.Close()
+	if err != nil {
+		return offset + n, errors.Wrap(err)
+	}
+	if errClose != nil {
+		return offset + n, errors.Wrap(errClose)
+	}
+	return offset + n, nil
+}
+
+func (d *Disk) Upload(_ context.Context, upload uint64) (model.Upload, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	upl, err := d.read(upload)
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	return upl, nil
+}
+
+func (d *Disk) Open(_ context.Context, upload uint64) (model.File, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	upl, err := d.read(upload)
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
+	if d.busy[upload] || upl.Offset < upl.Size {
+		return model.File{}, errors.Wrap(domain.ErrUploadIncomplete)
+	}
+	f, err := os.Open(d.data(upload))
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
+	F := model.NewFile(f, f.Close, upl.Size)
+	F.Name = upl.Name
+	return F, nil
+}
+
+func (d *Disk) Remove(_ context.Context, upload uint64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.busy[upload] {
+		return errors.Wrap(domain.ErrUploadLocked)
+	}
+	_, err := d.read(upload)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	err = d.remove(upload)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// read treats an expired upload as gone even before it is cleaned up
+func (d *Disk) read(upload uint64) (model.Upload, error) {
+	du, err := d.readInfo(upload)
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	if !time.Now().Before(du.Expires) {
+		return model.Upload{}, errors.Wrap(domain.ErrUploadNotFound)
+	}
+	fi, err := os.Stat(d.data(upload))
+	if errors.Is(err, fs.ErrNotExist) {
+		return model.Upload{}, errors.Wrap(domain.ErrUploadNotFound)
+	}
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	return model.Upload{Id: upload, Size: du.Size, Offset: fi.Size(), Name: du.Name, Expires: du.Expires}, nil
+}
+
+func (d *Disk) readInfo(upload uint64) (diskUpload, error) {
+	b, err := os.ReadFile(d.info(upload))
+	if errors.Is(err, fs.ErrNotExist) {
+		return diskUpload{}, errors.Wrap(domain.ErrUploadNotFound)
+	}
+	if err != nil {
+		return diskUpload{}, errors.Wrap(err)
+	}
+	du := diskUpload{}
+	err = json.Unmarshal(b, &du)
+	if err != nil {
+		return diskUpload{}, errors.Wrap(err)
+	}
+	return du, nil
+}
+
+func (d *Disk) remove(upload uint64) error {
+	err := os.Remove(d.data(upload))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err)
+	}
+	err = os.Remove(d.info(upload))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (d *Disk) data(upload uint64) string {
+	return filepath.Join(d.conf.Dir, base64.FromUint64(upload))
+}
+
+func (d *Disk) info(upload uint64) string {
+	return filepath.Join(d.conf.Dir, base64.FromUint64(upload)+".json")
+}
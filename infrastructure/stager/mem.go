@@ -0,0 +1,149 @@
+package stager
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
+	"github.com/zitryss/aye-and-nay/pkg/errors"
+	"github.com/zitryss/aye-and-nay/pkg/rand"
+)
+
+var (
+	_ domain.Stager = (*Mem)(nil)
+)
+
+func NewMem(conf MemConfig) *Mem {
+	return &Mem{
+		conf:    conf,
+		uploads: map[uint64]*memUpload{},
+	}
+}
+
+type Mem struct {
+	conf    MemConfig
+	mu      sync.Mutex
+	uploads map[uint64]*memUpload
+}
+
+type memUpload struct {
+	upl  model.Upload
+	buf  []byte
+	busy bool
+}
+
+func (m *Mem) Monitor(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			m.cleanup(time.Now())
+			time.Sleep(m.conf.CleanupInterval)
+		}
+	}()
+}
+
+// cleanup drops the uploads that expired before now, an upload that is
+// being appended to is left for the next round
+func (m *Mem) cleanup(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, u := range m.uploads {
+		if !u.busy && !now.Before(u.upl.Expires) {
+			delete(m.uploads, id)
+		}
+	}
+}
+
+func (m *Mem) Create(_ context.Context, size int64, name string) (model.Upload, error) {
+	id, err := rand.Id()
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	upl := model.Upload{Id: id, Size: size, Name: name, Expires: time.Now().Add(m.conf.TimeToLive)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploads[id] = &memUpload{upl: upl}
+	return upl, nil
+}
+
+func (m *Mem) Append(_ context.Context, upload uint64, offset int64, r io.Reader) (int64, error) {
+	m.mu.Lock()
+	u, err := m.get(upload)
+	if err != nil {
+		m.mu.Unlock()
+		return 0, errors.Wrap(err)
+	}
+	if u.busy {
+		m.mu.Unlock()
+		return 0, errors.Wrap(domain.ErrUploadLocked)
+	}
+	if offset != u.upl.Offset {
+		m.mu.Unlock()
+		return 0, errors.Wrap(domain.ErrUploadOffset)
+	}
+	u.busy = true
+	m.mu.Unlock()
+	b, err := io.ReadAll(io.LimitReader(r, u.upl.Size-offset))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u.busy = false
+	u.buf = append(u.buf, b...)
+	u.upl.Offset += int64(len(b))
+	if err != nil {
+		return u.upl.Offset, errors.Wrap(err)
+	}
+	return u.upl.Offset, nil
+}
+
+func (m *Mem) Upload(_ context.Context, upload uint64) (model.Upload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, err := m.get(upload)
+	if err != nil {
+		return model.Upload{}, errors.Wrap(err)
+	}
+	return u.upl, nil
+}
+
+func (m *Mem) Open(_ context.Context, upload uint64) (model.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, err := m.get(upload)
+	if err != nil {
+		return model.File{}, errors.Wrap(err)
+	}
+	if u.busy || u.upl.Offset < u.upl.Size {
+		return model.File{}, errors.Wrap(domain.ErrUploadIncomplete)
+	}
+	f := model.NewFile(bytes.NewReader(u.buf), nil, u.upl.Size)
+	f.Name = u.upl.Name
+	return f, nil
+}
+
+func (m *Mem) Remove(_ context.Context, upload uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.get(upload)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	delete(m.uploads, upload)
+	return nil
+}
+
+// get treats an expired upload as gone even before it is cleaned up
+func (m *Mem) get(upload uint64) (*memUpload, error) {
+	u, ok := m.uploads[upload]
+	if !ok || !time.Now().Before(u.upl.Expires) {
+		return nil, errors.Wrap(domain.ErrUploadNotFound)
+	}
+	return u, nil
+}
@@ -0,0 +1,27 @@
+package stager
+
+import (
+	"context"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+)
+
+func New(ctx context.Context, conf StagerConfig) (domain.Stager, error) {
+	switch conf.Stager {
+	case "disk":
+		disk, err := NewDisk(conf.Disk)
+		if err != nil {
+			return nil, err
+		}
+		disk.Monitor(ctx)
+		return disk, nil
+	case "mem":
+		mem := NewMem(conf.Mem)
+		mem.Monitor(ctx)
+		return mem, nil
+	default:
+		mem := NewMem(conf.Mem)
+		mem.Monitor(ctx)
+		return mem, nil
+	}
+}
@@ -0,0 +1,157 @@
+package stager
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zitryss/aye-and-nay/domain/domain"
+)
+
+var (
+	unit = flag.Bool("unit", false, "")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestMem(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	conf := DefaultMemConfig
+	conf.TimeToLive = time.Hour
+	testStager(t, func() (domain.Stager, func(time.Time)) {
+		mem := NewMem(conf)
+		return mem, mem.cleanup
+	})
+}
+
+func TestDisk(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	conf := DefaultDiskConfig
+	conf.TimeToLive = time.Hour
+	testStager(t, func() (domain.Stager, func(time.Time)) {
+		conf := conf
+		conf.Dir = t.TempDir()
+		disk, err := NewDisk(conf)
+		require.NoError(t, err)
+		return disk, disk.cleanup
+	})
+}
+
+func testStager(t *testing.T, newStager func() (domain.Stager, func(time.Time))) {
+	ctx := context.Background()
+	t.Run("Positive", func(t *testing.T) {
+		st, _ := newStager()
+		upl, err := st.Create(ctx, 10, "alan.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), upl.Size)
+		assert.Equal(t, int64(0), upl.Offset)
+		offset, err := st.Append(ctx, upl.Id, 0, strings.NewReader("0123"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), offset)
+		upl, err = st.Upload(ctx, upl.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), upl.Offset)
+		assert.Equal(t, "alan.jpg", upl.Name)
+		_, err = st.Open(ctx, upl.Id)
+		assert.ErrorIs(t, err, domain.ErrUploadIncomplete)
+		offset, err = st.Append(ctx, upl.Id, 4, strings.NewReader("456789abc"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), offset)
+		f, err := st.Open(ctx, upl.Id)
+		require.NoError(t, err)
+		b, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		assert.Equal(t, "0123456789", string(b))
+		assert.Equal(t, int64(10), f.Size)
+		assert.Equal(t, "alan.jpg", f.Name)
+		err = st.Remove(ctx, upl.Id)
+		assert.NoError(t, err)
+		_, err = st.Upload(ctx, upl.Id)
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+	})
+	t.Run("PositiveInterrupted", func(t *testing.T) {
+		st, _ := newStager()
+		upl, err := st.Create(ctx, 10, "")
+		require.NoError(t, err)
+		r := io.MultiReader(strings.NewReader("012"), iotest.ErrReader(io.ErrUnexpectedEOF))
+		offset, err := st.Append(ctx, upl.Id, 0, r)
+		assert.Error(t, err)
+		assert.Equal(t, int64(3), offset)
+		upl, err = st.Upload(ctx, upl.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), upl.Offset)
+		offset, err = st.Append(ctx, upl.Id, 3, bytes.NewReader([]byte("3456789")))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), offset)
+	})
+	t.Run("NegativeOffset", func(t *testing.T) {
+		st, _ := newStager()
+		upl, err := st.Create(ctx, 10, "")
+		require.NoError(t, err)
+		_, err = st.Append(ctx, upl.Id, 0, strings.NewReader("0123"))
+		assert.NoError(t, err)
+		_, err = st.Append(ctx, upl.Id, 0, strings.NewReader("0123"))
+		assert.ErrorIs(t, err, domain.ErrUploadOffset)
+		_, err = st.Append(ctx, upl.Id, 5, strings.NewReader("0123"))
+		assert.ErrorIs(t, err, domain.ErrUploadOffset)
+	})
+	t.Run("NegativeLocked", func(t *testing.T) {
+		st, _ := newStager()
+		upl, err := st.Create(ctx, 10, "")
+		require.NoError(t, err)
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = st.Append(ctx, upl.Id, 0, pr)
+		}()
+		_, err = pw.Write([]byte("01"))
+		require.NoError(t, err)
+		_, err = st.Append(ctx, upl.Id, 0, strings.NewReader("0123"))
+		assert.ErrorIs(t, err, domain.ErrUploadLocked)
+		_, err = st.Open(ctx, upl.Id)
+		assert.ErrorIs(t, err, domain.ErrUploadIncomplete)
+		_ = pw.Close()
+		<-done
+	})
+	t.Run("NegativeNotFound", func(t *testing.T) {
+		st, _ := newStager()
+		_, err := st.Upload(ctx, 0xB2E6)
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+		_, err = st.Append(ctx, 0xB2E6, 0, strings.NewReader("0123"))
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+		_, err = st.Open(ctx, 0xB2E6)
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+		err = st.Remove(ctx, 0xB2E6)
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+	})
+	t.Run("NegativeExpired", func(t *testing.T) {
+		st, cleanup := newStager()
+		upl1, err := st.Create(ctx, 10, "")
+		require.NoError(t, err)
+		cleanup(upl1.Expires.Add(-time.Second))
+		_, err = st.Upload(ctx, upl1.Id)
+		assert.NoError(t, err)
+		cleanup(upl1.Expires)
+		_, err = st.Upload(ctx, upl1.Id)
+		assert.ErrorIs(t, err, domain.ErrUploadNotFound)
+	})
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	minioS3 "github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/domain/model"
@@ -28,7 +32,11 @@ func NewMinio(ctx context.Context, conf MinioConfig) (*Minio, error) {
 	if err != nil {
 		return &Minio{}, errors.Wrap(err)
 	}
-	m := &Minio{conf, client}
+	public, prefix, err := newPublic(conf)
+	if err != nil {
+		return &Minio{}, errors.Wrap(err)
+	}
+	m := &Minio{conf, client, public, prefix}
 	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
 	defer cancel()
 	err = retry.Do(conf.RetryTimes, conf.RetryPause, func() error {
@@ -56,12 +64,73 @@ func NewMinio(ctx context.Context, conf MinioConfig) (*Minio, error) {
 			return &Minio{}, errors.Wrap(err)
 		}
 	}
+	err = m.lifecycle(ctx)
+	if err != nil {
+		return &Minio{}, errors.Wrap(err)
+	}
 	return m, nil
 }
 
+// newPublic builds the client that signs urls for the address the clients
+// see, signing needs no connection as long as the region is known
+func newPublic(conf MinioConfig) (*minioS3.Client, string, error) {
+	if conf.PublicUrl == "" {
+		conf.PublicUrl = "http://" + conf.Host + ":" + conf.Port
+		if conf.Secure {
+			conf.PublicUrl = "https://" + conf.Host + ":" + conf.Port
+		}
+	}
+	u, err := url.Parse(conf.PublicUrl)
+	if err != nil {
+		return nil, "", errors.Wrap(err)
+	}
+	client, err := minioS3.New(u.Host, &minioS3.Options{
+		Creds:  credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, conf.Token),
+		Secure: u.Scheme == "https",
+		Region: conf.Location,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err)
+	}
+	return client, strings.TrimSuffix(u.Path, "/"), nil
+}
+
+// lifecycle drops the direct uploads that were never turned into an album,
+// a day is far longer than any presigned form stays valid, the rule is set
+// on every start so that a bucket made before it existed gets it too, the
+// other rules of the bucket are kept
+func (m *Minio) lifecycle(ctx context.Context) error {
+	conf, err := m.client.GetBucketLifecycle(ctx, "aye-and-nay")
+	if minioS3.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+		conf, err = lifecycle.NewConfiguration(), nil
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	rules := make([]lifecycle.Rule, 0, len(conf.Rules)+1)
+	for _, rule := range conf.Rules {
+		if rule.ID != "direct" {
+			rules = append(rules, rule)
+		}
+	}
+	conf.Rules = append(rules, lifecycle.Rule{
+		ID:         "direct",
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: "direct/"},
+		Expiration: lifecycle.Expiration{Days: 1},
+	})
+	err = m.client.SetBucketLifecycle(ctx, "aye-and-nay", conf)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 type Minio struct {
 	conf   MinioConfig
 	client *minioS3.Client
+	public *minioS3.Client
+	prefix string
 }
 
 func (m *Minio) Put(ctx context.Context, album uint64, image uint64, f model.File) (string, error) {
@@ -113,6 +182,94 @@ func (m *Minio) Remove(ctx context.Context, album uint64, image uint64) error {
 	return nil
 }
 
+// PresignPost signs the form for the address the clients see, a reverse
+// proxy in front of the storage passes the path on without the prefix, the
+// policy makes the storage itself refuse an image of more than maxSize
+func (m *Minio) PresignPost(ctx context.Context, direct uint64, maxSize int64) (string, map[string]string, time.Time, error) {
+	filename := "direct/" + base64.FromUint64(direct)
+	expires := time.Now().Add(m.conf.PresignExpiry)
+	policy := minioS3.NewPostPolicy()
+	err := policy.SetBucket("aye-and-nay")
+	if err != nil {
+		return "", nil, time.Time{}, errors.Wrap(err)
+	}
+	err = policy.SetKey(filename)
+	if err != nil {
+		return "", nil, time.Time{}, errors.Wrap(err)
+	}
+	err = policy.SetExpires(expires)
+	if err != nil {
+		return "", nil, time.Time{}, errors.Wrap(err)
+	}
+	err = policy.SetContentLengthRange(1, maxSize)
+	if err != nil {
+		return "", nil, time.Time{}, errors.Wrap(err)
+	}
+	u, fields, err := m.public.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, time.Time{}, errors.Wrap(err)
+	}
+	u.Path = m.prefix + u.Path
+	return u.String(), fields, expires, nil
+}
+
+// StatDirect returns the size of a direct upload and no more than its first
+// 512 bytes, enough to tell an image, the rest never leaves the storage
+func (m *Minio) StatDirect(ctx context.Context, direct uint64) (int64, []byte, error) {
+	filename := "direct/" + base64.FromUint64(direct)
+	info, err := m.client.StatObject(ctx, "aye-and-nay", filename, minioS3.StatObjectOptions{})
+	if minioS3.ToErrorResponse(err).Code == "NoSuchKey" {
+		return 0, nil, errors.Wrap(domain.ErrDirectNotFound)
+	}
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	opts := minioS3.GetObjectOptions{}
+	err = opts.SetRange(0, 511)
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	obj, err := m.client.GetObject(ctx, "aye-and-nay", filename, opts)
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	defer obj.Close()
+	head, err := io.ReadAll(io.LimitReader(obj, 512))
+	if minioS3.ToErrorResponse(err).Code == "NoSuchKey" {
+		return 0, nil, errors.Wrap(domain.ErrDirectNotFound)
+	}
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	return info.Size, head, nil
+}
+
+// CopyDirect moves a direct upload into an album within the storage
+func (m *Minio) CopyDirect(ctx context.Context, direct uint64, album uint64, image uint64) (string, error) {
+	albumB64 := base64.FromUint64(album)
+	imageB64 := base64.FromUint64(image)
+	filename := "albums/" + albumB64 + "/images/" + imageB64
+	dst := minioS3.CopyDestOptions{Bucket: "aye-and-nay", Object: filename}
+	src := minioS3.CopySrcOptions{Bucket: "aye-and-nay", Object: "direct/" + base64.FromUint64(direct)}
+	_, err := m.client.CopyObject(ctx, dst, src)
+	if minioS3.ToErrorResponse(err).Code == "NoSuchKey" {
+		return "", errors.Wrap(domain.ErrDirectNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return m.conf.Prefix + "/aye-and-nay/" + filename, nil
+}
+
+func (m *Minio) RemoveDirect(ctx context.Context, direct uint64) error {
+	filename := "direct/" + base64.FromUint64(direct)
+	err := m.client.RemoveObject(ctx, "aye-and-nay", filename, minioS3.RemoveObjectOptions{})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 func (m *Minio) Health(ctx context.Context) (bool, error) {
 	url := "http://" + m.conf.Host + ":" + m.conf.Port + "/minio/health/live"
 	body := io.Reader(nil)
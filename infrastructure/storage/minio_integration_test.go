@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
 	"testing"
+	"time"
 
 	minioS3 "github.com/minio/minio-go/v7"
 	"github.com/stretchr/testify/assert"
@@ -10,6 +15,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
+	"github.com/zitryss/aye-and-nay/domain/model"
 	. "github.com/zitryss/aye-and-nay/internal/generator"
 	. "github.com/zitryss/aye-and-nay/internal/testing"
 )
@@ -96,6 +102,83 @@ func (suite *MinioTestSuite) TestMinio() {
 	})
 }
 
+func (suite *MinioTestSuite) TestMinioDirect() {
+	post := func(t *testing.T, url string, fields map[string]string, f model.File) int {
+		body := bytes.Buffer{}
+		mw := multipart.NewWriter(&body)
+		for k, v := range fields {
+			require.NoError(t, mw.WriteField(k, v))
+		}
+		w, err := mw.CreateFormFile("file", "image")
+		require.NoError(t, err)
+		_, err = io.Copy(w, f.Reader)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		req, err := http.NewRequestWithContext(suite.ctx, http.MethodPost, url, &body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	suite.T().Run("Positive", func(t *testing.T) {
+		suite.setupTestFn()
+		id, ids := GenId()
+		direct := id()
+		_, _, err := suite.storage.StatDirect(suite.ctx, direct)
+		assert.ErrorIs(t, err, domain.ErrDirectNotFound)
+		url, fields, expires, err := suite.storage.PresignPost(suite.ctx, direct, Png().Size)
+		require.NoError(t, err)
+		assert.True(t, expires.After(time.Now()))
+		code := post(t, url, fields, Png())
+		assert.Equal(t, http.StatusNoContent, code)
+		size, head, err := suite.storage.StatDirect(suite.ctx, direct)
+		assert.NoError(t, err)
+		assert.Equal(t, Png().Size, size)
+		assert.Equal(t, "image/png", http.DetectContentType(head))
+		album := id()
+		image := id()
+		src, err := suite.storage.CopyDirect(suite.ctx, direct, album, image)
+		assert.NoError(t, err)
+		assert.Equal(t, "/aye-and-nay/albums/"+ids.Base64(1)+"/images/"+ids.Base64(2), src)
+		f, err := suite.storage.Get(suite.ctx, album, image)
+		assert.NoError(t, err)
+		AssertEqualFile(t, f, Png())
+		_ = f.Close()
+		err = suite.storage.RemoveDirect(suite.ctx, direct)
+		assert.NoError(t, err)
+		_, _, err = suite.storage.StatDirect(suite.ctx, direct)
+		assert.ErrorIs(t, err, domain.ErrDirectNotFound)
+	})
+	suite.T().Run("Negative", func(t *testing.T) {
+		suite.setupTestFn()
+		id, _ := GenId()
+		direct := id()
+		url, fields, _, err := suite.storage.PresignPost(suite.ctx, direct, Png().Size-1)
+		require.NoError(t, err)
+		code := post(t, url, fields, Png())
+		assert.Equal(t, http.StatusBadRequest, code)
+		_, _, err = suite.storage.StatDirect(suite.ctx, direct)
+		assert.ErrorIs(t, err, domain.ErrDirectNotFound)
+	})
+}
+
+func (suite *MinioTestSuite) TestMinioLifecycle() {
+	suite.T().Run("", func(t *testing.T) {
+		suite.setupTestFn()
+		m := suite.storage.(*Minio)
+		err := m.lifecycle(suite.ctx)
+		assert.NoError(t, err)
+		err = m.lifecycle(suite.ctx)
+		assert.NoError(t, err)
+		conf, err := m.client.GetBucketLifecycle(suite.ctx, "aye-and-nay")
+		require.NoError(t, err)
+		require.Len(t, conf.Rules, 1)
+		assert.Equal(t, "direct", conf.Rules[0].ID)
+	})
+}
+
 func (suite *MinioTestSuite) TestMinioHealth() {
 	_, err := suite.storage.Health(suite.ctx)
 	assert.NoError(suite.T(), err)
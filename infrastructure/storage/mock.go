@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/domain/model"
@@ -51,6 +52,36 @@ func (m *Mock) Remove(_ context.Context, _ uint64, _ uint64) error {
 	return nil
 }
 
+func (m *Mock) PresignPost(_ context.Context, direct uint64, _ int64) (string, map[string]string, time.Time, error) {
+	directB64 := base64.FromUint64(direct)
+	fields := map[string]string{"key": "direct/" + directB64}
+	return "/aye-and-nay", fields, time.Now().Add(DefaultMinioConfig.PresignExpiry), nil
+}
+
+func (m *Mock) StatDirect(ctx context.Context, direct uint64) (int64, []byte, error) {
+	f, err := m.Get(ctx, 0, direct)
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	defer f.Close()
+	head, err := io.ReadAll(io.LimitReader(f.Reader, 512))
+	if err != nil {
+		return 0, nil, errors.Wrap(err)
+	}
+	return f.Size, head, nil
+}
+
+func (m *Mock) CopyDirect(_ context.Context, _ uint64, album uint64, image uint64) (string, error) {
+	albumB64 := base64.FromUint64(album)
+	imageB64 := base64.FromUint64(image)
+	src := "/aye-and-nay/albums/" + albumB64 + "/images/" + imageB64
+	return src, nil
+}
+
+func (m *Mock) RemoveDirect(_ context.Context, _ uint64) error {
+	return nil
+}
+
 func (m *Mock) Health(_ context.Context) (bool, error) {
 	return true, nil
 }
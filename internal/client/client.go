@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -307,6 +308,55 @@ func (c *Client) Top(album string) ([]string, error) {
 	return src, nil
 }
 
+func (c *Client) Import(album string, owner string, filename string) (int, error) {
+	ct := ""
+	switch filepath.Ext(filename) {
+	case ".csv":
+		ct = "text/csv"
+	case ".jsonl", ".ndjson":
+		ct = "application/x-ndjson"
+	default:
+		return 0, errors.Wrap(errors.New("file extension: expected = .csv, .jsonl or .ndjson, actual = " + filepath.Ext(filename)))
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	defer f.Close()
+	req, err := http.NewRequest(http.MethodPost, c.apiAddress+"/api/albums/"+album+"/import/", f)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	req.Header.Set("Content-Type", ct)
+	req.Header.Set("X-Owner-Token", owner)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return 0, errors.Wrap(errors.New("response status code: expected = 2xx, actual = " + strconv.Itoa(resp.StatusCode)))
+	}
+
+	type result struct {
+		Album struct {
+			Votes int
+		}
+	}
+
+	res := result{}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	return res.Album.Votes, nil
+}
+
 func (c *Client) Health() error {
 	req, err := http.NewRequest(http.MethodGet, c.apiAddress+"/api/health/", http.NoBody)
 	if err != nil {
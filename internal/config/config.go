@@ -10,11 +10,14 @@ import (
 	"github.com/radovskyb/watcher"
 	"github.com/spf13/viper"
 
+	"github.com/zitryss/aye-and-nay/delivery/grpc"
 	"github.com/zitryss/aye-and-nay/delivery/http"
 	"github.com/zitryss/aye-and-nay/domain/service"
 	"github.com/zitryss/aye-and-nay/infrastructure/cache"
 	"github.com/zitryss/aye-and-nay/infrastructure/compressor"
 	"github.com/zitryss/aye-and-nay/infrastructure/database"
+	"github.com/zitryss/aye-and-nay/infrastructure/fetcher"
+	"github.com/zitryss/aye-and-nay/infrastructure/stager"
 	"github.com/zitryss/aye-and-nay/infrastructure/storage"
 	"github.com/zitryss/aye-and-nay/internal/log"
 	"github.com/zitryss/aye-and-nay/pkg/errors"
@@ -28,6 +31,10 @@ func New(path string) (Config, error) {
 	if err != nil {
 		return Config{}, errors.Wrap(err)
 	}
+	err = deprecated()
+	if err != nil {
+		return Config{}, errors.Wrap(err)
+	}
 	fillGaps(&conf)
 	err = validator.New().Struct(conf)
 	if err != nil {
@@ -43,11 +50,14 @@ type Config struct {
 	App            AppConfig                   `mapstructure:",squash"`
 	Server         http.ServerConfig           `mapstructure:",squash"`
 	Middleware     http.MiddlewareConfig       `mapstructure:",squash"`
+	Grpc           grpc.ServerConfig           `mapstructure:",squash"`
 	Service        service.ServiceConfig       `mapstructure:",squash"`
 	Cache          cache.CacheConfig           `mapstructure:",squash"`
 	Compressor     compressor.CompressorConfig `mapstructure:",squash"`
 	Database       database.DatabaseConfig     `mapstructure:",squash"`
 	Storage        storage.StorageConfig       `mapstructure:",squash"`
+	Fetcher        fetcher.FetcherConfig       `mapstructure:",squash"`
+	Stager         stager.StagerConfig         `mapstructure:",squash"`
 }
 
 type AppConfig struct {
@@ -133,3 +143,12 @@ func fillGaps(conf *Config) {
 		conf.Database.Badger.Compressed = true
 	}
 }
+
+// deprecated refuses the keys that were renamed and whose values mean
+// something else now, a silent zero value would be worse than no start
+func deprecated() error {
+	if viper.IsSet("SERVICE_ACCURACY") {
+		return errors.New("SERVICE_ACCURACY is no longer supported, set SERVICE_TOLERANCE to the convergence threshold of PageRank instead, e.g. 0.000001")
+	}
+	return nil
+}
@@ -35,14 +35,14 @@ func AlbumFactory(id IdGenFunc, ids Ids) model.Album {
 	img4 := model.Image{Id: id(), Src: "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(4), Rating: 0.77920413}
 	img5 := model.Image{Id: id(), Src: "/aye-and-nay/albums/" + ids.Base64(0) + "/images/" + ids.Base64(5), Rating: 0.13278389}
 	imgs := []model.Image{img1, img2, img3, img4, img5}
-	edgs := map[uint64]map[uint64]int{}
-	edgs[ids.Uint64(1)] = map[uint64]int{}
-	edgs[ids.Uint64(2)] = map[uint64]int{}
-	edgs[ids.Uint64(3)] = map[uint64]int{}
-	edgs[ids.Uint64(4)] = map[uint64]int{}
-	edgs[ids.Uint64(5)] = map[uint64]int{}
+	edgs := map[uint64]map[uint64]float64{}
+	edgs[ids.Uint64(1)] = map[uint64]float64{}
+	edgs[ids.Uint64(2)] = map[uint64]float64{}
+	edgs[ids.Uint64(3)] = map[uint64]float64{}
+	edgs[ids.Uint64(4)] = map[uint64]float64{}
+	edgs[ids.Uint64(5)] = map[uint64]float64{}
 	expires := time.Time{}
-	alb := model.Album{album, imgs, edgs, expires}
+	alb := model.Album{Id: album, Images: imgs, Edges: edgs, Expires: expires}
 	return alb
 }
 
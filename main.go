@@ -12,12 +12,15 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/zitryss/aye-and-nay/delivery/grpc"
 	"github.com/zitryss/aye-and-nay/delivery/http"
 	"github.com/zitryss/aye-and-nay/domain/domain"
 	"github.com/zitryss/aye-and-nay/domain/service"
 	"github.com/zitryss/aye-and-nay/infrastructure/cache"
 	"github.com/zitryss/aye-and-nay/infrastructure/compressor"
 	"github.com/zitryss/aye-and-nay/infrastructure/database"
+	"github.com/zitryss/aye-and-nay/infrastructure/fetcher"
+	"github.com/zitryss/aye-and-nay/infrastructure/stager"
 	"github.com/zitryss/aye-and-nay/infrastructure/storage"
 	"github.com/zitryss/aye-and-nay/internal/config"
 	"github.com/zitryss/aye-and-nay/internal/gctuner"
@@ -124,6 +127,15 @@ func main() {
 			continue
 		}
 
+		stag, err := stager.New(ctx, conf.Stager)
+		if err != nil {
+			log.Critical(context.Background(), "err", "stacktrace", err)
+			reload = true
+			stop()
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
 		qCalc := service.NewQueueCalc(cach)
 		qCalc.Monitor(ctx)
 
@@ -164,7 +176,17 @@ func main() {
 
 		middle := http.NewMiddleware(conf.Middleware, cach)
 		srvWait := make(chan error, 1)
-		srv, err := http.NewServer(conf.Server, middle.Chain, serv, srvWait)
+		fetch := fetcher.NewHttp(conf.Fetcher)
+		srv, err := http.NewServer(conf.Server, middle.Chain, serv, cach, fetch, stag, stor, srvWait)
+		if err != nil {
+			log.Critical(context.Background(), "err", "stacktrace", err)
+			reload = true
+			stop()
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		grpcWait := make(chan error, 1)
+		grpcSrv, err := grpc.NewServer(conf.Grpc, serv, cach, grpcWait)
 		if err != nil {
 			log.Critical(context.Background(), "err", "stacktrace", err)
 			reload = true
@@ -172,6 +194,14 @@ func main() {
 			time.Sleep(2 * time.Second)
 			continue
 		}
+		grpcSrv.Monitor(ctx)
+		log.Info(context.Background(), "starting grpc server")
+		go func() {
+			err := grpcSrv.Start()
+			if err != nil {
+				log.Error(context.Background(), "err", "stacktrace", err)
+			}
+		}()
 		srv.Monitor(ctx)
 		log.Info(context.Background(), "starting web server")
 		err = srv.Start()
@@ -185,6 +215,12 @@ func main() {
 			log.Error(context.Background(), "err", "stacktrace", err)
 		}
 
+		log.Info(context.Background(), "stopping grpc server")
+		err = <-grpcWait
+		if err != nil {
+			log.Error(context.Background(), "err", "stacktrace", err)
+		}
+
 		log.Info(context.Background(), "stopping deletion worker pool")
 		err = gDel.Wait()
 		if err != nil {
@@ -1,6 +1,14 @@
 package linalg
 
-func PageRank(graph map[uint64]map[uint64]int, accuracy float64) map[uint64]float64 {
+import (
+	"math"
+)
+
+const (
+	maxIterations = 1000
+)
+
+func PageRank(graph map[uint64]map[uint64]float64, accuracy float64) map[uint64]float64 {
 	// n - amount of vertices
 	n := len(graph)
 
@@ -95,3 +103,124 @@ func PageRank(graph map[uint64]map[uint64]int, accuracy float64) map[uint64]floa
 	}
 	return res
 }
+
+func PageRankSparse(graph map[uint64]map[uint64]float64, tolerance float64) map[uint64]float64 {
+	return PageRankSparseFrom(graph, tolerance, nil)
+}
+
+func PageRankSparseFrom(graph map[uint64]map[uint64]float64, tolerance float64, prev map[uint64]float64) map[uint64]float64 {
+	idToIndex := make(map[uint64]int, len(graph))
+	indexToId := make([]uint64, 0, len(graph))
+	for id1 := range graph {
+		if _, ok := idToIndex[id1]; !ok {
+			idToIndex[id1] = len(indexToId)
+			indexToId = append(indexToId, id1)
+		}
+		for id2 := range graph[id1] {
+			if _, ok := idToIndex[id2]; !ok {
+				idToIndex[id2] = len(indexToId)
+				indexToId = append(indexToId, id2)
+			}
+		}
+	}
+	// n - amount of vertices
+	n := len(indexToId)
+	if n == 0 {
+		return map[uint64]float64{}
+	}
+
+	// s - out-degree weighted by the amount of votes
+	s := make([]float64, n)
+	// in - incoming edges in compressed sparse row format
+	inStart := make([]int, n+1)
+	for id1 := range graph {
+		for id2, w := range graph[id1] {
+			if w <= 0 {
+				continue
+			}
+			from := idToIndex[id1]
+			to := idToIndex[id2]
+			s[from] += float64(w)
+			inStart[to+1]++
+		}
+	}
+	for i := 0; i < n; i++ {
+		inStart[i+1] += inStart[i]
+	}
+	inFrom := make([]int, inStart[n])
+	inWeight := make([]float64, inStart[n])
+	pos := make([]int, n)
+	copy(pos, inStart[:n])
+	for id1 := range graph {
+		for id2, w := range graph[id1] {
+			if w <= 0 {
+				continue
+			}
+			from := idToIndex[id1]
+			to := idToIndex[id2]
+			inFrom[pos[to]] = from
+			inWeight[pos[to]] = float64(w) / s[from]
+			pos[to]++
+		}
+	}
+	// dangling - vertices without outgoing edges
+	dangling := []int(nil)
+	for i := 0; i < n; i++ {
+		if s[i] == 0 {
+			dangling = append(dangling, i)
+		}
+	}
+
+	// p - damping factor
+	p := 0.15
+
+	// v - significance vector, warm started from the previous one if possible
+	v := make([]float64, n)
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		r, ok := prev[indexToId[i]]
+		if !ok || r <= 0 {
+			r = 1 / float64(n)
+		}
+		v[i] = r
+		sum += r
+	}
+	for i := 0; i < n; i++ {
+		v[i] /= sum
+	}
+	vv := make([]float64, n)
+	for k := 0; k < maxIterations; k++ {
+		// the rank of dangling vertices is spread evenly across the graph
+		d := 0.0
+		for _, i := range dangling {
+			d += v[i]
+		}
+		base := p/float64(n) + (1-p)*d/float64(n)
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			r := 0.0
+			for e := inStart[j]; e < inStart[j+1]; e++ {
+				r += inWeight[e] * v[inFrom[e]]
+			}
+			vv[j] = base + (1-p)*r
+			sum += vv[j]
+		}
+		// delta - L1 distance between two consecutive iterations
+		delta := 0.0
+		for j := 0; j < n; j++ {
+			vv[j] /= sum
+			delta += math.Abs(vv[j] - v[j])
+		}
+		v, vv = vv, v
+		if delta < tolerance {
+			break
+		}
+	}
+
+	res := make(map[uint64]float64, n)
+	for index, rating := range v {
+		id := indexToId[index]
+		res[id] = rating
+	}
+	return res
+}
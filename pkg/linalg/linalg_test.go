@@ -23,12 +23,12 @@ func TestPageRank(t *testing.T) {
 		t.Skip()
 	}
 	t.Parallel()
-	edgs := map[uint64]map[uint64]int{}
-	edgs[0x5B92] = map[uint64]int{}
-	edgs[0x804F] = map[uint64]int{}
-	edgs[0xFB26] = map[uint64]int{}
-	edgs[0xF523] = map[uint64]int{}
-	edgs[0xFC63] = map[uint64]int{}
+	edgs := map[uint64]map[uint64]float64{}
+	edgs[0x5B92] = map[uint64]float64{}
+	edgs[0x804F] = map[uint64]float64{}
+	edgs[0xFB26] = map[uint64]float64{}
+	edgs[0xF523] = map[uint64]float64{}
+	edgs[0xFC63] = map[uint64]float64{}
 	edgs[0x804F][0x5B92]++
 	edgs[0xFB26][0x5B92]++
 	edgs[0xFB26][0x804F]++
@@ -49,14 +49,122 @@ func TestPageRank(t *testing.T) {
 	assert.InDeltaMapValues(t, want, got, TOLERANCE)
 }
 
+func TestPageRankSparse(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x5B92] = map[uint64]float64{}
+		edgs[0x804F] = map[uint64]float64{}
+		edgs[0xFB26] = map[uint64]float64{}
+		edgs[0xF523] = map[uint64]float64{}
+		edgs[0xFC63] = map[uint64]float64{}
+		edgs[0x804F][0x5B92]++
+		edgs[0xFB26][0x5B92]++
+		edgs[0xFB26][0x804F]++
+		edgs[0xF523][0x5B92]++
+		edgs[0xF523][0x804F]++
+		edgs[0xF523][0xFB26]++
+		edgs[0xFC63][0x5B92]++
+		edgs[0xFC63][0x804F]++
+		edgs[0xFC63][0xFB26]++
+		edgs[0xFC63][0xF523]++
+		got := linalg.PageRankSparse(edgs, 0.000000000001)
+		want := map[uint64]float64{}
+		want[0x5B92] = 0.4066324726629707
+		want[0x804F] = 0.2198013365744921
+		want[0xFB26] = 0.15424655198211576
+		want[0xF523] = 0.1201921184276656
+		want[0xFC63] = 0.0991275203527559
+		assert.InDeltaMapValues(t, want, got, 0.000000000001)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x3E3D] = map[uint64]float64{}
+		edgs[0xB399] = map[uint64]float64{}
+		edgs[0xDF8A] = map[uint64]float64{}
+		edgs[0x3E3D][0xB399]++
+		edgs[0xB399][0xDF8A]++
+		edgs[0xDF8A][0x3E3D]++
+		edgs[0xDF8A][0xB399]++
+		got := linalg.PageRankSparse(edgs, 0.000000000001)
+		want := linalg.PageRank(edgs, 10)
+		assert.InDeltaMapValues(t, want, got, 0.000000000001)
+	})
+	t.Run("Positive3", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x1A8C] = map[uint64]float64{}
+		edgs[0x7E0B] = map[uint64]float64{}
+		got := linalg.PageRankSparse(edgs, 0.000000000001)
+		want := map[uint64]float64{}
+		want[0x1A8C] = 0.5
+		want[0x7E0B] = 0.5
+		assert.InDeltaMapValues(t, want, got, TOLERANCE)
+	})
+	t.Run("Positive4", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		got := linalg.PageRankSparse(edgs, 0.000000000001)
+		assert.Empty(t, got)
+	})
+	t.Run("Positive5", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		for i := 0; i < 1000; i++ {
+			edgs[uint64(i)] = map[uint64]float64{}
+		}
+		for i := 0; i < 5000; i++ {
+			edgs[uint64(rand.Intn(1000))][uint64(rand.Intn(1000))]++
+		}
+		got := linalg.PageRankSparse(edgs, 0.000000000001)
+		sum := 0.0
+		for _, rating := range got {
+			sum += rating
+		}
+		assert.Len(t, got, 1000)
+		assert.InDelta(t, 1, sum, 0.000000001)
+	})
+}
+
+func TestPageRankSparseFrom(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	edgs := map[uint64]map[uint64]float64{}
+	edgs[0x5B92] = map[uint64]float64{}
+	edgs[0x804F] = map[uint64]float64{}
+	edgs[0xFB26] = map[uint64]float64{}
+	edgs[0x804F][0x5B92]++
+	edgs[0xFB26][0x5B92]++
+	edgs[0xFB26][0x804F]++
+	want := linalg.PageRankSparse(edgs, 0.000000000001)
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		got := linalg.PageRankSparseFrom(edgs, 0.000000000001, want)
+		assert.InDeltaMapValues(t, want, got, 0.000000001)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		prev := map[uint64]float64{}
+		prev[0x5B92] = 0.1
+		prev[0x804F] = 0.7
+		got := linalg.PageRankSparseFrom(edgs, 0.000000000001, prev)
+		assert.InDeltaMapValues(t, want, got, 0.000000001)
+	})
+}
+
 func BenchmarkPageRank(b *testing.B) {
 	for k := 0.2; k <= 1; k += 0.2 {
 		for i := 98; i <= 102; i++ {
 			b.Run(fmt.Sprintf("%f-%d", k, i), func(b *testing.B) {
-				edgs := map[uint64]map[uint64]int{}
+				edgs := map[uint64]map[uint64]float64{}
 				for j := 0; j < i; j++ {
 					node := uint64(j)
-					edgs[node] = map[uint64]int{}
+					edgs[node] = map[uint64]float64{}
 				}
 				for j := 0; j < i; j++ {
 					from := uint64(rand.Intn(i))
@@ -71,3 +179,30 @@ func BenchmarkPageRank(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkPageRankDenseVsSparse(b *testing.B) {
+	for _, n := range []int{100, 500, 1000, 2000} {
+		edgs := map[uint64]map[uint64]float64{}
+		for j := 0; j < n; j++ {
+			node := uint64(j)
+			edgs[node] = map[uint64]float64{}
+		}
+		for j := 0; j < 10*n; j++ {
+			from := uint64(rand.Intn(n))
+			to := uint64(rand.Intn(n))
+			edgs[from][to]++
+		}
+		b.Run(fmt.Sprintf("dense-%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for j := 0; j < b.N; j++ {
+				linalg.PageRank(edgs, 0.625)
+			}
+		})
+		b.Run(fmt.Sprintf("sparse-%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for j := 0; j < b.N; j++ {
+				linalg.PageRankSparse(edgs, 0.000001)
+			}
+		})
+	}
+}
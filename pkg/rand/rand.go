@@ -2,6 +2,7 @@ package rand
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/binary"
 )
 
@@ -13,3 +14,12 @@ func Id() (uint64, error) {
 	}
 	return binary.LittleEndian.Uint64(b), nil
 }
+
+func Token() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -21,3 +21,15 @@ func TestId(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Positive(t, got)
 }
+
+func TestToken(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	got1, err := Token()
+	assert.NoError(t, err)
+	assert.Len(t, got1, 43)
+	got2, err := Token()
+	assert.NoError(t, err)
+	assert.NotEqual(t, got1, got2)
+}
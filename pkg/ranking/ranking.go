@@ -0,0 +1,354 @@
+package ranking
+
+import (
+	"math"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	maxIterations = 1000
+)
+
+// Vote - a single comparison, a tie is two votes of half the weight
+type Vote struct {
+	Loser  uint64
+	Winner uint64
+	Weight float64
+}
+
+func Elo(graph map[uint64]map[uint64]float64, k float64) map[uint64]float64 {
+	ids := vertices(graph)
+	res := make(map[uint64]float64, len(ids))
+	for _, id := range ids {
+		res[id] = 1500
+	}
+	// the order of votes is lost, so they are replayed in a stable order
+	return EloUpdate(res, expand(graph, ids), k)
+}
+
+func EloUpdate(ratings map[uint64]float64, votes []Vote, k float64) map[uint64]float64 {
+	res := make(map[uint64]float64, len(ratings))
+	for id, rating := range ratings {
+		res[id] = rating
+	}
+	for _, vote := range votes {
+		loser, winner := vote.Loser, vote.Winner
+		if loser == winner || vote.Weight <= 0 {
+			continue
+		}
+		if _, ok := res[loser]; !ok {
+			res[loser] = 1500
+		}
+		if _, ok := res[winner]; !ok {
+			res[winner] = 1500
+		}
+		e := 1 / (1 + math.Pow(10, (res[loser]-res[winner])/400))
+		res[winner] += vote.Weight * k * (1 - e)
+		res[loser] -= vote.Weight * k * (1 - e)
+	}
+	return res
+}
+
+// Glicko2 returns the ratings and their deviations. The votes carry no
+// time, so they are spread evenly over a fixed number of rating periods,
+// in every period the opponents are rated as they stood after the
+// previous one.
+func Glicko2(graph map[uint64]map[uint64]float64, tau float64) (map[uint64]float64, map[uint64]float64) {
+	const (
+		scale      = 173.7178
+		rating     = 1500.0
+		deviation  = 350.0
+		volatility = 0.06
+		epsilon    = 0.000001
+		periods    = 10
+	)
+	ids := vertices(graph)
+	// games - amount of games between every two players per period,
+	// score - amount of wins of the first player over the second one
+	games := make(map[uint64]map[uint64]float64, len(ids))
+	score := make(map[uint64]map[uint64]float64, len(ids))
+	for _, id := range ids {
+		games[id] = map[uint64]float64{}
+		score[id] = map[uint64]float64{}
+	}
+	for loser := range graph {
+		for winner, w := range graph[loser] {
+			if w <= 0 || loser == winner {
+				continue
+			}
+			games[winner][loser] += w / periods
+			games[loser][winner] += w / periods
+			score[winner][loser] += w / periods
+		}
+	}
+	mu := make(map[uint64]float64, len(ids))
+	phi := make(map[uint64]float64, len(ids))
+	sigma := make(map[uint64]float64, len(ids))
+	for _, id := range ids {
+		mu[id] = (rating - 1500) / scale
+		phi[id] = deviation / scale
+		sigma[id] = volatility
+	}
+	g := func(phi float64) float64 {
+		return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+	}
+	for p := 0; p < periods; p++ {
+		muNext := make(map[uint64]float64, len(ids))
+		phiNext := make(map[uint64]float64, len(ids))
+		for _, id := range ids {
+			if len(games[id]) == 0 {
+				muNext[id] = mu[id]
+				phiNext[id] = math.Min(math.Sqrt(phi[id]*phi[id]+sigma[id]*sigma[id]), deviation/scale)
+				continue
+			}
+			// v - estimated variance based on game outcomes only,
+			// sum - outcomes weighed by the certainty of the opponents
+			v, sum := 0.0, 0.0
+			for opp, n := range games[id] {
+				gj := g(phi[opp])
+				e := 1 / (1 + math.Exp(-gj*(mu[id]-mu[opp])))
+				v += n * gj * gj * e * (1 - e)
+				sum += gj * (score[id][opp] - n*e)
+			}
+			v = 1 / v
+			// delta - estimated improvement in rating
+			delta := v * sum
+			phi2 := phi[id] * phi[id]
+			a := math.Log(sigma[id] * sigma[id])
+			f := func(x float64) float64 {
+				ex := math.Exp(x)
+				return ex*(delta*delta-phi2-v-ex)/(2*(phi2+v+ex)*(phi2+v+ex)) - (x-a)/(tau*tau)
+			}
+			A := a
+			B := 0.0
+			if delta*delta > phi2+v {
+				B = math.Log(delta*delta - phi2 - v)
+			} else {
+				k := 1.0
+				for f(a-k*tau) < 0 {
+					k++
+				}
+				B = a - k*tau
+			}
+			fA := f(A)
+			fB := f(B)
+			for i := 0; math.Abs(B-A) > epsilon && i < maxIterations; i++ {
+				C := A + (A-B)*fA/(fB-fA)
+				fC := f(C)
+				if fC*fB <= 0 {
+					A = B
+					fA = fB
+				} else {
+					fA /= 2
+				}
+				B = C
+				fB = fC
+			}
+			sigma[id] = math.Exp(A / 2)
+			phiStar := math.Sqrt(phi2 + sigma[id]*sigma[id])
+			phiNext[id] = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+			muNext[id] = mu[id] + phiNext[id]*phiNext[id]*sum
+		}
+		mu, phi = muNext, phiNext
+	}
+	ratings := make(map[uint64]float64, len(ids))
+	deviations := make(map[uint64]float64, len(ids))
+	for _, id := range ids {
+		ratings[id] = scale*mu[id] + 1500
+		deviations[id] = scale * phi[id]
+	}
+	return ratings, deviations
+}
+
+func BradleyTerry(graph map[uint64]map[uint64]float64, tolerance float64) map[uint64]float64 {
+	return BradleyTerryFrom(graph, tolerance, nil)
+}
+
+func BradleyTerryFrom(graph map[uint64]map[uint64]float64, tolerance float64, prev map[uint64]float64) map[uint64]float64 {
+	ids := vertices(graph)
+	n := len(ids)
+	if n == 0 {
+		return map[uint64]float64{}
+	}
+	idToIndex := make(map[uint64]int, n)
+	for i, id := range ids {
+		idToIndex[id] = i
+	}
+	// w - amount of wins
+	w := make([]float64, n)
+	// games - amount of comparisons between every two vertices
+	games := make([]map[int]float64, n)
+	for i := 0; i < n; i++ {
+		games[i] = map[int]float64{}
+	}
+	for loser := range graph {
+		for winner, count := range graph[loser] {
+			if count <= 0 || loser == winner {
+				continue
+			}
+			i := idToIndex[winner]
+			j := idToIndex[loser]
+			w[i] += float64(count)
+			games[i][j] += float64(count)
+			games[j][i] += float64(count)
+		}
+	}
+	// every vertex additionally wins and loses once against a virtual
+	// vertex of average strength, so that the estimate stays finite for
+	// vertices that have never won or never lost
+	ref := 1 / float64(n)
+	p := make([]float64, n)
+	sum := 0.0
+	for i, id := range ids {
+		r, ok := prev[id]
+		if !ok || r <= 0 {
+			r = ref
+		}
+		p[i] = r
+		sum += r
+	}
+	for i := 0; i < n; i++ {
+		p[i] /= sum
+	}
+	pp := make([]float64, n)
+	for k := 0; k < maxIterations; k++ {
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			d := 2 / (p[i] + ref)
+			for j, count := range games[i] {
+				d += count / (p[i] + p[j])
+			}
+			pp[i] = (w[i] + 1) / d
+			sum += pp[i]
+		}
+		delta := 0.0
+		for i := 0; i < n; i++ {
+			pp[i] /= sum
+			delta += math.Abs(pp[i] - p[i])
+		}
+		p, pp = pp, p
+		if delta < tolerance {
+			break
+		}
+	}
+	res := make(map[uint64]float64, n)
+	for i, id := range ids {
+		res[id] = p[i]
+	}
+	return res
+}
+
+// expand splits the weights of the edges into single votes in a stable order
+func expand(graph map[uint64]map[uint64]float64, ids []uint64) []Vote {
+	votes := []Vote(nil)
+	for _, loser := range ids {
+		winners := make([]uint64, 0, len(graph[loser]))
+		for winner := range graph[loser] {
+			if winner == loser {
+				continue
+			}
+			winners = append(winners, winner)
+		}
+		slices.Sort(winners)
+		for _, winner := range winners {
+			w := graph[loser][winner]
+			for ; w >= 1; w-- {
+				votes = append(votes, Vote{loser, winner, 1})
+			}
+			if w > 0 {
+				votes = append(votes, Vote{loser, winner, w})
+			}
+		}
+	}
+	return votes
+}
+
+func vertices(graph map[uint64]map[uint64]float64) []uint64 {
+	seen := make(map[uint64]struct{}, len(graph))
+	ids := make([]uint64, 0, len(graph))
+	for id1 := range graph {
+		if _, ok := seen[id1]; !ok {
+			seen[id1] = struct{}{}
+			ids = append(ids, id1)
+		}
+		for id2 := range graph[id1] {
+			if _, ok := seen[id2]; !ok {
+				seen[id2] = struct{}{}
+				ids = append(ids, id2)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func Comparisons(graph map[uint64]map[uint64]float64) map[uint64]int {
+	ids := vertices(graph)
+	sum := make(map[uint64]float64, len(ids))
+	for from := range graph {
+		for to, count := range graph[from] {
+			if count <= 0 || from == to {
+				continue
+			}
+			sum[from] += count
+			sum[to] += count
+		}
+	}
+	res := make(map[uint64]int, len(ids))
+	for _, id := range ids {
+		res[id] = int(math.Round(sum[id]))
+	}
+	return res
+}
+
+func Bootstrap(
+	graph map[uint64]map[uint64]float64,
+	samples int,
+	alpha float64,
+	intn func(n int) int,
+	rank func(graph map[uint64]map[uint64]float64) (map[uint64]float64, error),
+) (map[uint64]float64, map[uint64]float64, error) {
+	ids := vertices(graph)
+	votes := expand(graph, ids)
+	estimates := make(map[uint64][]float64, len(ids))
+	for s := 0; s < samples; s++ {
+		sample := make(map[uint64]map[uint64]float64, len(ids))
+		for _, id := range ids {
+			sample[id] = map[uint64]float64{}
+		}
+		for range votes {
+			v := votes[intn(len(votes))]
+			sample[v.Loser][v.Winner] += v.Weight
+		}
+		vect, err := rank(sample)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range ids {
+			estimates[id] = append(estimates[id], vect[id])
+		}
+	}
+	low := make(map[uint64]float64, len(ids))
+	high := make(map[uint64]float64, len(ids))
+	for _, id := range ids {
+		est := estimates[id]
+		if len(est) == 0 {
+			continue
+		}
+		slices.Sort(est)
+		low[id] = percentile(est, alpha/2)
+		high[id] = percentile(est, 1-alpha/2)
+	}
+	return low, high, nil
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
@@ -0,0 +1,312 @@
+package ranking_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/zitryss/aye-and-nay/internal/testing"
+	"github.com/zitryss/aye-and-nay/pkg/ranking"
+)
+
+var (
+	unit        = flag.Bool("unit", false, "")
+	integration = flag.Bool("int", false, "")
+	ci          = flag.Bool("ci", false, "")
+)
+
+func graph() map[uint64]map[uint64]float64 {
+	edgs := map[uint64]map[uint64]float64{}
+	edgs[0x7C31] = map[uint64]float64{}
+	edgs[0x15AE] = map[uint64]float64{}
+	edgs[0xE5F0] = map[uint64]float64{}
+	edgs[0x9D0B] = map[uint64]float64{}
+	edgs[0x7C31][0x15AE] += 3
+	edgs[0x7C31][0xE5F0] += 1
+	edgs[0x15AE][0xE5F0] += 2
+	edgs[0xE5F0][0x7C31] += 1
+	return edgs
+}
+
+func TestElo(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x4B2E][0xA7F3]++
+		got := ranking.Elo(edgs, 32)
+		want := map[uint64]float64{}
+		want[0x4B2E] = 1484
+		want[0xA7F3] = 1516
+		assert.InDeltaMapValues(t, want, got, TOLERANCE)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		got := ranking.Elo(graph(), 32)
+		want := map[uint64]float64{}
+		want[0x7C31] = 1460.5875216057595
+		want[0x15AE] = 1517.0285355022704
+		want[0xE5F0] = 1522.38394289197
+		want[0x9D0B] = 1500
+		assert.InDeltaMapValues(t, want, got, 0.000000001)
+	})
+	t.Run("Positive3", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x4B2E][0xA7F3] += 0.5
+		edgs[0xA7F3][0x4B2E] += 0.5
+		got := ranking.Elo(edgs, 32)
+		want := map[uint64]float64{}
+		want[0x4B2E] = 1500
+		want[0xA7F3] = 1500
+		assert.InDeltaMapValues(t, want, got, 1)
+		assert.InDelta(t, 3000, got[0x4B2E]+got[0xA7F3], TOLERANCE)
+	})
+}
+
+func TestEloUpdate(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		prev := map[uint64]float64{}
+		prev[0x4B2E] = 1500
+		prev[0xA7F3] = 1500
+		votes := []ranking.Vote{{0x4B2E, 0xA7F3, 1}}
+		got := ranking.EloUpdate(prev, votes, 32)
+		want := map[uint64]float64{}
+		want[0x4B2E] = 1484
+		want[0xA7F3] = 1516
+		assert.InDeltaMapValues(t, want, got, TOLERANCE)
+		assert.Equal(t, 1500.0, prev[0x4B2E])
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		edgs := graph()
+		want := ranking.Elo(edgs, 32)
+		edgs[0x9D0B][0x7C31]++
+		got1 := ranking.Elo(edgs, 32)
+		got2 := ranking.EloUpdate(want, []ranking.Vote{{0x9D0B, 0x7C31, 1}}, 32)
+		assert.Greater(t, got1[0x7C31], want[0x7C31])
+		assert.Greater(t, got2[0x7C31], want[0x7C31])
+		assert.Less(t, got2[0x9D0B], want[0x9D0B])
+	})
+}
+
+func TestGlicko2(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x4B2E][0xA7F3]++
+		got1, got2 := ranking.Glicko2(edgs, 0.5)
+		want1 := map[uint64]float64{}
+		want1[0x4B2E] = 1356.5804958463739
+		want1[0xA7F3] = 1643.4195041536261
+		want2 := map[uint64]float64{}
+		want2[0x4B2E] = 293.05479317297784
+		want2[0xA7F3] = 293.05479317297784
+		assert.InDeltaMapValues(t, want1, got1, 0.000000001)
+		assert.InDeltaMapValues(t, want2, got2, 0.000000001)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		got1, got2 := ranking.Glicko2(graph(), 0.5)
+		want1 := map[uint64]float64{}
+		want1[0x7C31] = 1327.4531600275873
+		want1[0x15AE] = 1544.5670333578892
+		want1[0xE5F0] = 1636.9328448735787
+		want1[0x9D0B] = 1500
+		want2 := map[uint64]float64{}
+		want2[0x7C31] = 187.93686409252857
+		want2[0x15AE] = 181.51876498972936
+		want2[0xE5F0] = 198.27129973438065
+		want2[0x9D0B] = 350
+		assert.InDeltaMapValues(t, want1, got1, 0.000001)
+		assert.InDeltaMapValues(t, want2, got2, 0.000001)
+	})
+	t.Run("Positive3", func(t *testing.T) {
+		t.Parallel()
+		// a win over the stronger opponent is worth more
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x1C5D] = map[uint64]float64{}
+		edgs[0x90E8] = map[uint64]float64{}
+		edgs[0xA7F3][0x4B2E] += 5
+		edgs[0x4B2E][0x1C5D]++
+		edgs[0xA7F3][0x90E8]++
+		got, _ := ranking.Glicko2(edgs, 0.5)
+		assert.Greater(t, got[0x1C5D], got[0x90E8])
+	})
+	t.Run("Positive4", func(t *testing.T) {
+		t.Parallel()
+		// more votes narrow the deviation
+		edgs1 := map[uint64]map[uint64]float64{}
+		edgs1[0x4B2E] = map[uint64]float64{0xA7F3: 2}
+		edgs1[0xA7F3] = map[uint64]float64{0x4B2E: 1}
+		edgs2 := map[uint64]map[uint64]float64{}
+		edgs2[0x4B2E] = map[uint64]float64{0xA7F3: 20}
+		edgs2[0xA7F3] = map[uint64]float64{0x4B2E: 10}
+		_, got1 := ranking.Glicko2(edgs1, 0.5)
+		_, got2 := ranking.Glicko2(edgs2, 0.5)
+		assert.Less(t, got2[0x4B2E], got1[0x4B2E])
+	})
+}
+
+func TestBradleyTerry(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x4B2E][0xA7F3]++
+		edgs[0xA7F3][0x4B2E]++
+		got := ranking.BradleyTerry(edgs, 0.000000000001)
+		want := map[uint64]float64{}
+		want[0x4B2E] = 0.5
+		want[0xA7F3] = 0.5
+		assert.InDeltaMapValues(t, want, got, 0.000000001)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		got := ranking.BradleyTerry(graph(), 0.000000000001)
+		want := map[uint64]float64{}
+		want[0x7C31] = 0.11216141500395606
+		want[0x15AE] = 0.262664533544119
+		want[0xE5F0] = 0.38239529206244244
+		want[0x9D0B] = 0.24277875938948248
+		assert.InDeltaMapValues(t, want, got, 0.000000001)
+	})
+	t.Run("Positive3", func(t *testing.T) {
+		t.Parallel()
+		got := ranking.BradleyTerry(map[uint64]map[uint64]float64{}, 0.000000000001)
+		assert.Empty(t, got)
+	})
+}
+
+func TestBradleyTerryFrom(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	want := ranking.BradleyTerry(graph(), 0.000000000001)
+	prev := map[uint64]float64{}
+	prev[0x7C31] = 0.7
+	prev[0x15AE] = 0.1
+	got := ranking.BradleyTerryFrom(graph(), 0.000000000001, prev)
+	assert.InDeltaMapValues(t, want, got, 0.000000001)
+}
+
+func TestComparisons(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	got := ranking.Comparisons(graph())
+	want := map[uint64]int{}
+	want[0x7C31] = 5
+	want[0x15AE] = 5
+	want[0xE5F0] = 4
+	want[0x9D0B] = 0
+	assert.Equal(t, want, got)
+	edgs := graph()
+	edgs[0x9D0B][0x7C31] += 0.5
+	edgs[0x7C31][0x9D0B] += 0.5
+	got = ranking.Comparisons(edgs)
+	want[0x7C31] = 6
+	want[0x9D0B] = 1
+	assert.Equal(t, want, got)
+}
+
+func TestBootstrap(t *testing.T) {
+	if !*unit {
+		t.Skip()
+	}
+	rank := func(edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+		return ranking.BradleyTerry(edgs, 0.000000000001), nil
+	}
+	t.Run("Positive1", func(t *testing.T) {
+		t.Parallel()
+		edgs := map[uint64]map[uint64]float64{}
+		edgs[0x4B2E] = map[uint64]float64{}
+		edgs[0xA7F3] = map[uint64]float64{}
+		edgs[0x4B2E][0xA7F3]++
+		got1, got2, err := ranking.Bootstrap(edgs, 10, 0.05, rand.New(rand.NewSource(1)).Intn, rank)
+		assert.NoError(t, err)
+		want, _ := rank(edgs)
+		assert.InDeltaMapValues(t, want, got1, TOLERANCE)
+		assert.InDeltaMapValues(t, want, got2, TOLERANCE)
+	})
+	t.Run("Positive2", func(t *testing.T) {
+		t.Parallel()
+		got1, got2, err := ranking.Bootstrap(graph(), 100, 0.05, rand.New(rand.NewSource(1)).Intn, rank)
+		assert.NoError(t, err)
+		assert.Len(t, got1, 4)
+		assert.Len(t, got2, 4)
+		for id := range got1 {
+			assert.Less(t, got1[id], got2[id])
+		}
+	})
+	t.Run("Positive3", func(t *testing.T) {
+		t.Parallel()
+		got1, got2, err := ranking.Bootstrap(graph(), 0, 0.05, rand.New(rand.NewSource(1)).Intn, rank)
+		assert.NoError(t, err)
+		assert.Empty(t, got1)
+		assert.Empty(t, got2)
+	})
+	t.Run("Negative", func(t *testing.T) {
+		t.Parallel()
+		fn := func(edgs map[uint64]map[uint64]float64) (map[uint64]float64, error) {
+			return nil, errors.New("no luck")
+		}
+		_, _, err := ranking.Bootstrap(graph(), 10, 0.05, rand.New(rand.NewSource(1)).Intn, fn)
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkRanking(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		edgs := map[uint64]map[uint64]float64{}
+		for j := 0; j < n; j++ {
+			node := uint64(j)
+			edgs[node] = map[uint64]float64{}
+		}
+		for j := 0; j < 10*n; j++ {
+			from := uint64(rand.Intn(n))
+			to := uint64(rand.Intn(n))
+			edgs[from][to]++
+		}
+		b.Run(fmt.Sprintf("elo-%d", n), func(b *testing.B) {
+			for j := 0; j < b.N; j++ {
+				ranking.Elo(edgs, 32)
+			}
+		})
+		b.Run(fmt.Sprintf("glicko2-%d", n), func(b *testing.B) {
+			for j := 0; j < b.N; j++ {
+				ranking.Glicko2(edgs, 0.5)
+			}
+		})
+		b.Run(fmt.Sprintf("bradleyterry-%d", n), func(b *testing.B) {
+			for j := 0; j < b.N; j++ {
+				ranking.BradleyTerry(edgs, 0.000001)
+			}
+		})
+	}
+}